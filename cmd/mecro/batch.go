@@ -0,0 +1,188 @@
+package main
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime/debug"
+	"strings"
+	"unicode/utf8"
+	"github.com/zyedidia/micro/v2/internal/action"
+	"github.com/zyedidia/micro/v2/internal/buffer"
+	"github.com/zyedidia/micro/v2/internal/config"
+	ulua "github.com/zyedidia/micro/v2/internal/lua"
+	"github.com/zyedidia/micro/v2/internal/screen"
+	"github.com/zyedidia/micro/v2/internal/shell"
+	isatty "github.com/mattn/go-isatty"
+	dmp "github.com/sergi/go-diff/diffmatchpatch"
+)
+type commandList []string
+func (c *commandList) String() string {
+	return strings.Join(*c, "; ")
+}
+func (c *commandList) Set(v string) error {
+	*c = append(*c, v)
+	return nil
+}
+func RunBatch(args []string) (status int) {
+	defer func() {
+		if err := recover(); err != nil {
+			fmt.Fprintf(os.Stderr, "Mecro encountered an error: %v\n%s", err, debug.Stack())
+			status = 1
+		}
+	}()
+	if len(flagCommands) == 0 && *flagScript == "" {
+		fmt.Fprintln(os.Stderr, "No commands given, use -c or -script")
+		return 1
+	}
+	var script []byte
+	if *flagScript != "" {
+		var err error
+		if script, err = ioutil.ReadFile(*flagScript); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	for k, v := range map[string]interface{}{"autosave": 0.0, "backup": false, "savecursor": false, "saveundo": false} {
+		config.GlobalSettings[k] = v
+		config.VolatileSettings[k] = true
+	}
+	if _, err := screen.InitSimScreen(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	stdin := len(args) == 0
+	if stdin && isatty.IsTerminal(os.Stdin.Fd()) {
+		fmt.Fprintln(os.Stderr, "No files given")
+		return 1
+	}
+	if err := config.LoadAllPlugins(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	action.InitBindings()
+	action.InitCommands()
+	if err := config.InitColorscheme(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if err := config.RunPluginFn("preinit"); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	action.InitGlobals()
+	buffer.SetMessager(action.InfoBar)
+	var bufs []*buffer.Buffer
+	if stdin {
+		input, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error reading from stdin:", err)
+			return 1
+		}
+		bufs = append(bufs, buffer.NewBufferFromString(string(input), "", buffer.BTDefault))
+	}
+	for _, path := range args {
+		b, err := buffer.NewBufferFromFile(path, buffer.BTDefault)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+			continue
+		}
+		bufs = append(bufs, b)
+	}
+	if len(bufs) == 0 {
+		return status
+	}
+	action.InitTabs(bufs[:1])
+	for _, fn := range []string{"init", "postinit"} {
+		if err := config.RunPluginFn(fn); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+	var h *action.BufPane
+	changed := 0
+	for _, b := range bufs {
+		if h == nil {
+			h = batchPane(b)
+		} else {
+			h.OpenBuffer(b)
+			batchPane(b)
+		}
+		if h == nil {
+			fmt.Fprintln(os.Stderr, "Could not open a pane for", b.GetName())
+			return 1
+		}
+		orig := string(b.Bytes())
+		for _, cmd := range flagCommands {
+			h.HandleCommand(cmd)
+			if err := batchResult(); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s: %s\n", b.GetName(), cmd, strings.TrimSpace(err.Error()))
+				status = 1
+			}
+		}
+		if script != nil {
+			err := ulua.LoadFile("batch", *flagScript, script)
+			if err == nil {
+				err = batchResult()
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", b.GetName(), strings.TrimSpace(err.Error()))
+				status = 1
+			}
+		}
+		if stdin {
+			os.Stdout.Write(b.Bytes())
+			continue
+		}
+		ins, del := diffStat(orig, string(b.Bytes()))
+		if ins == 0 && del == 0 {
+			continue
+		}
+		changed++
+		msg := fmt.Sprintf("%s: +%d -%d lines", b.GetName(), ins, del)
+		if b.Modified() {
+			msg += " (not saved)"
+		}
+		fmt.Println(msg)
+	}
+	if !stdin {
+		fmt.Printf("%d of %d files changed\n", changed, len(bufs))
+	}
+	return status
+}
+func batchPane(b *buffer.Buffer) *action.BufPane {
+	t := action.MainTab()
+	for i, p := range t.Panes {
+		if bp, ok := p.(*action.BufPane); ok && bp.Buf == b {
+			t.SetActive(i)
+			return bp
+		}
+	}
+	return nil
+}
+func batchResult() error {
+	for len(shell.Jobs) > 0 {
+		f := <-shell.Jobs
+		f.Function(f.Output, f.Args)
+	}
+	defer action.InfoBar.Reset()
+	if action.InfoBar.HasPrompt || action.InfoBar.HasYN {
+		action.InfoBar.AbortCommand()
+		return errors.New("command needs interactive input")
+	}
+	if action.InfoBar.HasError {
+		return errors.New(action.InfoBar.Msg)
+	}
+	return nil
+}
+func diffStat(a, b string) (int, int) {
+	differ := dmp.New()
+	ca, cb, _ := differ.DiffLinesToChars(a, b)
+	ins, del := 0, 0
+	for _, d := range differ.DiffMain(ca, cb, false) {
+		switch d.Type {
+		case dmp.DiffInsert:
+			ins += utf8.RuneCountInString(d.Text)
+		case dmp.DiffDelete:
+			del += utf8.RuneCountInString(d.Text)
+		}
+	}
+	return ins, del
+}
\ No newline at end of file
@@ -0,0 +1,48 @@
+package main
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+func TestBatchExitStatus(t *testing.T) {
+	if args := os.Getenv("MECRO_TEST_ARGS"); args != "" {
+		os.Args = append([]string{"mecro"}, strings.Split(args, "\n")...)
+		main()
+		return
+	}
+	dir := t.TempDir()
+	file := filepath.Join(dir, "f.txt")
+	if err := ioutil.WriteFile(file, []byte("abc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		cmd    string
+		status int
+	}{
+		{"eval 1+1", 0},
+		{"eval error('boom')", 1},
+		{"eval 1+", 1},
+		{"eval nosuchfunction()", 1},
+		{"nosuchcommand", 1},
+	}
+	for _, tt := range tests {
+		cmd := exec.Command(os.Args[0], "-test.run=TestBatchExitStatus")
+		args := []string{"-config-dir", filepath.Join(dir, "config"), "-batch", "-c", tt.cmd, file}
+		cmd.Env = append(os.Environ(), "MECRO_TEST_ARGS="+strings.Join(args, "\n"))
+		out, err := cmd.CombinedOutput()
+		status := 0
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			status = exit.ExitCode()
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if status != tt.status {
+			t.Errorf("%q exited with status %d, want %d\n%s", tt.cmd, status, tt.status, out)
+		}
+	}
+}
\ No newline at end of file
@@ -0,0 +1,91 @@
+package main
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	isatty "github.com/mattn/go-isatty"
+	"github.com/zyedidia/micro/v2/internal/buffer"
+	"github.com/zyedidia/micro/v2/internal/compression"
+	"github.com/zyedidia/micro/v2/internal/config"
+	"github.com/zyedidia/micro/v2/internal/export"
+	"github.com/zyedidia/micro/v2/internal/util"
+)
+func CatFiles(args []string) int {
+	if err := config.InitColorscheme(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	colorterm := os.Getenv("COLORTERM")
+	export.TrueColor = colorterm == "truecolor" || colorterm == "24bit"
+	if len(args) == 0 {
+		args = []string{"-"}
+	}
+	color := isatty.IsTerminal(os.Stdout.Fd())
+	status := 0
+	for _, path := range args {
+		out, err := catFile(path, color)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+			continue
+		}
+		os.Stdout.WriteString(out)
+	}
+	return status
+}
+func catFile(path string, color bool) (string, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		path = ""
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return "", err
+	}
+	r, codec, err := compression.Open(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	if data, err = ioutil.ReadAll(r); err != nil {
+		return "", err
+	}
+	if !color {
+		return string(data), nil
+	}
+	if codec != nil {
+		path = strings.TrimSuffix(path, codec.Ext())
+	}
+	absPath, _ := filepath.Abs(path)
+	settings := config.DefaultCommonSettings()
+	for k, v := range config.GlobalSettings {
+		if _, ok := config.DefaultGlobalOnlySettings[k]; !ok {
+			settings[k] = v
+		}
+	}
+	config.InitLocalSettings(settings, absPath)
+	lines := strings.Split(strings.Replace(string(data), "\r\n", "\n", -1), "\n")
+	limit := len(lines)
+	if detectlimit := util.IntOpt(settings["detectlimit"]); detectlimit > 0 && limit > detectlimit {
+		limit = detectlimit
+	}
+	byteLines := make([][]byte, limit)
+	for i := range byteLines {
+		byteLines[i] = []byte(lines[i])
+	}
+	def, _ := buffer.DetectSyntax(settings["filetype"].(string), path, byteLines)
+	if def != nil {
+		settings["filetype"] = def.FileType
+		config.InitLocalSettings(settings, absPath)
+	}
+	if !settings["syntax"].(bool) || settings["filetype"] == "off" {
+		def = nil
+	}
+	rng := export.Range{EndX: -1, EndY: len(lines) - 1}
+	return export.Render("ansi", def, lines, rng, util.IntOpt(settings["tabsize"]), config.GetGlobalOption("exportruler").(bool))
+}
\ No newline at end of file
@@ -12,6 +12,7 @@ import (
 	"github.com/zyedidia/micro/v2/internal/screen"
 	"github.com/zyedidia/micro/v2/internal/shell"
 	"github.com/zyedidia/micro/v2/internal/util"
+	"github.com/zyedidia/micro/v2/internal/watcher"
 )
 func init() {
 	ulua.L = lua.NewState()
@@ -115,6 +116,8 @@ func luaImportMicroBuffer() *lua.LTable {
 	ulua.L.SetField(pkg, "BTScratch", luar.New(ulua.L, buffer.BTScratch.Kind))
 	ulua.L.SetField(pkg, "BTRaw", luar.New(ulua.L, buffer.BTRaw.Kind))
 	ulua.L.SetField(pkg, "BTInfo", luar.New(ulua.L, buffer.BTInfo.Kind))
+	ulua.L.SetField(pkg, "BTQuickfix", luar.New(ulua.L, buffer.BTQuickfix.Kind))
+	ulua.L.SetField(pkg, "BTList", luar.New(ulua.L, buffer.BTList.Kind))
 	ulua.L.SetField(pkg, "NewBuffer", luar.New(ulua.L, func(text, path string) *buffer.Buffer {
 		return buffer.NewBufferFromString(text, path, buffer.BTDefault)
 	}))
@@ -122,6 +125,9 @@ func luaImportMicroBuffer() *lua.LTable {
 		return buffer.NewBufferFromFile(path, buffer.BTDefault)
 	}))
 	ulua.L.SetField(pkg, "ByteOffset", luar.New(ulua.L, buffer.ByteOffset))
+	ulua.L.SetField(pkg, "OpenBuffers", luar.New(ulua.L, func() []*buffer.Buffer {
+		return buffer.OpenBuffers
+	}))
 	ulua.L.SetField(pkg, "Log", luar.New(ulua.L, buffer.WriteLog))
 	ulua.L.SetField(pkg, "LogBuf", luar.New(ulua.L, buffer.GetLogBuf))
 	return pkg
@@ -137,6 +143,8 @@ func luaImportMicroUtil() *lua.LTable {
 	ulua.L.SetField(pkg, "SemVersion", luar.New(ulua.L, util.SemVersion))
 	ulua.L.SetField(pkg, "HttpRequest", luar.New(ulua.L, util.HttpRequest))
 	ulua.L.SetField(pkg, "CharacterCountInString", luar.New(ulua.L, util.CharacterCountInString))
+	ulua.L.SetField(pkg, "Watch", luar.New(ulua.L, watcher.Watch))
+	ulua.L.SetField(pkg, "Unwatch", luar.New(ulua.L, watcher.Unwatch))
 	ulua.L.SetField(pkg, "RuneStr", luar.New(ulua.L, func(r rune) string {
 		return string(r)
 	}))
@@ -21,20 +21,34 @@ import (
 	"github.com/zyedidia/micro/v2/internal/buffer"
 	"github.com/zyedidia/micro/v2/internal/clipboard"
 	"github.com/zyedidia/micro/v2/internal/config"
+	"github.com/zyedidia/micro/v2/internal/remote"
 	"github.com/zyedidia/micro/v2/internal/screen"
 	"github.com/zyedidia/micro/v2/internal/shell"
 	"github.com/zyedidia/micro/v2/internal/util"
+	"github.com/zyedidia/micro/v2/internal/watcher"
+	"github.com/zyedidia/micro/v2/pkg/highlight"
 	"github.com/zyedidia/tcell/v2"
 )
 var (
-	flagVersion   = flag.Bool("version", false, "Show the version number and information")
-	flagConfigDir = flag.String("config-dir", "", "Specify a custom location for the configuration directory")
-	flagOptions   = flag.Bool("options", false, "Show all option help")
-	flagDebug     = flag.Bool("debug", false, "Enable debug mode (prints debug info to ./log.txt)")
-	flagProfile   = flag.Bool("profile", false, "Enable CPU profiling (writes profile info to ./mecro.prof)")
-	flagPlugin    = flag.String("plugin", "", "Plugin command")
-	flagClean     = flag.Bool("clean", false, "Clean configuration directory")
-	optionFlags   map[string]*string
+	flagVersion    = flag.Bool("version", false, "Show the version number and information")
+	flagConfigDir  = flag.String("config-dir", "", "Specify a custom location for the configuration directory")
+	flagOptions    = flag.Bool("options", false, "Show all option help")
+	flagDebug      = flag.Bool("debug", false, "Enable debug mode (prints debug info to ./log.txt)")
+	flagProfile    = flag.Bool("profile", false, "Enable CPU profiling (writes profile info to ./mecro.prof)")
+	flagPlugin     = flag.String("plugin", "", "Plugin command")
+	flagClean      = flag.Bool("clean", false, "Clean configuration directory")
+	flagDiff       = flag.Bool("diff", false, "Show the two given files side by side in a diff view")
+	flagCat        = flag.Bool("cat", false, "Print the given files with syntax highlighting and exit")
+	flagPager      = flag.Bool("pager", false, "Open the given files read-only with pager keybindings")
+	flagBatch      = flag.Bool("batch", false, "Run commands against the given files without a terminal")
+	flagScript     = flag.String("script", "", "Lua script to run against each file in batch mode")
+	flagCommands   commandList
+	flagRemote     = flag.Bool("remote", false, "Open the given files in a running instance")
+	flagRemoteWait = flag.Bool("remote-wait", false, "Like -remote, but wait until the files are closed or hidden")
+	flagRemoteEval = flag.Bool("remote-eval", false, "Run the given commands in a running instance")
+	flagGrammar    = flag.String("import-grammar", "", "Convert a TextMate grammar to a syntax file")
+	flagTheme      = flag.String("import-theme", "", "Convert a VS Code, tmTheme or base16 theme to a colorscheme")
+	optionFlags    map[string]*string
 	sigterm chan os.Signal
 	sighup  chan os.Signal
 	timerChan chan func()
@@ -46,6 +60,30 @@ func InitFlags() {
 		fmt.Println("    \tCleans the configuration directory")
 		fmt.Println("-config-dir dir")
 		fmt.Println("    \tSpecify a custom location for the configuration directory")
+		fmt.Println("-diff FILE1 FILE2")
+		fmt.Println("    \tShow two files side by side with their differences aligned")
+		fmt.Println("-cat [FILE]...")
+		fmt.Println("    \tPrint files (or stdin) with syntax highlighting to the terminal and exit")
+		fmt.Println("-pager [FILE]...")
+		fmt.Println("    \tOpen files (or stdin) read-only with less-like keybindings")
+		fmt.Println("-batch -c COMMAND... [-script FILE] [FILE]...")
+		fmt.Println("    \tRun each command (and the Lua script) against every file (or stdin)")
+		fmt.Println("    \twithout a terminal and print a summary of the changes. Exits with")
+		fmt.Println("    \tstatus 1 if a command fails or the arguments are wrong")
+		fmt.Println("-remote [FILE]:LINE:COL...")
+		fmt.Println("    \tOpen files in the running instance, or start a new one if there is none")
+		fmt.Println("-remote-wait [FILE]:LINE:COL...")
+		fmt.Println("    \tLike -remote, but only exit once the files are closed or no longer")
+		fmt.Println("    \tshown in any pane")
+		fmt.Println("    \t(use EDITOR=\"mecro -remote-wait\" for git commit messages)")
+		fmt.Println("-remote-eval COMMAND...")
+		fmt.Println("    \tRun commands in the current pane of the running instance")
+		fmt.Println("-import-grammar FILE")
+		fmt.Println("    \tConvert a TextMate grammar (.tmLanguage.json) to a syntax file")
+		fmt.Println("    \tand print it to stdout")
+		fmt.Println("-import-theme FILE")
+		fmt.Println("    \tConvert a VS Code (.json), TextMate (.tmTheme) or base16 (.yaml)")
+		fmt.Println("    \ttheme to a .micro colorscheme and print it to stdout")
 		fmt.Println("[FILE]:LINE:COL (if the `parsecursor` option is enabled)")
 		fmt.Println("+LINE:COL")
 		fmt.Println("    \tSpecify a line and column to start the cursor at when opening a buffer")
@@ -77,10 +115,11 @@ func InitFlags() {
 		fmt.Println("    \tFor example: `mecro -syntax off file.c`")
 		fmt.Println("\nUse `mecro -options` to see the full list of configuration options")
 	}
-	optionFlags = make(map[string]*string)
+	optionFlags    = make(map[string]*string)
 	for k, v := range config.DefaultAllSettings() {
 		optionFlags[k] = flag.String(k, "", fmt.Sprintf("The %s option. Default value: '%v'.", k, v))
 	}
+	flag.Var(&flagCommands, "c", "Command to run in batch mode (may be repeated)")
 	flag.Parse()
 	if *flagVersion {
 		fmt.Println("Version:", util.Version)
@@ -101,10 +140,32 @@ func InitFlags() {
 		}
 		os.Exit(0)
 	}
+	if *flagDiff && len(flag.Args()) != 2 {
+		fmt.Fprintln(os.Stderr, "-diff needs exactly two files")
+		os.Exit(1)
+	}
+	if *flagGrammar != "" {
+		ConvertFile(*flagGrammar, highlight.ConvertTextMate)
+	}
+	if *flagTheme != "" {
+		ConvertFile(*flagTheme, config.ConvertTheme)
+	}
 	if util.Debug == "OFF" && *flagDebug {
 		util.Debug = "ON"
 	}
 }
+func ConvertFile(path string, convert func([]byte) ([]byte, error)) {
+	data, err := ioutil.ReadFile(path)
+	if err == nil {
+		data, err = convert(data)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error converting", path+":", err)
+		os.Exit(1)
+	}
+	os.Stdout.Write(data)
+	os.Exit(0)
+}
 func DoPluginFlags() {
 	if *flagClean || *flagPlugin != "" {
 		config.LoadAllPlugins()
@@ -175,15 +236,26 @@ func LoadInput(args []string) []*buffer.Buffer {
 	}
 	return buffers
 }
+func exit(status int) {
+	action.ReleaseRemoteWaits()
+	remote.Close()
+	os.Exit(status)
+}
 func main() {
 	defer func() {
 		if util.Stdout.Len() > 0 {
 			fmt.Fprint(os.Stdout, util.Stdout.String())
 		}
-		os.Exit(0)
+		exit(0)
 	}()
 	var err error
 	InitFlags()
+	screen.Headless = *flagBatch
+	if *flagRemote || *flagRemoteWait || *flagRemoteEval {
+		if status, ok := RunRemote(flag.Args()); ok {
+			os.Exit(status)
+		}
+	}
 	if *flagProfile {
 		f, err := os.Create("mecro.prof")
 		if err != nil {
@@ -221,19 +293,23 @@ func main() {
 		}
 	}
 	DoPluginFlags()
+	if *flagCat {
+		os.Exit(CatFiles(flag.Args()))
+	}
+	m := clipboard.SetMethod(config.GetGlobalOption("clipboard").(string))
+	clipErr := clipboard.Initialize(m)
+	if *flagBatch {
+		os.Exit(RunBatch(flag.Args()))
+	}
 	err = screen.Init()
 	if err != nil {
 		fmt.Println(err)
 		fmt.Println("Fatal: Mecro could not initialize a Screen.")
 		os.Exit(1)
 	}
-	m := clipboard.SetMethod(config.GetGlobalOption("clipboard").(string))
-	clipErr := clipboard.Initialize(m)
 	defer func() {
 		if err := recover(); err != nil {
-			if screen.Screen != nil {
-				screen.Screen.Fini()
-			}
+			screen.Fini()
 			if e, ok := err.(*lua.ApiError); ok {
 				fmt.Println("Lua API error:", e)
 			} else {
@@ -242,7 +318,7 @@ func main() {
 			for _, b := range buffer.OpenBuffers {
 				b.Backup()
 			}
-			os.Exit(1)
+			exit(1)
 		}
 	}()
 	err = config.LoadAllPlugins()
@@ -264,10 +340,15 @@ func main() {
 	args := flag.Args()
 	b := LoadInput(args)
 	if len(b) == 0 {
-		screen.Screen.Fini()
+		screen.Fini()
 		runtime.Goexit()
 	}
-	action.InitTabs(b)
+	if *flagDiff && len(b) == 2 {
+		action.InitTabs(b[:1])
+		action.MainTab().CurPane().DiffBuf(b[1])
+	} else {
+		action.InitTabs(b)
+	}
 	err = config.RunPluginFn("init")
 	if err != nil {
 		screen.TermMessage(err)
@@ -276,6 +357,14 @@ func main() {
 	if err != nil {
 		screen.TermMessage(err)
 	}
+	if *flagPager {
+		action.InitPager()
+	}
+	if config.GetGlobalOption("server").(bool) {
+		if _, err := remote.Listen(action.HandleRemote); err != nil {
+			log.Println("Could not start server:", err)
+		}
+	}
 	if clipErr != nil {
 		log.Println(clipErr, " or change 'clipboard' option")
 	}
@@ -330,6 +419,8 @@ func DoEvent() {
 			b.AutoSave()
 		}
 	case <-shell.CloseTerms:
+	case path := <-watcher.Events:
+		action.FileChanged(path)
 	case event = <-screen.Events:
 	case <-screen.DrawChan():
 		for len(screen.DrawChan()) > 0 {
@@ -343,17 +434,15 @@ func DoEvent() {
 				b.Fini()
 			}
 		}
-		os.Exit(0)
+		exit(0)
 	case <-sigterm:
 		for _, b := range buffer.OpenBuffers {
 			if !b.Modified() {
 				b.Fini()
 			}
 		}
-		if screen.Screen != nil {
-			screen.Screen.Fini()
-		}
-		os.Exit(0)
+		screen.Fini()
+		exit(0)
 	}
 	if event == nil {
 		return
@@ -366,10 +455,8 @@ func DoEvent() {
 					b.Fini()
 				}
 			}
-			if screen.Screen != nil {
-				screen.Screen.Fini()
-			}
-			os.Exit(0)
+			screen.Fini()
+			exit(0)
 		}
 		return
 	}
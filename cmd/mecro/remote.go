@@ -0,0 +1,27 @@
+package main
+import (
+	"fmt"
+	"os"
+	"github.com/zyedidia/micro/v2/internal/remote"
+)
+func RunRemote(args []string) (int, bool) {
+	req := remote.Request{Command: "open", Args: args, Wait: *flagRemoteWait}
+	if *flagRemoteEval {
+		req.Command = "eval"
+	}
+	req.Dir, _ = os.Getwd()
+	resp, err := remote.Send(req)
+	if err == remote.ErrNoServer && req.Command == "open" {
+		return 0, false
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1, true
+	}
+	fmt.Print(resp.Output)
+	if resp.Error != "" {
+		fmt.Fprintln(os.Stderr, resp.Error)
+		return 1, true
+	}
+	return 0, true
+}
\ No newline at end of file
@@ -1,14 +1,18 @@
 module github.com/zyedidia/micro/v2
 require (
+filippo.io/age v1.1.1
 github.com/blang/semver v3.5.1+incompatible
+github.com/dsnet/compress v0.0.1
 github.com/dustin/go-humanize v1.0.1
 github.com/go-errors/errors v1.5.1
 github.com/kballard/go-shellquote v0.0.0-20180428030007-95032a82bc51
+github.com/klauspost/compress v1.15.15
 github.com/mattn/go-isatty v0.0.20
 github.com/mattn/go-runewidth v0.0.15
 github.com/mitchellh/go-homedir v1.1.0
-github.com/sergi/go-diff v1.3.1
+github.com/sergi/go-diff v1.3.2-0.20230802210424-5b0b94c5c0d3
 github.com/stretchr/testify v1.4.0
+github.com/ulikunitz/xz v0.5.11
 github.com/yuin/gopher-lua v1.1.1
 github.com/zyedidia/clipper v0.1.1
 github.com/zyedidia/glob v0.0.0-20170209203856-dd4023a66dc3
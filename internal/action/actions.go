@@ -149,12 +149,18 @@ func (h *BufPane) MoveCursorDown(n int) {
 	}
 }
 func (h *BufPane) CursorUp() bool {
+	if h.repl != nil && h.replHistoryMove(-1) {
+		return true
+	}
 	h.Cursor.Deselect(true)
 	h.MoveCursorUp(1)
 	h.Relocate()
 	return true
 }
 func (h *BufPane) CursorDown() bool {
+	if h.repl != nil && h.replHistoryMove(1) {
+		return true
+	}
 	h.Cursor.Deselect(true)
 	h.MoveCursorDown(1)
 	h.Relocate()
@@ -461,6 +467,9 @@ func (h *BufPane) SelectToEnd() bool {
 	return true
 }
 func (h *BufPane) InsertNewline() bool {
+	if h.repl != nil {
+		return h.replEnter()
+	}
 	if h.Cursor.HasSelection() {
 		h.Cursor.DeleteSelection()
 		h.Cursor.ResetSelection()
@@ -618,6 +627,9 @@ func (h *BufPane) Autocomplete() bool {
 	if h.Cursor.HasSelection() {
 		return false
 	}
+	if h.repl != nil && !b.HasSuggestions {
+		return b.Autocomplete(h.replComplete)
+	}
 	if h.Cursor.X == 0 {
 		return false
 	}
@@ -630,6 +642,9 @@ func (h *BufPane) Autocomplete() bool {
 		b.CycleAutocomplete(true)
 		return true
 	}
+	if h.lspComplete() {
+		return true
+	}
 	return b.Autocomplete(buffer.BufferComplete)
 }
 func (h *BufPane) CycleAutocompleteBack() bool {
@@ -724,6 +739,8 @@ func (h *BufPane) saveBufToFile(filename string, action string, callback func())
 				} else {
 					h.Buf.Path = filename
 					h.Buf.SetName(filename)
+					updateGitBase(h.Buf)
+					lspSaved(h.Buf)
 					InfoBar.Message("Saved " + filename)
 					if callback != nil {
 						callback()
@@ -750,6 +767,8 @@ func (h *BufPane) saveBufToFile(filename string, action string, callback func())
 	} else {
 		h.Buf.Path = filename
 		h.Buf.SetName(filename)
+		updateGitBase(h.Buf)
+		lspSaved(h.Buf)
 		InfoBar.Message("Saved " + filename)
 		if callback != nil {
 			callback()
@@ -912,6 +931,9 @@ func (h *BufPane) FindPrevious() bool {
 	return true
 }
 func (h *BufPane) DiffNext() bool {
+	if d, _ := h.diffView(); d != nil {
+		return h.diffJump(true)
+	}
 	cur := h.Cursor.Loc.Y
 	dl, err := h.Buf.FindNextDiffLine(cur, true)
 	if err != nil {
@@ -921,6 +943,9 @@ func (h *BufPane) DiffNext() bool {
 	return true
 }
 func (h *BufPane) DiffPrevious() bool {
+	if d, _ := h.diffView(); d != nil {
+		return h.diffJump(false)
+	}
 	cur := h.Cursor.Loc.Y
 	dl, err := h.Buf.FindNextDiffLine(cur, false)
 	if err != nil {
@@ -1251,6 +1276,7 @@ func (h *BufPane) HalfPageDown() bool {
 func (h *BufPane) ToggleDiffGutter() bool {
 	if !h.Buf.Settings["diffgutter"].(bool) {
 		h.Buf.Settings["diffgutter"] = true
+		updateGitBase(h.Buf)
 		h.Buf.UpdateDiff(func(synchronous bool) {
 			screen.Redraw()
 		})
@@ -1320,19 +1346,40 @@ func (h *BufPane) ClearInfo() bool {
 	return true
 }
 func (h *BufPane) ForceQuit() bool {
+	if h.Float() != nil {
+		h.tab.CloseFloat(h)
+		return true
+	}
+	h.diffOff()
 	h.Buf.Close()
 	if len(MainTab().Panes) > 1 {
 		h.Unsplit()
 	} else if len(Tabs.List) > 1 {
 		Tabs.RemoveTab(h.splitID)
 	} else {
-		screen.Screen.Fini()
+		buffer.CloseOpenBuffers()
+		stopLanguageServers()
+		screen.Fini()
 		InfoBar.Close()
 		runtime.Goexit()
 	}
 	return true
 }
 func (h *BufPane) Quit() bool {
+	if h.Float() == nil && len(MainTab().Panes) == 1 && len(Tabs.List) == 1 {
+		if hidden := HiddenModifiedBuffers(); len(hidden) > 0 {
+			InfoBar.YNPrompt(fmt.Sprintf("%d hidden buffers have unsaved changes. Quit anyway? (y,n,esc)", len(hidden)), func(yes, canceled bool) {
+				if !canceled && yes {
+					h.quitBuffer()
+				}
+			})
+			return true
+		}
+	}
+	h.quitBuffer()
+	return true
+}
+func (h *BufPane) quitBuffer() {
 	if h.Buf.Modified() {
 		if config.GlobalSettings["autosave"].(float64) > 0 {
 			h.SaveCB("Quit", func() {
@@ -1352,7 +1399,6 @@ func (h *BufPane) Quit() bool {
 	} else {
 		h.ForceQuit()
 	}
-	return true
 }
 func (h *BufPane) QuitAll() bool {
 	anyModified := false
@@ -1364,7 +1410,8 @@ func (h *BufPane) QuitAll() bool {
 	}
 	quit := func() {
 		buffer.CloseOpenBuffers()
-		screen.Screen.Fini()
+		stopLanguageServers()
+		screen.Fini()
 		InfoBar.Close()
 		runtime.Goexit()
 	}
@@ -1515,7 +1562,11 @@ func (h *BufPane) SpawnMultiCursorUpN(n int) bool {
 		h.Buf.DeselectCursors()
 		c = buffer.NewCursor(h.Buf, buffer.Loc{lastC.X, lastC.Y - n})
 		c.LastVisualX = lastC.LastVisualX
-		c.X = c.GetCharPosInLine(h.Buf.LineBytes(c.Y), c.LastVisualX)
+		if h.Buf.GetCharPos != nil {
+			c.X = h.Buf.GetCharPos(c.Y, c.LastVisualX)
+		} else {
+			c.X = c.GetCharPosInLine(h.Buf.LineBytes(c.Y), c.LastVisualX)
+		}
 		c.Relocate()
 	} else {
 		vloc := h.VLocFromLoc(lastC.Loc)
@@ -9,15 +9,20 @@ import (
 	"regexp"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 	"github.com/zyedidia/json5"
 	"github.com/zyedidia/micro/v2/internal/config"
 	"github.com/zyedidia/micro/v2/internal/screen"
 	"github.com/zyedidia/tcell/v2"
 )
+var keyTimeouts map[string]float64
 var Binder = map[string]func(e Event, action string){
 	"command":  InfoMapEvent,
 	"buffer":   BufMapEvent,
 	"terminal": TermMapEvent,
+	"normal":   vimMapEvent(VimNormal),
+	"insert":   vimMapEvent(VimInsert),
+	"visual":   vimMapEvent(VimVisual),
 }
 func createBindingsIfNotExist(fname string) {
 	if _, e := os.Stat(fname); os.IsNotExist(e) {
@@ -39,6 +44,7 @@ func InitBindings() {
 			screen.TermMessage("Error reading bindings.json:", err.Error())
 		}
 	}
+	keyTimeouts = make(map[string]float64)
 	for p, bind := range Binder {
 		defaults := DefaultBindings(p)
 		for k, v := range defaults {
@@ -52,21 +58,52 @@ func InitBindings() {
 		case map[string]interface{}:
 			bind, ok := Binder[k]
 			if !ok || bind == nil {
+				if _, ok := val["timeout"]; ok {
+					bindTimeout(k, val, Binder["buffer"])
+					continue
+				}
 				screen.TermMessage(fmt.Sprintf("%s is not a valid pane type", k))
 				continue
 			}
 			for e, a := range val {
-				s, ok := a.(string)
-				if !ok {
+				switch a := a.(type) {
+				case string:
+					BindKey(e, a, bind)
+				case map[string]interface{}:
+					bindTimeout(e, a, bind)
+				default:
 					screen.TermMessage("Error reading bindings.json: non-string and non-map entry", k)
-				} else {
-					BindKey(e, s, bind)
 				}
 			}
 		default:
 			screen.TermMessage("Error reading bindings.json: non-string and non-map entry", k)
 		}
 	}
+	setKeymap(config.GetGlobalOption("keymap").(string))
+}
+func bindTimeout(k string, v map[string]interface{}, bind func(e Event, a string)) {
+	ms, ok := v["timeout"].(float64)
+	if !ok || ms < 0 {
+		screen.TermMessage("Error reading bindings.json: invalid key timeout for", k)
+		return
+	}
+	event, err := findEvent(k)
+	if err != nil {
+		screen.TermMessage(err)
+		return
+	}
+	if seq, ok := event.(KeySequenceEvent); ok {
+		keyTimeouts[seq.Name()] = ms
+	} else {
+		keyTimeouts[KeySequenceEvent{[]Event{event}}.Name()] = ms
+	}
+	if a, ok := v["action"]; ok {
+		if s, ok := a.(string); ok {
+			BindKey(k, s, bind)
+		} else {
+			screen.TermMessage("Error reading bindings.json: non-string action for", k)
+		}
+	}
 }
 func BindKey(k, v string, bind func(e Event, a string)) {
 	event, err := findEvent(k)
@@ -79,21 +116,29 @@ func BindKey(k, v string, bind func(e Event, a string)) {
 	}
 	bind(event, v)
 }
-var r = regexp.MustCompile("<(.+?)>")
+var r = regexp.MustCompile("^<(.+?)>")
 func findEvents(k string) (b KeySequenceEvent, ok bool, err error) {
 	var events []Event = nil
 	for len(k) > 0 {
 		groups := r.FindStringSubmatchIndex(k)
 		if len(groups) > 3 {
-			if events == nil {
-				events = make([]Event, 0, 3)
+			name := k[groups[2]:groups[3]]
+			var e Event = LeaderEvent{}
+			if name != "leader" {
+				if e, ok = findSingleEvent(name); !ok {
+					return KeySequenceEvent{}, false, errors.New("Invalid event " + name)
+				}
 			}
-			e, ok := findSingleEvent(k[groups[2]:groups[3]])
+			events = append(events, e)
+			k = k[groups[3]+1:]
+		} else if events != nil {
+			_, size := utf8.DecodeRuneInString(k)
+			e, ok := findSingleEvent(k[:size])
 			if !ok {
-				return KeySequenceEvent{}, false, errors.New("Invalid event " + k[groups[2]:groups[3]])
+				return KeySequenceEvent{}, false, errors.New("Invalid event " + k[:size])
 			}
 			events = append(events, e)
-			k = k[groups[3]+1:]
+			k = k[size:]
 		} else {
 			return KeySequenceEvent{}, false, nil
 		}
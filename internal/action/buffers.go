@@ -0,0 +1,271 @@
+package action
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	luar "layeh.com/gopher-luar"
+	"github.com/zyedidia/micro/v2/internal/buffer"
+	"github.com/zyedidia/micro/v2/internal/config"
+	ulua "github.com/zyedidia/micro/v2/internal/lua"
+	"github.com/zyedidia/micro/v2/internal/screen"
+	"github.com/zyedidia/micro/v2/internal/util"
+)
+func ListedBuffers() []*buffer.Buffer {
+	var bufs []*buffer.Buffer
+	seen := make(map[*buffer.SharedBuffer]int)
+	for _, b := range buffer.OpenBuffers {
+		if b.Type.Kind != buffer.BTDefault.Kind || b.Type.Scratch {
+			continue
+		}
+		if i, ok := seen[b.SharedBuffer]; ok {
+			if !bufferShown(bufs[i]) && bufferShown(b) {
+				bufs[i] = b
+			}
+			continue
+		}
+		seen[b.SharedBuffer] = len(bufs)
+		bufs = append(bufs, b)
+	}
+	sort.SliceStable(bufs, func(i, j int) bool {
+		return bufs[i].Num < bufs[j].Num
+	})
+	return bufs
+}
+func bufferPane(b *buffer.Buffer) (*Tab, *BufPane) {
+	for _, t := range Tabs.List {
+		for _, p := range t.Panes {
+			if bp, ok := p.(*BufPane); ok && bp.Buf == b {
+				return t, bp
+			}
+		}
+	}
+	return nil, nil
+}
+func bufferShown(b *buffer.Buffer) bool {
+	_, bp := bufferPane(b)
+	return bp != nil
+}
+func HiddenModifiedBuffers() []*buffer.Buffer {
+	var bufs []*buffer.Buffer
+	for _, b := range ListedBuffers() {
+		if b.Modified() && !bufferShown(b) {
+			bufs = append(bufs, b)
+		}
+	}
+	return bufs
+}
+func openBufferFile(path string) (*buffer.Buffer, error) {
+	if abs, err := filepath.Abs(path); err == nil {
+		for _, b := range ListedBuffers() {
+			if b.AbsPath == abs && !bufferShown(b) {
+				return b, nil
+			}
+		}
+	}
+	return buffer.NewBufferFromFile(path, buffer.BTDefault)
+}
+func (h *BufPane) releaseBuffer() {
+	b := h.Buf
+	if b.Type.Kind != buffer.BTDefault.Kind || b.Path == "" && !b.Modified() {
+		b.Close()
+	}
+}
+func (h *BufPane) SwitchBuffer(b *buffer.Buffer) {
+	if b == h.Buf {
+		return
+	}
+	if t, bp := bufferPane(b); bp != nil {
+		for i, tab := range Tabs.List {
+			if tab == t {
+				Tabs.SetActive(i)
+			}
+		}
+		t.SetActive(t.GetPane(bp.ID()))
+		return
+	}
+	h.OpenBuffer(b)
+}
+func findBuffer(name string) (*buffer.Buffer, error) {
+	bufs := ListedBuffers()
+	if n, err := strconv.Atoi(name); err == nil {
+		for _, b := range bufs {
+			if b.Num == n {
+				return b, nil
+			}
+		}
+		return nil, errors.New("No buffer number " + name)
+	}
+	for _, b := range bufs {
+		if b.Path != "" && (b.Path == name || b.AbsPath == name || b.GetName() == name) {
+			return b, nil
+		}
+	}
+	var best *buffer.Buffer
+	bestScore, ties := 0, 0
+	for _, b := range bufs {
+		if b.Path == "" {
+			continue
+		}
+		score, ok := util.FuzzyMatch(name, b.GetName())
+		if !ok {
+			continue
+		}
+		if best == nil || score > bestScore {
+			best, bestScore, ties = b, score, 0
+		} else if score == bestScore {
+			ties++
+		}
+	}
+	if best == nil {
+		return nil, errors.New("No matching buffer for " + name)
+	}
+	if ties > 0 {
+		return nil, errors.New("More than one buffer matches " + name)
+	}
+	return best, nil
+}
+func (h *BufPane) BufferCmd(args []string) {
+	if len(args) < 1 {
+		InfoBar.Error("Not enough arguments: provide a buffer name or number")
+		return
+	}
+	b, err := findBuffer(strings.Join(args, " "))
+	if err != nil {
+		InfoBar.Error(err)
+		return
+	}
+	h.SwitchBuffer(b)
+}
+func (h *BufPane) BuffersCmd(args []string) {
+	var lines []string
+	for _, b := range ListedBuffers() {
+		flags := " "
+		if b == h.Buf {
+			flags = "%"
+		}
+		if bufferShown(b) {
+			flags += "a"
+		} else {
+			flags += "h"
+		}
+		if b.Modified() {
+			flags += "+"
+		} else {
+			flags += " "
+		}
+		lines = append(lines, fmt.Sprintf("%3d %s %-30s line %d", b.Num, flags, b.GetName(), b.GetActiveCursor().Y+1))
+	}
+	list := buffer.NewBufferFromString(strings.Join(lines, "\n"), "", buffer.BTList)
+	list.SetName("Buffers")
+	for i, p := range MainTab().Panes {
+		if bp, ok := p.(*BufPane); ok && bp.Buf.Type == buffer.BTList && bp.Buf.GetName() == "Buffers" {
+			bp.OpenBuffer(list)
+			MainTab().SetActive(i)
+			return
+		}
+	}
+	h.HSplitIndex(list, true)
+}
+func (h *BufPane) cycleBuffer(forward bool) bool {
+	var bufs []*buffer.Buffer
+	cur := -1
+	for _, b := range ListedBuffers() {
+		if b == h.Buf {
+			cur = len(bufs)
+		} else if bufferShown(b) {
+			continue
+		}
+		bufs = append(bufs, b)
+	}
+	if len(bufs) < 2 {
+		InfoBar.Message("No other buffers")
+		return false
+	}
+	next := 0
+	if cur >= 0 && forward {
+		next = (cur + 1) % len(bufs)
+	} else if cur >= 0 {
+		next = (cur - 1 + len(bufs)) % len(bufs)
+	}
+	h.OpenBuffer(bufs[next])
+	return true
+}
+func (h *BufPane) NextBuffer() bool {
+	return h.cycleBuffer(true)
+}
+func (h *BufPane) PreviousBuffer() bool {
+	return h.cycleBuffer(false)
+}
+func BufferNameComplete(b *buffer.Buffer) ([]string, []string) {
+	c := b.GetActiveCursor()
+	input, argstart := b.GetArg()
+	type match struct {
+		name  string
+		score int
+	}
+	var matches []match
+	for _, buf := range ListedBuffers() {
+		if buf.Path == "" {
+			continue
+		}
+		if score, ok := util.FuzzyMatch(input, buf.GetName()); ok {
+			matches = append(matches, match{buf.GetName(), score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	suggestions := make([]string, len(matches))
+	prefixed := true
+	for i, m := range matches {
+		suggestions[i] = m.name
+		if !strings.HasPrefix(m.name, input) {
+			prefixed = false
+		}
+	}
+	completions := make([]string, len(suggestions))
+	for i := range suggestions {
+		if prefixed {
+			completions[i] = util.SliceEndStr(suggestions[i], c.X-argstart)
+		} else {
+			completions[i] = suggestions[i]
+		}
+	}
+	return completions, suggestions
+}
+func bufferNameComplete(b *buffer.Buffer) ([]string, []string) {
+	c := b.GetActiveCursor()
+	input, argstart := b.GetArg()
+	completions, suggestions := BufferNameComplete(b)
+	for _, s := range suggestions {
+		if !strings.HasPrefix(s, input) {
+			b.Remove(buffer.Loc{X: argstart, Y: c.Y}, c.Loc)
+			break
+		}
+	}
+	return completions, suggestions
+}
+func FileChanged(path string) {
+	for _, t := range Tabs.List {
+		for _, p := range t.Panes {
+			bp, ok := p.(*BufPane)
+			if !ok || bp.Buf.AbsPath != path {
+				continue
+			}
+			if InfoBar.HasPrompt {
+				bp.reloadPending = true
+			} else {
+				bp.checkReload()
+			}
+		}
+	}
+	gitChanged(path)
+	tagsChanged(path)
+	err := config.RunPluginFn("onFileChanged", luar.New(ulua.L, path))
+	if err != nil {
+		screen.TermMessage(err)
+	}
+}
@@ -69,7 +69,14 @@ func LuaAction(fn string, k Event) BufAction {
 	return action
 }
 func BufMapEvent(k Event, action string) {
-	config.Bindings["buffer"][k.Name()] = action
+	BufMapModeEvent(k, "", action)
+}
+func BufMapModeEvent(k Event, mode, action string) {
+	if mode == "" {
+		config.Bindings["buffer"][k.Name()] = action
+	} else {
+		config.Bindings[mode][k.Name()] = action
+	}
 	var actionfns []BufAction
 	var names []string
 	var types []byte
@@ -144,13 +151,22 @@ func BufMapEvent(k Event, action string) {
 		}
 		return true
 	}
+	keyAction := BufKeyActionGeneral(func(h *BufPane) bool {
+		return bufAction(h, nil)
+	})
 	switch e := k.(type) {
 	case KeyEvent, KeySequenceEvent, RawEvent:
-		BufBindings.RegisterKeyBinding(e, BufKeyActionGeneral(func(h *BufPane) bool {
-			return bufAction(h, nil)
-		}))
+		if mode == "" {
+			BufBindings.RegisterKeyBinding(e, keyAction)
+		} else {
+			BufBindings.RegisterModeKeyBinding(e, keyAction, mode)
+		}
 	case MouseEvent:
-		BufBindings.RegisterMouseBinding(e, BufMouseActionGeneral(bufAction))
+		if mode == "" {
+			BufBindings.RegisterMouseBinding(e, BufMouseActionGeneral(bufAction))
+		} else {
+			BufBindings.RegisterModeMouseBinding(e, BufMouseActionGeneral(bufAction), mode)
+		}
 	}
 }
 func BufUnmap(k Event) {
@@ -170,9 +186,16 @@ type BufPane struct {
 	tripleClick bool
 	multiWord bool
 	splitID uint64
+	floatOwner uint64
 	tab     *Tab
 	searchOrig buffer.Loc
 	initialized bool
+	reloadPending bool
+	tagStack []tagEntry
+	follow bool
+	followGen int
+	repl *replState
+	vim *vimState
 }
 func newBufPane(buf *buffer.Buffer, win display.BWindow, tab *Tab) *BufPane {
 	h := new(BufPane)
@@ -181,6 +204,7 @@ func newBufPane(buf *buffer.Buffer, win display.BWindow, tab *Tab) *BufPane {
 	h.tab = tab
 	h.Cursor = h.Buf.GetActiveCursor()
 	h.mousePressed = make(map[MouseEvent]bool)
+	h.vim = newVimState()
 	return h
 }
 func NewBufPane(buf *buffer.Buffer, win display.BWindow, tab *Tab) *BufPane {
@@ -235,8 +259,13 @@ func (h *BufPane) resetMouse() {
 	}
 }
 func (h *BufPane) OpenBuffer(b *buffer.Buffer) {
-	h.Buf.Close()
+	if b == h.Buf {
+		return
+	}
+	old := h.Buf
+	h.releaseBuffer()
 	h.Buf = b
+	h.reloadPending = true
 	h.BWindow.SetBuffer(b)
 	h.Cursor = b.GetActiveCursor()
 	h.Resize(h.GetView().Width, h.GetView().Height)
@@ -244,6 +273,9 @@ func (h *BufPane) OpenBuffer(b *buffer.Buffer) {
 	h.resetMouse()
 	h.isOverwriteMode = false
 	h.lastClickTime = time.Time{}
+	if !bufferShown(old) {
+		remoteReleased(old)
+	}
 }
 func (h *BufPane) GotoLoc(loc buffer.Loc) {
 	sloc := h.SLocFromLoc(loc)
@@ -288,9 +320,12 @@ func (h *BufPane) getReloadSetting() string {
 	reloadSetting := h.Buf.Settings["reload"]
 	return reloadSetting.(string)
 }
-func (h *BufPane) HandleEvent(event tcell.Event) {
+func (h *BufPane) checkReload() {
 	if h.Buf.ExternallyModified() && !h.Buf.ReloadDisabled {
 		reload := h.getReloadSetting()
+		if h.follow {
+			reload = "auto"
+		}
 		if reload == "prompt" {
 			InfoBar.YNPrompt("The file on disk has changed. Reload file? (y,n,esc)", func(yes, canceled bool) {
 				if canceled {
@@ -304,12 +339,24 @@ func (h *BufPane) HandleEvent(event tcell.Event) {
 			})
 		} else if reload == "auto" {
 			h.Buf.ReOpen()
+			if h.follow {
+				h.CursorEnd()
+			}
 		} else if reload == "disabled" {
 			h.Buf.DisableReload()
 		} else {
 			InfoBar.Message("Invalid reload setting")
 		}
 	}
+}
+func (h *BufPane) HandleEvent(event tcell.Event) {
+	if h.reloadPending || !h.Buf.Watched() {
+		h.reloadPending = false
+		h.checkReload()
+	}
+	if h.repl != nil && h.replGuard(event) {
+		return
+	}
 	switch e := event.(type) {
 	case *tcell.EventRaw:
 		re := RawEvent{
@@ -325,8 +372,11 @@ func (h *BufPane) HandleEvent(event tcell.Event) {
 			mod:  metaToAlt(e.Modifiers()),
 			r:    e.Rune(),
 		}
+		if h.vim != nil && h.vimKeyEvent(ke) {
+			break
+		}
 		done := h.DoKeyEvent(ke)
-		if !done && e.Key() == tcell.KeyRune {
+		if !done && e.Key() == tcell.KeyRune && (h.vim == nil || h.vim.mode == VimInsert) {
 			h.DoRuneInsert(e.Rune())
 		}
 	case *tcell.EventMouse:
@@ -353,6 +403,9 @@ func (h *BufPane) HandleEvent(event tcell.Event) {
 		}
 	}
 	h.Buf.MergeCursors()
+	if h.vim != nil && h.vim.mode == VimNormal {
+		h.vimClampCursors()
+	}
 	if h.IsActive() {
 		c := h.Buf.GetActiveCursor()
 		none := true
@@ -384,15 +437,33 @@ func (h *BufPane) Bindings() *KeyTree {
 func (h *BufPane) DoKeyEvent(e Event) bool {
 	binds := h.Bindings()
 	action, more := binds.NextEvent(e, nil)
+	if more {
+		h.whichKeyPending(binds)
+	} else {
+		whichKeyDone()
+	}
 	if action != nil && !more {
 		action(h)
 		binds.ResetEvents()
 		return true
-	} else if action == nil && !more {
-		binds.ResetEvents()
+	} else if action == nil && !more && h.flushKeys(binds) {
+		return h.DoKeyEvent(e)
 	}
 	return more
 }
+func (h *BufPane) flushKeys(binds *KeyTree) bool {
+	prefix, pending := binds.PendingAction(), binds.PendingEvents()
+	binds.ResetEvents()
+	if len(pending) == 0 {
+		return false
+	}
+	if prefix != nil {
+		prefix(h)
+	} else {
+		h.insertKeys(pending)
+	}
+	return true
+}
 func (h *BufPane) execAction(action BufAction, name string, cursor int, te *tcell.EventMouse) bool {
 	if name != "Autocomplete" && name != "CycleAutocompleteBack" {
 		h.Buf.HasSuggestions = false
@@ -485,11 +556,15 @@ func (h *BufPane) HSplitBuf(buf *buffer.Buffer) *BufPane {
 	return h.HSplitIndex(buf, h.Buf.Settings["splitbottom"].(bool))
 }
 func (h *BufPane) Close() {
+	h.follow = false
 	h.Buf.Close()
 }
 func (h *BufPane) SetActive(b bool) {
 	h.BWindow.SetActive(b)
 	if b {
+		if h.vim != nil {
+			h.vim.apply()
+		}
 		c := h.Buf.GetActiveCursor()
 		none := true
 		for _, m := range h.Buf.Messages {
@@ -547,6 +622,28 @@ var BufKeyActions = map[string]BufKeyAction{
 	"FindPrevious":              (*BufPane).FindPrevious,
 	"DiffNext":                  (*BufPane).DiffNext,
 	"DiffPrevious":              (*BufPane).DiffPrevious,
+	"DiffGet":                   (*BufPane).DiffGet,
+	"DiffPut":                   (*BufPane).DiffPut,
+	"ConflictNext":              (*BufPane).ConflictNext,
+	"ConflictPrevious":          (*BufPane).ConflictPrevious,
+	"ConflictOurs":              (*BufPane).ConflictOurs,
+	"ConflictTheirs":            (*BufPane).ConflictTheirs,
+	"ConflictBoth":              (*BufPane).ConflictBoth,
+	"ConflictBase":              (*BufPane).ConflictBase,
+	"Hover":                     (*BufPane).Hover,
+	"GotoDefinition":            (*BufPane).GotoDefinition,
+	"FindReferences":            (*BufPane).FindReferences,
+	"RenameSymbol":              (*BufPane).RenameSymbol,
+	"FormatBuffer":              (*BufPane).FormatBuffer,
+	"JumpToTag":                 (*BufPane).JumpToTag,
+	"PopTag":                    (*BufPane).PopTag,
+	"PickColorscheme":           (*BufPane).PickColorscheme,
+	"ToggleFollow":              (*BufPane).ToggleFollow,
+	"CommandPalette":            (*BufPane).CommandPalette,
+	"NormalMode":                (*BufPane).NormalMode,
+	"InsertMode":                (*BufPane).InsertMode,
+	"VisualMode":                (*BufPane).VisualMode,
+	"VisualLineMode":            (*BufPane).VisualLineMode,
 	"Center":                    (*BufPane).Center,
 	"Undo":                      (*BufPane).Undo,
 	"Redo":                      (*BufPane).Redo,
@@ -616,6 +713,11 @@ var BufKeyActions = map[string]BufKeyAction{
 	"SkipMultiCursor":           (*BufPane).SkipMultiCursor,
 	"JumpToMatchingBrace":       (*BufPane).JumpToMatchingBrace,
 	"JumpLine":                  (*BufPane).JumpLine,
+	"QuickfixJump":              (*BufPane).QuickfixJump,
+	"QuickfixNext":              (*BufPane).QuickfixNext,
+	"QuickfixPrevious":          (*BufPane).QuickfixPrevious,
+	"NextBuffer":                (*BufPane).NextBuffer,
+	"PreviousBuffer":            (*BufPane).PreviousBuffer,
 	"Deselect":                  (*BufPane).Deselect,
 	"ClearInfo":                 (*BufPane).ClearInfo,
 	"None":                      (*BufPane).None,
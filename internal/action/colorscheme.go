@@ -0,0 +1,56 @@
+package action
+import (
+	"sort"
+	"github.com/zyedidia/micro/v2/internal/config"
+)
+func colorschemeNames() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, f := range config.ListRuntimeFiles(config.RTColorscheme) {
+		if !seen[f.Name()] {
+			seen[f.Name()] = true
+			names = append(names, f.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+func (h *BufPane) PickColorscheme() bool {
+	prev := config.GlobalSettings["colorscheme"].(string)
+	names := colorschemeNames()
+	selected := 0
+	for i, name := range names {
+		if name == prev {
+			selected = i
+		}
+	}
+	preview := func(name string) {
+		config.GlobalSettings["colorscheme"] = name
+		config.InitColorscheme()
+	}
+	h.Pick("Colorscheme", names, selected, func(i int) {
+		preview(names[i])
+	}, func(i int, canceled bool) {
+		if canceled {
+			preview(prev)
+			return
+		}
+		if err := SetGlobalOptionNative("colorscheme", names[i]); err != nil {
+			InfoBar.Error(err)
+		}
+	})
+	return true
+}
+func (h *BufPane) ColorschemeCmd(args []string) {
+	if len(args) == 0 {
+		h.PickColorscheme()
+		return
+	}
+	if !config.ColorschemeExists(args[0]) {
+		InfoBar.Error(args[0], " is not a valid colorscheme")
+		return
+	}
+	if err := SetGlobalOptionNative("colorscheme", args[0]); err != nil {
+		InfoBar.Error(err)
+	}
+}
\ No newline at end of file
@@ -3,6 +3,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -13,6 +14,7 @@ import (
 	"github.com/zyedidia/micro/v2/internal/buffer"
 	"github.com/zyedidia/micro/v2/internal/clipboard"
 	"github.com/zyedidia/micro/v2/internal/config"
+	"github.com/zyedidia/micro/v2/internal/export"
 	"github.com/zyedidia/micro/v2/internal/screen"
 	"github.com/zyedidia/micro/v2/internal/shell"
 	"github.com/zyedidia/micro/v2/internal/util"
@@ -24,39 +26,61 @@ type Command struct {
 var commands map[string]Command
 func InitCommands() {
 	commands = map[string]Command{
-		"set":        {(*BufPane).SetCmd, OptionValueComplete},
-		"reset":      {(*BufPane).ResetCmd, OptionValueComplete},
-		"setlocal":   {(*BufPane).SetLocalCmd, OptionValueComplete},
-		"show":       {(*BufPane).ShowCmd, OptionComplete},
-		"showkey":    {(*BufPane).ShowKeyCmd, nil},
-		"run":        {(*BufPane).RunCmd, nil},
-		"bind":       {(*BufPane).BindCmd, nil},
-		"unbind":     {(*BufPane).UnbindCmd, nil},
-		"quit":       {(*BufPane).QuitCmd, nil},
-		"goto":       {(*BufPane).GotoCmd, nil},
-		"jump":       {(*BufPane).JumpCmd, nil},
-		"save":       {(*BufPane).SaveCmd, nil},
-		"replace":    {(*BufPane).ReplaceCmd, nil},
-		"replaceall": {(*BufPane).ReplaceAllCmd, nil},
-		"vsplit":     {(*BufPane).VSplitCmd, buffer.FileComplete},
-		"hsplit":     {(*BufPane).HSplitCmd, buffer.FileComplete},
-		"tab":        {(*BufPane).NewTabCmd, buffer.FileComplete},
-		"help":       {(*BufPane).HelpCmd, HelpComplete},
-		"eval":       {(*BufPane).EvalCmd, nil},
-		"log":        {(*BufPane).ToggleLogCmd, nil},
-		"plugin":     {(*BufPane).PluginCmd, PluginComplete},
-		"reload":     {(*BufPane).ReloadCmd, nil},
-		"reopen":     {(*BufPane).ReopenCmd, nil},
-		"cd":         {(*BufPane).CdCmd, buffer.FileComplete},
-		"pwd":        {(*BufPane).PwdCmd, nil},
-		"open":       {(*BufPane).OpenCmd, buffer.FileComplete},
-		"tabmove":    {(*BufPane).TabMoveCmd, nil},
-		"tabswitch":  {(*BufPane).TabSwitchCmd, nil},
-		"term":       {(*BufPane).TermCmd, nil},
-		"memusage":   {(*BufPane).MemUsageCmd, nil},
-		"retab":      {(*BufPane).RetabCmd, nil},
-		"raw":        {(*BufPane).RawCmd, nil},
-		"textfilter": {(*BufPane).TextFilterCmd, nil},
+		"set":          {(*BufPane).SetCmd, OptionValueComplete},
+		"reset":        {(*BufPane).ResetCmd, OptionValueComplete},
+		"setlocal":     {(*BufPane).SetLocalCmd, OptionValueComplete},
+		"show":         {(*BufPane).ShowCmd, OptionComplete},
+		"showkey":      {(*BufPane).ShowKeyCmd, nil},
+		"run":          {(*BufPane).RunCmd, nil},
+		"bind":         {(*BufPane).BindCmd, nil},
+		"unbind":       {(*BufPane).UnbindCmd, nil},
+		"quit":         {(*BufPane).QuitCmd, nil},
+		"goto":         {(*BufPane).GotoCmd, nil},
+		"jump":         {(*BufPane).JumpCmd, nil},
+		"save":         {(*BufPane).SaveCmd, nil},
+		"replace":      {(*BufPane).ReplaceCmd, nil},
+		"replaceall":   {(*BufPane).ReplaceAllCmd, nil},
+		"vsplit":       {(*BufPane).VSplitCmd, buffer.FileComplete},
+		"hsplit":       {(*BufPane).HSplitCmd, buffer.FileComplete},
+		"tab":          {(*BufPane).NewTabCmd, buffer.FileComplete},
+		"help":         {(*BufPane).HelpCmd, HelpComplete},
+		"eval":         {(*BufPane).EvalCmd, nil},
+		"repl":         {(*BufPane).ReplCmd, nil},
+		"log":          {(*BufPane).ToggleLogCmd, nil},
+		"plugin":       {(*BufPane).PluginCmd, PluginComplete},
+		"reload":       {(*BufPane).ReloadCmd, nil},
+		"reopen":       {(*BufPane).ReopenCmd, nil},
+		"cd":           {(*BufPane).CdCmd, buffer.FileComplete},
+		"pwd":          {(*BufPane).PwdCmd, nil},
+		"open":         {(*BufPane).OpenCmd, buffer.FileComplete},
+		"tabmove":      {(*BufPane).TabMoveCmd, nil},
+		"tabswitch":    {(*BufPane).TabSwitchCmd, nil},
+		"term":         {(*BufPane).TermCmd, nil},
+		"memusage":     {(*BufPane).MemUsageCmd, nil},
+		"retab":        {(*BufPane).RetabCmd, nil},
+		"raw":          {(*BufPane).RawCmd, nil},
+		"textfilter":   {(*BufPane).TextFilterCmd, nil},
+		"quickfix":     {(*BufPane).QuickfixCmd, QuickfixComplete},
+		"buffers":      {(*BufPane).BuffersCmd, nil},
+		"b":            {(*BufPane).BufferCmd, bufferNameComplete},
+		"encrypt":      {(*BufPane).EncryptCmd, nil},
+		"decrypt":      {(*BufPane).DecryptCmd, nil},
+		"diff":         {(*BufPane).DiffCmd, buffer.FileComplete},
+		"diffoff":      {(*BufPane).DiffOffCmd, nil},
+		"blame":        {(*BufPane).BlameCmd, nil},
+		"stage":        {(*BufPane).StageCmd, nil},
+		"unstage":      {(*BufPane).UnstageCmd, nil},
+		"revert":       {(*BufPane).RevertCmd, nil},
+		"hover":        {(*BufPane).HoverCmd, nil},
+		"definition":   {(*BufPane).DefinitionCmd, nil},
+		"references":   {(*BufPane).ReferencesCmd, nil},
+		"renamesymbol": {(*BufPane).RenameSymbolCmd, nil},
+		"format":       {(*BufPane).FormatCmd, nil},
+		"tag":          {(*BufPane).TagCmd, TagComplete},
+		"poptag":       {(*BufPane).PopTagCmd, nil},
+		"colorscheme":  {(*BufPane).ColorschemeCmd, ColorschemeComplete},
+		"export":       {(*BufPane).ExportCmd, ExportComplete},
+		"palette":      {(*BufPane).PaletteCmd, nil},
 	}
 }
 func MakeCommand(name string, action func(bp *BufPane, args []string), completer buffer.Completer) {
@@ -124,6 +148,50 @@ func (h *BufPane) TextFilterCmd(args []string) {
 	h.Cursor.DeleteSelection()
 	h.Buf.Insert(h.Cursor.Loc, bout.String())
 }
+func (h *BufPane) ExportCmd(args []string) {
+	if len(args) == 0 {
+		InfoBar.Error("usage: export html|ansi|svg ['file']")
+		return
+	}
+	lines := make([]string, h.Buf.LinesNum())
+	for i := range lines {
+		lines[i] = string(h.Buf.LineBytes(i))
+	}
+	r := export.Range{EndX: -1, EndY: len(lines) - 1}
+	if h.Cursor.HasSelection() {
+		start, end := h.Cursor.CurSelection[0], h.Cursor.CurSelection[1]
+		if start.GreaterThan(end) {
+			start, end = end, start
+		}
+		r = export.Range{StartX: start.X, StartY: start.Y, EndX: end.X, EndY: end.Y}
+	}
+	def := h.Buf.SyntaxDef
+	if !h.Buf.Settings["syntax"].(bool) {
+		def = nil
+	}
+	out, err := export.Render(args[0], def, lines, r, util.IntOpt(h.Buf.Settings["tabsize"]), config.GetGlobalOption("exportruler").(bool))
+	if err != nil {
+		InfoBar.Error(err)
+		return
+	}
+	if len(args) < 2 {
+		if err := clipboard.Write(out, clipboard.ClipboardReg); err != nil {
+			InfoBar.Error(err)
+			return
+		}
+		InfoBar.Message("Exported ", args[0], " to the clipboard")
+		return
+	}
+	filename, err := util.ReplaceHome(args[1])
+	if err == nil {
+		err = ioutil.WriteFile(filename, []byte(out), 0644)
+	}
+	if err != nil {
+		InfoBar.Error(err)
+		return
+	}
+	InfoBar.Message("Exported ", args[0], " to ", filename)
+}
 func (h *BufPane) TabMoveCmd(args []string) {
 	if len(args) <= 0 {
 		InfoBar.Error("Not enough arguments: provide an index, starting at 1")
@@ -231,26 +299,12 @@ func (h *BufPane) OpenCmd(args []string) {
 			return
 		}
 		filename = strings.Join(args, " ")
-		open := func() {
-			b, err := buffer.NewBufferFromFile(filename, buffer.BTDefault)
-			if err != nil {
-				InfoBar.Error(err)
-				return
-			}
-			h.OpenBuffer(b)
-		}
-		if h.Buf.Modified() {
-			InfoBar.YNPrompt("Save changes to "+h.Buf.GetName()+" before closing? (y,n,esc)", func(yes, canceled bool) {
-				if !canceled && !yes {
-					open()
-				} else if !canceled && yes {
-					h.Save()
-					open()
-				}
-			})
-		} else {
-			open()
+		b, err := openBufferFile(filename)
+		if err != nil {
+			InfoBar.Error(err)
+			return
 		}
+		h.OpenBuffer(b)
 	} else {
 		InfoBar.Error("No filename")
 	}
@@ -386,7 +440,19 @@ func (h *BufPane) HSplitCmd(args []string) {
 	h.HSplitBuf(buf)
 }
 func (h *BufPane) EvalCmd(args []string) {
-	InfoBar.Error("Eval unsupported")
+	if len(args) == 0 {
+		InfoBar.Error("Not enough arguments")
+		return
+	}
+	out, incomplete, err := replEval(strings.Join(args, " "))
+	if incomplete {
+		InfoBar.Error("Incomplete Lua expression")
+		return
+	} else if err != nil {
+		InfoBar.Error(strings.Replace(out+err.Error(), "\n", " ", -1))
+		return
+	}
+	InfoBar.Message(strings.Replace(out, "\n", " ", -1))
 }
 func (h *BufPane) NewTabCmd(args []string) {
 	width, height := screen.Screen.Size()
@@ -424,6 +490,10 @@ func SetGlobalOptionNative(option string, nativeValue interface{}) error {
 		for _, b := range buffer.OpenBuffers {
 			b.UpdateRules()
 		}
+	} else if option == "keymap" {
+		setKeymap(nativeValue.(string))
+	} else if option == "lsptimeout" {
+		setLSPTimeout()
 	} else if option == "infobar" || option == "keymenu" {
 		Tabs.Resize()
 	} else if option == "mouse" {
@@ -650,7 +720,7 @@ func (h *BufPane) SaveCmd(args []string) {
 	if len(args) == 0 {
 		h.Save()
 	} else {
-		h.Buf.SaveAs(args[0])
+		h.saveBufToFile(args[0], "Save", nil)
 	}
 }
 func (h *BufPane) ReplaceCmd(args []string) {
@@ -0,0 +1,61 @@
+package action
+import "github.com/zyedidia/micro/v2/internal/buffer"
+func (h *BufPane) conflictJump(next bool) bool {
+	h.Buf.UpdateConflicts()
+	conflicts := h.Buf.Conflicts()
+	target := -1
+	for _, c := range conflicts {
+		if next && c.Start > h.Cursor.Y {
+			target = c.Start
+			break
+		} else if !next && c.End < h.Cursor.Y {
+			target = c.Start
+		}
+	}
+	if target < 0 {
+		return false
+	}
+	h.GotoLoc(buffer.Loc{X: 0, Y: target})
+	return true
+}
+func (h *BufPane) ConflictNext() bool {
+	return h.conflictJump(true)
+}
+func (h *BufPane) ConflictPrevious() bool {
+	return h.conflictJump(false)
+}
+func (h *BufPane) resolveConflict(sections ...func(buffer.Conflict) (int, int)) bool {
+	h.Buf.UpdateConflicts()
+	c, ok := h.Buf.ConflictAt(h.Cursor.Y)
+	if !ok {
+		return false
+	}
+	var lines []string
+	for _, section := range sections {
+		start, end := section(c)
+		for i := start; i < end; i++ {
+			lines = append(lines, string(h.Buf.LineBytes(i)))
+		}
+	}
+	replaceLines(h.Buf, c.Start, c.End+1, lines)
+	h.Buf.UpdateConflicts()
+	h.GotoLoc(buffer.Loc{X: 0, Y: c.Start})
+	return true
+}
+func (h *BufPane) ConflictOurs() bool {
+	return h.resolveConflict(buffer.Conflict.Ours)
+}
+func (h *BufPane) ConflictTheirs() bool {
+	return h.resolveConflict(buffer.Conflict.Theirs)
+}
+func (h *BufPane) ConflictBoth() bool {
+	return h.resolveConflict(buffer.Conflict.Ours, buffer.Conflict.Theirs)
+}
+func (h *BufPane) ConflictBase() bool {
+	h.Buf.UpdateConflicts()
+	if c, ok := h.Buf.ConflictAt(h.Cursor.Y); ok && c.Base < 0 {
+		InfoBar.Message("Conflict has no base section")
+		return false
+	}
+	return h.resolveConflict(buffer.Conflict.Ancestor)
+}
@@ -26,7 +26,7 @@ var bufdefaults = map[string]string{
 	"CtrlShiftDown":  "SelectToEnd",
 	"Alt-{":          "ParagraphPrevious",
 	"Alt-}":          "ParagraphNext",
-	"Enter":          "InsertNewline",
+	"Enter":          "QuickfixJump|InsertNewline",
 	"CtrlH":          "Backspace",
 	"Backspace":      "Backspace",
 	"OldBackspace":   "Backspace",
@@ -40,8 +40,14 @@ var bufdefaults = map[string]string{
 	"Alt-F":          "FindLiteral",
 	"Ctrl-n":         "FindNext",
 	"Ctrl-p":         "FindPrevious",
-	"Alt-[":          "DiffPrevious|CursorStart",
-	"Alt-]":          "DiffNext|CursorEnd",
+	"Alt-[":          "ConflictPrevious|DiffPrevious|CursorStart",
+	"Alt-]":          "ConflictNext|DiffNext|CursorEnd",
+	"Alt-<":          "DiffGet",
+	"Alt->":          "DiffPut",
+	"Alt-1":          "ConflictOurs",
+	"Alt-2":          "ConflictTheirs",
+	"Alt-3":          "ConflictBoth",
+	"Alt-4":          "ConflictBase",
 	"Ctrl-z":         "Undo",
 	"Ctrl-y":         "Redo",
 	"Ctrl-c":         "CopyLine|Copy",
@@ -69,6 +75,7 @@ var bufdefaults = map[string]string{
 	"Ctrl-b":         "ShellMode",
 	"Ctrl-q":         "Quit",
 	"Ctrl-e":         "CommandMode",
+	"Alt-P":          "CommandPalette",
 	"Ctrl-w":         "NextSplit",
 	"Ctrl-u":         "ToggleMacro",
 	"Ctrl-j":         "PlayMacro",
@@ -81,6 +88,8 @@ var bufdefaults = map[string]string{
 	"F3":  "Find",
 	"F4":  "Quit",
 	"F7":  "Find",
+	"F8":  "QuickfixNext",
+	"F9":  "QuickfixPrevious",
 	"F10": "Quit",
 	"Esc": "Escape,Deselect,ClearInfo,RemoveAllMultiCursors,UnhighlightSearch",
 	"MouseWheelUp":     "ScrollUp",
@@ -1,5 +1,4 @@
 //go:build !darwin
-// +build !darwin
 package action
 var bufdefaults = map[string]string{
 	"Up":             "CursorUp",
@@ -28,7 +27,7 @@ var bufdefaults = map[string]string{
 	"CtrlShiftDown":  "SelectToEnd",
 	"Alt-{":          "ParagraphPrevious",
 	"Alt-}":          "ParagraphNext",
-	"Enter":          "InsertNewline",
+	"Enter":          "QuickfixJump|InsertNewline",
 	"CtrlH":          "Backspace",
 	"Backspace":      "Backspace",
 	"OldBackspace":   "Backspace",
@@ -42,8 +41,14 @@ var bufdefaults = map[string]string{
 	"Alt-F":          "FindLiteral",
 	"Ctrl-n":         "FindNext",
 	"Ctrl-p":         "FindPrevious",
-	"Alt-[":          "DiffPrevious|CursorStart",
-	"Alt-]":          "DiffNext|CursorEnd",
+	"Alt-[":          "ConflictPrevious|DiffPrevious|CursorStart",
+	"Alt-]":          "ConflictNext|DiffNext|CursorEnd",
+	"Alt-<":          "DiffGet",
+	"Alt->":          "DiffPut",
+	"Alt-1":          "ConflictOurs",
+	"Alt-2":          "ConflictTheirs",
+	"Alt-3":          "ConflictBoth",
+	"Alt-4":          "ConflictBase",
 	"Ctrl-z":         "Undo",
 	"Ctrl-y":         "Redo",
 	"Ctrl-c":         "CopyLine|Copy",
@@ -71,6 +76,7 @@ var bufdefaults = map[string]string{
 	"Ctrl-b":         "ShellMode",
 	"Ctrl-q":         "Quit",
 	"Ctrl-e":         "CommandMode",
+	"Alt-P":          "CommandPalette",
 	"Ctrl-w":         "NextSplit",
 	"Ctrl-u":         "ToggleMacro",
 	"Ctrl-j":         "PlayMacro",
@@ -83,6 +89,8 @@ var bufdefaults = map[string]string{
 	"F3":  "Find",
 	"F4":  "Quit",
 	"F7":  "Find",
+	"F8":  "QuickfixNext",
+	"F9":  "QuickfixPrevious",
 	"F10": "Quit",
 	"Esc": "Escape,Deselect,ClearInfo,RemoveAllMultiCursors,UnhighlightSearch",
 	"MouseWheelUp":     "ScrollUp",
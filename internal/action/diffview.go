@@ -0,0 +1,131 @@
+package action
+import (
+	"strings"
+	"github.com/zyedidia/micro/v2/internal/buffer"
+	"github.com/zyedidia/micro/v2/internal/display"
+	"github.com/zyedidia/micro/v2/internal/util"
+)
+func (h *BufPane) diffView() (*display.DiffView, int) {
+	w, ok := h.BWindow.(*display.BufWindow)
+	if !ok || w.DiffView() == nil {
+		return nil, 0
+	}
+	d := w.DiffView()
+	return d, d.Side(w)
+}
+func (h *BufPane) DiffBuf(b *buffer.Buffer) *BufPane {
+	e := h.VSplitIndex(b, true)
+	left, ok1 := h.BWindow.(*display.BufWindow)
+	right, ok2 := e.BWindow.(*display.BufWindow)
+	if ok1 && ok2 {
+		display.NewDiffView(left, right)
+	}
+	return e
+}
+func (h *BufPane) DiffCmd(args []string) {
+	if len(args) < 1 {
+		InfoBar.Error("Not enough arguments: provide a file to diff against")
+		return
+	}
+	b, err := openBufferFile(args[0])
+	if err != nil {
+		InfoBar.Error(err)
+		return
+	}
+	if b.SharedBuffer == h.Buf.SharedBuffer {
+		InfoBar.Error("Cannot diff a buffer against itself")
+		return
+	}
+	h.diffOff()
+	h.DiffBuf(b)
+}
+func (h *BufPane) DiffOffCmd(args []string) {
+	if d, _ := h.diffView(); d == nil {
+		InfoBar.Error("Not in diff mode")
+		return
+	}
+	h.diffOff()
+}
+func (h *BufPane) diffOff() {
+	if d, _ := h.diffView(); d != nil {
+		d.Close()
+	}
+}
+func (h *BufPane) diffPane(d *display.DiffView, side int) *BufPane {
+	for _, t := range Tabs.List {
+		for _, p := range t.Panes {
+			if bp, ok := p.(*BufPane); ok && bp.BWindow == d.Windows[side] {
+				return bp
+			}
+		}
+	}
+	return nil
+}
+func (h *BufPane) diffJump(next bool) bool {
+	d, side := h.diffView()
+	cur := d.RowOf(side, h.Cursor.Y)
+	target := -1
+	for _, hunk := range d.Hunks() {
+		l := d.LineAt(side, hunk[0])
+		if r := d.RowOf(side, l); next && r > cur {
+			target = l
+			break
+		} else if !next && r < cur {
+			target = l
+		}
+	}
+	if target < 0 {
+		return false
+	}
+	h.GotoLoc(buffer.Loc{X: 0, Y: target})
+	return true
+}
+func replaceLines(b *buffer.Buffer, start, end int, lines []string) {
+	text := strings.Join(lines, "\n")
+	switch {
+	case start < end && len(lines) > 0:
+		b.Replace(buffer.Loc{X: 0, Y: start}, buffer.Loc{X: util.CharacterCount(b.LineBytes(end - 1)), Y: end - 1}, text)
+	case len(lines) > 0 && start < b.LinesNum():
+		b.Insert(buffer.Loc{X: 0, Y: start}, text+"\n")
+	case len(lines) > 0:
+		b.Insert(b.End(), "\n"+text)
+	case end < b.LinesNum():
+		b.Remove(buffer.Loc{X: 0, Y: start}, buffer.Loc{X: 0, Y: end})
+	case start > 0:
+		b.Remove(buffer.Loc{X: util.CharacterCount(b.LineBytes(start - 1)), Y: start - 1}, b.End())
+	default:
+		b.Remove(b.Start(), b.End())
+	}
+}
+func (h *BufPane) copyHunk(from, to int) bool {
+	d, side := h.diffView()
+	if d == nil {
+		return false
+	}
+	hunk, ok := d.HunkAt(side, d.RowOf(side, h.Cursor.Y))
+	if !ok {
+		InfoBar.Message("No diff hunk under the cursor")
+		return false
+	}
+	src, dst := d.Windows[from].Buf, d.Windows[to].Buf
+	ss, se := d.HunkLines(hunk, from)
+	ds, de := d.HunkLines(hunk, to)
+	lines := make([]string, 0, se-ss)
+	for i := ss; i < se; i++ {
+		lines = append(lines, string(src.LineBytes(i)))
+	}
+	replaceLines(dst, ds, de, lines)
+	d.Invalidate()
+	if p := h.diffPane(d, to); p != nil {
+		p.Relocate()
+	}
+	return true
+}
+func (h *BufPane) DiffGet() bool {
+	_, side := h.diffView()
+	return h.copyHunk(1-side, side)
+}
+func (h *BufPane) DiffPut() bool {
+	_, side := h.diffView()
+	return h.copyHunk(side, 1-side)
+}
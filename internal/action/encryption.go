@@ -0,0 +1,85 @@
+package action
+import (
+	"github.com/zyedidia/micro/v2/internal/buffer"
+)
+var lockedBuffers []*buffer.Buffer
+func queueDecrypt(b *buffer.Buffer) {
+	if !b.Locked() {
+		return
+	}
+	for _, l := range lockedBuffers {
+		if l.SharedBuffer == b.SharedBuffer {
+			return
+		}
+	}
+	lockedBuffers = append(lockedBuffers, b)
+	if len(lockedBuffers) == 1 {
+		promptDecrypt()
+	}
+}
+func promptDecrypt() {
+	if len(lockedBuffers) == 0 {
+		return
+	}
+	b := lockedBuffers[0]
+	InfoBar.PasswordPrompt("Passphrase for "+b.GetName()+": ", func(resp string, canceled bool) {
+		if canceled {
+			lockedBuffers = nil
+			return
+		}
+		lockedBuffers = lockedBuffers[1:]
+		if err := b.Decrypt(resp); err != nil {
+			InfoBar.Error(b.GetName(), ": ", err)
+		} else {
+			InfoBar.Message("Decrypted ", b.GetName())
+		}
+		promptDecrypt()
+	})
+}
+func (h *BufPane) EncryptCmd(args []string) {
+	b := h.Buf
+	if b.Locked() {
+		InfoBar.Error("Buffer is still encrypted: run decrypt first")
+		return
+	}
+	if b.Path == "" {
+		InfoBar.Error("Cannot encrypt a buffer without a file name")
+		return
+	}
+	InfoBar.PasswordPrompt("New passphrase: ", func(pass string, canceled bool) {
+		if canceled {
+			return
+		}
+		if pass == "" {
+			InfoBar.Error("Empty passphrase")
+			return
+		}
+		InfoBar.PasswordPrompt("Confirm passphrase: ", func(confirm string, canceled bool) {
+			if canceled {
+				return
+			}
+			if confirm != pass {
+				InfoBar.Error("Passphrases do not match")
+				return
+			}
+			if err := b.Encrypt(pass); err != nil {
+				InfoBar.Error(err)
+				return
+			}
+			InfoBar.Message(b.GetName(), " will be encrypted when saved")
+		})
+	})
+}
+func (h *BufPane) DecryptCmd(args []string) {
+	b := h.Buf
+	if b.Locked() {
+		lockedBuffers = nil
+		queueDecrypt(b)
+		return
+	}
+	if err := b.RemoveEncryption(); err != nil {
+		InfoBar.Error(err)
+		return
+	}
+	InfoBar.Message(b.GetName(), " will be saved unencrypted")
+}
@@ -15,6 +15,10 @@ type RawEvent struct {
 func (r RawEvent) Name() string {
 	return r.esc
 }
+type LeaderEvent struct{}
+func (l LeaderEvent) Name() string {
+	return "leader"
+}
 type KeyEvent struct {
 	code tcell.Key
 	mod  tcell.ModMask
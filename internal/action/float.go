@@ -0,0 +1,169 @@
+package action
+import (
+	"sort"
+	luar "layeh.com/gopher-luar"
+	"github.com/zyedidia/micro/v2/internal/buffer"
+	"github.com/zyedidia/micro/v2/internal/config"
+	"github.com/zyedidia/micro/v2/internal/display"
+	ulua "github.com/zyedidia/micro/v2/internal/lua"
+	"github.com/zyedidia/micro/v2/internal/screen"
+	"github.com/zyedidia/micro/v2/internal/views"
+	"github.com/zyedidia/tcell/v2"
+)
+func (h *BufPane) Float() *display.FloatWindow {
+	w, _ := h.BWindow.(*display.FloatWindow)
+	return w
+}
+func (t *Tab) AddFloat(w *display.FloatWindow, owner uint64, focus bool) *BufPane {
+	w.Buf.SetOptionNative("ruler", false)
+	w.Buf.SetOptionNative("diffgutter", false)
+	w.Place(t.X, t.Y, t.W, t.H)
+	p := NewBufPane(w.Buf, w, t)
+	p.splitID = views.NewID()
+	p.floatOwner = owner
+	p.SetActive(false)
+	t.floats = append(t.floats, p)
+	sort.SliceStable(t.floats, func(i, j int) bool {
+		return t.floats[i].Float().Z < t.floats[j].Float().Z
+	})
+	if focus {
+		t.FocusFloat(p)
+	}
+	return p
+}
+func (t *Tab) OpenFloat(b *buffer.Buffer, x, y, width, height int, focus bool) *BufPane {
+	w := display.NewFloatWindow(b, width, height)
+	w.MoveTo(x, y)
+	return t.AddFloat(w, t.Panes[t.active].ID(), focus)
+}
+func (h *BufPane) OpenFloatAt(b *buffer.Buffer, loc buffer.Loc, width, height int, focus bool) *BufPane {
+	w := display.NewFloatWindow(b, width, height)
+	if bw, ok := h.BWindow.(*display.BufWindow); ok {
+		w.AnchorTo(bw, loc, 1, 0)
+	} else if fw := h.Float(); fw != nil {
+		w.AnchorTo(fw.BufWindow, loc, 1, 0)
+	}
+	return h.tab.AddFloat(w, h.splitID, focus)
+}
+func (t *Tab) Floats() []*BufPane {
+	return t.floats
+}
+func (t *Tab) FocusFloat(p *BufPane) {
+	if p == nil {
+		if t.focus != nil {
+			t.SetActive(t.active)
+		}
+		return
+	}
+	for _, q := range t.Panes {
+		q.SetActive(false)
+	}
+	for _, q := range t.floats {
+		q.SetActive(q == p)
+	}
+	t.focus = p
+	err := config.RunPluginFn("onSetActive", luar.New(ulua.L, p))
+	if err != nil {
+		screen.TermMessage(err)
+	}
+}
+func (t *Tab) CloseFloat(p *BufPane) {
+	for i, q := range t.floats {
+		if q == p {
+			copy(t.floats[i:], t.floats[i+1:])
+			t.floats[len(t.floats)-1] = nil
+			t.floats = t.floats[:len(t.floats)-1]
+			p.Close()
+			if t.focus == p {
+				t.SetActive(t.active)
+			}
+			t.closeOwnedFloats(p.splitID)
+			return
+		}
+	}
+}
+func (t *Tab) closeOwnedFloats(owner uint64) {
+	var owned []*BufPane
+	for _, p := range t.floats {
+		if p.floatOwner == owner {
+			owned = append(owned, p)
+		}
+	}
+	for _, p := range owned {
+		t.CloseFloat(p)
+	}
+}
+func (t *Tab) closeFloats() {
+	t.focus = nil
+	for len(t.floats) > 0 {
+		t.CloseFloat(t.floats[len(t.floats)-1])
+	}
+}
+func (t *Tab) closeTransientFloats(keep *BufPane) {
+	for i := len(t.floats) - 1; i >= 0; i-- {
+		if p := t.floats[i]; p != keep && p != t.focus && p.Float().Transient {
+			t.CloseFloat(p)
+		}
+	}
+}
+func (t *Tab) floatAt(x, y int) *BufPane {
+	for i := len(t.floats) - 1; i >= 0; i-- {
+		if t.floats[i].Float().Contains(x, y) {
+			return t.floats[i]
+		}
+	}
+	return nil
+}
+func (t *Tab) handleFloatEvent(event tcell.Event) bool {
+	if len(t.floats) == 0 {
+		return false
+	}
+	switch e := event.(type) {
+	case *tcell.EventKey:
+		t.closeTransientFloats(nil)
+		if t.focus != nil && e.Key() == tcell.KeyEscape {
+			t.focus.Quit()
+			return true
+		}
+	case *tcell.EventPaste:
+		t.closeTransientFloats(nil)
+	case *tcell.EventMouse:
+		if t.resizing != nil {
+			return false
+		}
+		mx, my := e.Position()
+		btn := e.Buttons()
+		wheel := btn&(tcell.WheelUp|tcell.WheelDown|tcell.WheelLeft|tcell.WheelRight) != tcell.ButtonNone
+		f := t.floatAt(mx, my)
+		if f == nil {
+			if btn != tcell.ButtonNone && !wheel && t.release {
+				t.closeTransientFloats(nil)
+			}
+			return false
+		}
+		if wheel {
+			f.HandleEvent(event)
+			return true
+		}
+		if btn == tcell.ButtonNone {
+			t.release = true
+		} else if t.release {
+			t.release = false
+			t.closeTransientFloats(f)
+			if f.Float().Focusable && t.focus != f {
+				t.FocusFloat(f)
+			}
+		}
+		if t.focus == f {
+			f.HandleEvent(event)
+		}
+		return true
+	}
+	return false
+}
+func (t *Tab) displayFloats() {
+	for _, p := range t.floats {
+		p.Float().Place(t.X, t.Y, t.W, t.H)
+		p.Display()
+	}
+}
\ No newline at end of file
@@ -0,0 +1,177 @@
+package action
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"github.com/zyedidia/micro/v2/internal/buffer"
+	"github.com/zyedidia/micro/v2/internal/git"
+	"github.com/zyedidia/micro/v2/internal/shell"
+)
+func gitChanged(path string) {
+	dir, name := filepath.Dir(path), filepath.Base(path)
+	if name != "index" && name != "HEAD" {
+		return
+	}
+	for _, b := range buffer.OpenBuffers {
+		if b.GitDir == dir {
+			updateGitBase(b)
+			if b.HasBlame() {
+				blameBuffer(b)
+			}
+		}
+	}
+}
+func updateGitBase(b *buffer.Buffer) {
+	b.UpdateGitBaseAsync(func(f func()) {
+		shell.Jobs <- shell.JobFunction{Function: func(string, []interface{}) {
+			f()
+		}}
+	})
+}
+func blameBuffer(b *buffer.Buffer) {
+	path := b.AbsPath
+	go func() {
+		repo, err := git.Open(path)
+		var lines []git.BlameLine
+		var content []byte
+		if err == nil {
+			lines, content, err = repo.Blame(path)
+		}
+		shell.Jobs <- shell.JobFunction{Function: func(string, []interface{}) {
+			if err != nil {
+				InfoBar.Error(err)
+				return
+			}
+			annotations := make([]string, len(lines))
+			for i, l := range lines {
+				author := []rune(l.Author)
+				if len(author) > 16 {
+					author = author[:16]
+				}
+				annotations[i] = fmt.Sprintf("%.7s %-16s %s", l.Hash, string(author), l.Date.Format("2006-01-02"))
+			}
+			b.SetBlame(content, annotations, "Not committed yet")
+		}}
+	}()
+}
+func (h *BufPane) BlameCmd(args []string) {
+	if h.Buf.HasBlame() {
+		h.Buf.SetBlame(nil, nil, "")
+		return
+	}
+	if h.Buf.Path == "" {
+		InfoBar.Error("Buffer has no file to blame")
+		return
+	}
+	blameBuffer(h.Buf)
+}
+func (h *BufPane) diffHunk() (buffer.DiffHunk, bool) {
+	if !h.Buf.Settings["diffgutter"].(bool) {
+		InfoBar.Error("The diffgutter option must be enabled")
+		return buffer.DiffHunk{}, false
+	}
+	hunk, ok := h.Buf.DiffHunkAt(h.Cursor.Y)
+	if !ok {
+		InfoBar.Message("No diff hunk under the cursor")
+	}
+	return hunk, ok
+}
+func (h *BufPane) gitRepo() *git.Repo {
+	if h.Buf.Path == "" {
+		InfoBar.Error("Buffer has no file")
+		return nil
+	}
+	if h.Buf.Encrypted() || h.Buf.Compression != nil {
+		InfoBar.Error("Cannot stage hunks of an encrypted or compressed file")
+		return nil
+	}
+	repo, err := git.Open(h.Buf.AbsPath)
+	if err != nil {
+		InfoBar.Error(err)
+		return nil
+	}
+	return repo
+}
+func spliceLines(dst, src []byte, start, end, srcStart, srcEnd int) []byte {
+	d, s := bytes.Split(dst, []byte{'\n'}), bytes.Split(src, []byte{'\n'})
+	lines := make([][]byte, 0, len(d)+srcEnd-srcStart)
+	lines = append(lines, d[:start]...)
+	lines = append(lines, s[srcStart:srcEnd]...)
+	lines = append(lines, d[end:]...)
+	return bytes.Join(lines, []byte{'\n'})
+}
+func (h *BufPane) StageCmd(args []string) {
+	repo := h.gitRepo()
+	if repo == nil {
+		return
+	}
+	content := h.Buf.Bytes()
+	index, err := repo.Index(h.Buf.AbsPath)
+	if err == nil {
+		hunk, ok := buffer.FindDiffHunk(buffer.LineDiff(index, content), h.Cursor.Y)
+		if !ok {
+			InfoBar.Message("No diff hunk under the cursor")
+			return
+		}
+		content = spliceLines(index, content, hunk.BaseStart, hunk.BaseEnd, hunk.Start, hunk.End)
+	} else if err != git.ErrNotTracked {
+		InfoBar.Error(err)
+		return
+	}
+	if err := repo.Stage(h.Buf.AbsPath, content); err != nil {
+		InfoBar.Error(err)
+		return
+	}
+	updateGitBase(h.Buf)
+	InfoBar.Message("Staged hunk")
+}
+func (h *BufPane) UnstageCmd(args []string) {
+	repo := h.gitRepo()
+	if repo == nil {
+		return
+	}
+	head, err := repo.Head(h.Buf.AbsPath)
+	var index []byte
+	if err == nil {
+		index, err = repo.Index(h.Buf.AbsPath)
+	}
+	if err != nil {
+		InfoBar.Error(err)
+		return
+	}
+	line := h.Cursor.Y
+	for _, bh := range buffer.LineDiff(index, h.Buf.Bytes()) {
+		if h.Cursor.Y < bh.Start {
+			break
+		} else if h.Cursor.Y < bh.End {
+			line = bh.BaseStart
+			break
+		}
+		line = h.Cursor.Y - bh.End + bh.BaseEnd
+	}
+	hunk, ok := buffer.FindDiffHunk(buffer.LineDiff(head, index), line)
+	if !ok {
+		InfoBar.Message("No staged hunk under the cursor")
+		return
+	}
+	content := spliceLines(index, head, hunk.Start, hunk.End, hunk.BaseStart, hunk.BaseEnd)
+	if err := repo.Stage(h.Buf.AbsPath, content); err != nil {
+		InfoBar.Error(err)
+		return
+	}
+	updateGitBase(h.Buf)
+	InfoBar.Message("Unstaged hunk")
+}
+func (h *BufPane) RevertCmd(args []string) {
+	hunk, ok := h.diffHunk()
+	if !ok {
+		return
+	}
+	base := strings.Split(string(h.Buf.DiffBase()), "\n")[hunk.BaseStart:hunk.BaseEnd]
+	for i := range base {
+		base[i] = strings.TrimSuffix(base[i], "\r")
+	}
+	replaceLines(h.Buf, hunk.Start, hunk.End, base)
+	h.Relocate()
+}
@@ -0,0 +1,126 @@
+package action
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	lua "github.com/yuin/gopher-lua"
+	"github.com/zyedidia/micro/v2/internal/buffer"
+	"github.com/zyedidia/micro/v2/internal/compression"
+	"github.com/zyedidia/micro/v2/internal/config"
+	"github.com/zyedidia/micro/v2/internal/encryption"
+	ulua "github.com/zyedidia/micro/v2/internal/lua"
+	"github.com/zyedidia/micro/v2/internal/screen"
+)
+func init() {
+	ulua.L = lua.NewState()
+	config.InitRuntimeFiles(false)
+	config.InitGlobalSettings()
+	config.GlobalSettings["backup"] = false
+	screen.InitSimScreen()
+	InitGlobals()
+}
+func gitCmd(t *testing.T, dir string, args ...string) string {
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
+func TestStageRefusesEncodedBuffers(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := ioutil.TempDir("", "mecro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.ConfigDir = dir
+	path := filepath.Join(dir, "file.txt")
+	if err := ioutil.WriteFile(path, []byte("one\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitCmd(t, dir, "init", "-q")
+	gitCmd(t, dir, "add", "file.txt")
+	gitCmd(t, dir, "commit", "-q", "-m", "init")
+	tests := []struct {
+		name  string
+		setup func(b *buffer.Buffer)
+	}{
+		{"compressed", func(b *buffer.Buffer) {
+			b.Compression = &compression.Codec{Format: compression.Gzip, Level: gzip.DefaultCompression}
+		}},
+		{"encrypted", func(b *buffer.Buffer) {
+			b.Encryption = encryption.NewKey("secret")
+		}},
+	}
+	for _, tt := range tests {
+		b := buffer.NewBufferFromString("one\ntwo\n", path, buffer.BTDefault)
+		tt.setup(b)
+		b.SetOptionNative("diffgutter", true)
+		b.SetDiffBase([]byte("one\n"))
+		h := NewBufPaneFromBuf(b, nil)
+		h.Cursor.GotoLoc(buffer.Loc{X: 0, Y: 1})
+		for _, cmd := range []func([]string){h.StageCmd, h.UnstageCmd} {
+			InfoBar.Reset()
+			cmd(nil)
+			if !InfoBar.HasError || !strings.Contains(InfoBar.Msg, "encrypted or compressed") {
+				t.Errorf("%s: got message %q, want a refusal", tt.name, InfoBar.Msg)
+			}
+		}
+		if out := gitCmd(t, dir, "diff", "--cached", "--name-only"); out != "" {
+			t.Errorf("%s: the index was changed: %s", tt.name, out)
+		}
+		b.Close()
+	}
+}
+func TestStageUnstageHunk(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := ioutil.TempDir("", "mecro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.ConfigDir = dir
+	path := filepath.Join(dir, "file.txt")
+	if err := ioutil.WriteFile(path, []byte("one\nthree\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitCmd(t, dir, "init", "-q")
+	gitCmd(t, dir, "add", "file.txt")
+	gitCmd(t, dir, "commit", "-q", "-m", "init")
+	b := buffer.NewBufferFromString("zero\none\nthree\nfour\n", path, buffer.BTDefault)
+	defer b.Close()
+	b.SetOptionNative("diffgutter", true)
+	b.SetDiffBase([]byte("one\nthree\n"))
+	h := NewBufPaneFromBuf(b, nil)
+	tests := []struct {
+		cmd   func([]string)
+		line  int
+		index string
+	}{
+		{h.StageCmd, 3, "one\nthree\nfour\n"},
+		{h.StageCmd, 0, "zero\none\nthree\nfour\n"},
+		{h.UnstageCmd, 3, "zero\none\nthree\n"},
+		{h.UnstageCmd, 0, "one\nthree\n"},
+	}
+	for i, tt := range tests {
+		InfoBar.Reset()
+		h.Cursor.GotoLoc(buffer.Loc{X: 0, Y: tt.line})
+		tt.cmd(nil)
+		if InfoBar.HasError {
+			t.Fatalf("%d: %s", i, InfoBar.Msg)
+		}
+		if index := gitCmd(t, dir, "show", ":file.txt"); index != tt.index {
+			t.Errorf("%d: index is %q, want %q", i, index, tt.index)
+		}
+	}
+}
\ No newline at end of file
@@ -5,6 +5,13 @@ var LogBufPane *BufPane
 func InitGlobals() {
 	InfoBar = NewInfoBar()
 	buffer.LogBuf = buffer.NewBufferFromString("", "Log", buffer.BTLog)
+	buffer.AddOpenCallback(applyQuickfixMessages)
+	buffer.AddOpenCallback(queueDecrypt)
+	buffer.AddOpenCallback(updateGitBase)
+	buffer.AddOpenCallback(lspAttach)
+	buffer.AddCloseCallback(lspDetach)
+	buffer.AddCloseCallback(remoteReleased)
+	buffer.AddChangeCallback(lspChanged)
 }
 func GetInfoBar() *InfoPane {
 	return InfoBar
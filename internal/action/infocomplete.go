@@ -5,6 +5,7 @@ import (
 	"strings"
 	"github.com/zyedidia/micro/v2/internal/buffer"
 	"github.com/zyedidia/micro/v2/internal/config"
+	"github.com/zyedidia/micro/v2/internal/export"
 	"github.com/zyedidia/micro/v2/internal/util"
 	"github.com/zyedidia/micro/v2/pkg/highlight"
 )
@@ -41,6 +42,35 @@ func HelpComplete(b *buffer.Buffer) ([]string, []string) {
 	}
 	return completions, suggestions
 }
+func ColorschemeComplete(b *buffer.Buffer) ([]string, []string) {
+	c := b.GetActiveCursor()
+	input, argstart := b.GetArg()
+	_, suggestions := colorschemeComplete(input)
+	sort.Strings(suggestions)
+	completions := make([]string, len(suggestions))
+	for i := range suggestions {
+		completions[i] = util.SliceEndStr(suggestions[i], c.X-argstart)
+	}
+	return completions, suggestions
+}
+func ExportComplete(b *buffer.Buffer) ([]string, []string) {
+	c := b.GetActiveCursor()
+	input, argstart := b.GetArg()
+	if args := bytes.Split(util.SliceStart(b.LineBytes(c.Y), c.X), []byte{' '}); len(args) > 2 {
+		return buffer.FileComplete(b)
+	}
+	var suggestions []string
+	for _, f := range export.Formats {
+		if strings.HasPrefix(f, input) {
+			suggestions = append(suggestions, f)
+		}
+	}
+	completions := make([]string, len(suggestions))
+	for i := range suggestions {
+		completions[i] = util.SliceEndStr(suggestions[i], c.X-argstart)
+	}
+	return completions, suggestions
+}
 func colorschemeComplete(input string) (string, []string) {
 	var suggestions []string
 	files := config.ListRuntimeFiles(config.RTColorscheme)
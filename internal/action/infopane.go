@@ -53,6 +53,7 @@ func NewInfoPane(ib *info.InfoBuf, w display.BWindow, tab *Tab) *InfoPane {
 	ip.InfoBuf = ib
 	ip.BufPane = NewBufPane(ib.Buffer, w, tab)
 	ip.BufPane.bindings = InfoBufBindings
+	ip.BufPane.vim = nil
 	return ip
 }
 func NewInfoBar() *InfoPane {
@@ -126,9 +127,17 @@ func (h *InfoPane) DoKeyEvent(e KeyEvent) bool {
 	return more
 }
 func (h *InfoPane) HistoryUp() {
+	if activePicker != nil && h.PromptType == "Picker" {
+		activePicker.move(-1)
+		return
+	}
 	h.UpHistory(h.History[h.PromptType])
 }
 func (h *InfoPane) HistoryDown() {
+	if activePicker != nil && h.PromptType == "Picker" {
+		activePicker.move(1)
+		return
+	}
 	h.DownHistory(h.History[h.PromptType])
 }
 func (h *InfoPane) HistorySearchUp() {
@@ -1,6 +1,7 @@
 package action
 import (
 	"bytes"
+	"github.com/zyedidia/micro/v2/internal/config"
 	"github.com/zyedidia/tcell/v2"
 )
 type PaneKeyAction func(Pane) bool
@@ -21,6 +22,7 @@ type TreeAction struct {
 	any    PaneKeyAnyAction
 	mouse  PaneMouseAction
 	modes []ModeConstraint
+	mode  string
 }
 type KeyTree struct {
 	root  *KeyTreeNode
@@ -30,6 +32,7 @@ type KeyTree struct {
 type KeyTreeCursor struct {
 	node *KeyTreeNode
 	recordedEvents []Event
+	keys           []Event
 	wildcards      []KeyEvent
 	mouseInfo      *tcell.EventMouse
 }
@@ -87,6 +90,48 @@ func (k *KeyTree) RegisterMouseBinding(e Event, a PaneMouseAction) {
 		modes:  nil,
 	})
 }
+func (k *KeyTree) RegisterModeKeyBinding(e Event, a PaneKeyAction, mode string) {
+	k.registerBinding(e, TreeAction{
+		action: a,
+		any:    nil,
+		mouse:  nil,
+		modes:  nil,
+		mode:   mode,
+	})
+}
+func (k *KeyTree) RegisterModeMouseBinding(e Event, a PaneMouseAction, mode string) {
+	k.registerBinding(e, TreeAction{
+		action: nil,
+		any:    nil,
+		mouse:  a,
+		modes:  nil,
+		mode:   mode,
+	})
+}
+func sameModes(a, b []ModeConstraint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+func (n *KeyTreeNode) setAction(a TreeAction) {
+	actions := []TreeAction{}
+	for _, old := range n.actions {
+		if old.mode != a.mode || !sameModes(old.modes, a.modes) {
+			actions = append(actions, old)
+		}
+	}
+	if a.mode != "" || len(a.modes) > 0 {
+		n.actions = append([]TreeAction{a}, actions...)
+	} else {
+		n.actions = append(actions, a)
+	}
+}
 func (k *KeyTree) registerBinding(e Event, a TreeAction) {
 	switch ev := e.(type) {
 	case KeyEvent, MouseEvent, RawEvent:
@@ -95,7 +140,7 @@ func (k *KeyTree) registerBinding(e Event, a TreeAction) {
 			newNode = NewKeyTreeNode()
 			k.root.children[e] = newNode
 		}
-		newNode.actions = []TreeAction{a}
+		newNode.setAction(a)
 	case KeySequenceEvent:
 		n := k.root
 		for _, key := range ev.keys {
@@ -106,18 +151,48 @@ func (k *KeyTree) registerBinding(e Event, a TreeAction) {
 			}
 			n = newNode
 		}
-		n.actions = []TreeAction{a}
+		n.setAction(a)
 	}
 }
-func (k *KeyTree) NextEvent(e Event, mouse *tcell.EventMouse) (PaneKeyAction, bool) {
+func (k *KeyTree) reachable(n *KeyTreeNode) bool {
+	if k.activeAction(n) != nil {
+		return true
+	}
+	return k.hasMore(n)
+}
+func (k *KeyTree) hasMore(n *KeyTreeNode) bool {
+	for _, c := range n.children {
+		if k.reachable(c) {
+			return true
+		}
+	}
+	return false
+}
+func isLeader(e Event) bool {
+	leader, ok := findSingleEvent(config.GetGlobalOption("leader").(string))
+	return ok && e == leader
+}
+func (k *KeyTree) next(e Event) (Event, *KeyTreeNode) {
 	n := k.cursor.node
-	c, ok := n.children[e]
-	if !ok {
+	if isLeader(e) {
+		if c, ok := n.children[LeaderEvent{}]; ok && k.reachable(c) {
+			return LeaderEvent{}, c
+		}
+	}
+	if c, ok := n.children[e]; ok && k.reachable(c) {
+		return e, c
+	}
+	return nil, nil
+}
+func (k *KeyTree) NextEvent(e Event, mouse *tcell.EventMouse) (PaneKeyAction, bool) {
+	key, c := k.next(e)
+	if c == nil {
 		return nil, false
 	}
-	more := len(c.children) > 0
+	more := k.hasMore(c)
 	k.cursor.node = c
 	k.cursor.recordedEvents = append(k.cursor.recordedEvents, e)
+	k.cursor.keys = append(k.cursor.keys, key)
 	switch ev := e.(type) {
 	case KeyEvent:
 		if ev.any {
@@ -126,26 +201,63 @@ func (k *KeyTree) NextEvent(e Event, mouse *tcell.EventMouse) (PaneKeyAction, bo
 	case MouseEvent:
 		k.cursor.mouseInfo = mouse
 	}
-	if len(c.actions) > 0 {
-		for _, a := range c.actions {
-			active := true
-			for _, mc := range a.modes {
-				hasMode := k.modes[mc.mode]
-				if hasMode != mc.disabled {
-					active = false
-				}
+	return k.activeAction(c), more
+}
+func (k *KeyTree) isActive(a TreeAction) bool {
+	for _, mc := range a.modes {
+		hasMode := k.modes[mc.mode]
+		if hasMode != mc.disabled {
+			return false
+		}
+	}
+	return a.mode == "" || k.modes[a.mode]
+}
+func (k *KeyTree) activeAction(n *KeyTreeNode) PaneKeyAction {
+	for _, a := range n.actions {
+		if k.isActive(a) {
+			return k.cursor.MakeClosure(a)
+		}
+	}
+	return nil
+}
+func (k *KeyTree) PendingAction() PaneKeyAction {
+	if k.cursor.node == k.root {
+		return nil
+	}
+	return k.activeAction(k.cursor.node)
+}
+func (k *KeyTree) HasModeBinding(e Event) bool {
+	var has func(n *KeyTreeNode) bool
+	has = func(n *KeyTreeNode) bool {
+		for _, a := range n.actions {
+			if a.mode != "" && k.isActive(a) {
+				return true
 			}
-			if active {
-				return k.cursor.MakeClosure(a), more
+		}
+		for _, c := range n.children {
+			if has(c) {
+				return true
 			}
 		}
+		return false
 	}
-	return nil, more
+	_, c := k.next(e)
+	return c != nil && has(c)
+}
+func (k *KeyTree) Pending() bool {
+	return k.cursor.node != k.root
+}
+func (k *KeyTree) PendingEvents() []Event {
+	return k.cursor.recordedEvents
+}
+func (k *KeyTree) PendingKeys() []Event {
+	return k.cursor.keys
 }
 func (k *KeyTree) ResetEvents() {
 	k.cursor.node = k.root
 	k.cursor.wildcards = []KeyEvent{}
 	k.cursor.recordedEvents = []Event{}
+	k.cursor.keys = []Event{}
 	k.cursor.mouseInfo = nil
 }
 func (k *KeyTree) RecordedEventsStr() string {
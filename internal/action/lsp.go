@@ -0,0 +1,598 @@
+package action
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	shellquote "github.com/kballard/go-shellquote"
+	"github.com/zyedidia/micro/v2/internal/buffer"
+	"github.com/zyedidia/micro/v2/internal/config"
+	"github.com/zyedidia/micro/v2/internal/git"
+	"github.com/zyedidia/micro/v2/internal/lsp"
+	"github.com/zyedidia/micro/v2/internal/quickfix"
+	"github.com/zyedidia/micro/v2/internal/shell"
+	"github.com/zyedidia/micro/v2/internal/util"
+)
+var errNoServer = errors.New("No language server for this buffer")
+var errStaleEdit = errors.New("Buffer changed before the language server replied")
+type lspDoc struct {
+	client    *lsp.Client
+	buf       *buffer.Buffer
+	uri       string
+	version   int
+	changes   []lsp.TextDocumentContentChangeEvent
+	dirty     bool
+	scheduled bool
+}
+type lspServer struct {
+	client *lsp.Client
+	queue  []*buffer.Buffer
+}
+var (
+	lspClients = make(map[string]*lspServer)
+	lspDocs    = make(map[*buffer.SharedBuffer]*lspDoc)
+	lspDiagLock    sync.Mutex
+	lspDiagPending map[string][]lsp.Diagnostic
+)
+func lspTimeout() time.Duration {
+	return time.Duration(config.GetGlobalOption("lsptimeout").(float64)) * time.Millisecond
+}
+func setLSPTimeout() {
+	for _, s := range lspClients {
+		if s.client != nil {
+			s.client.SetTimeout(lspTimeout())
+		}
+	}
+}
+func lspAsync(run func() func()) {
+	go func() {
+		done := run()
+		shell.Jobs <- shell.JobFunction{Function: func(string, []interface{}) {
+			done()
+		}}
+	}()
+}
+func lspServerFor(command, path string) (*lspServer, error) {
+	root := filepath.Dir(path)
+	if repo, err := git.Open(path); err == nil {
+		root = repo.Root
+	}
+	key := command + "\x00" + root
+	if s, ok := lspClients[key]; ok && (s.client == nil || s.client.Running()) {
+		return s, nil
+	}
+	args, err := shellquote.Split(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, errors.New("Invalid lspserver")
+	}
+	s := new(lspServer)
+	lspClients[key] = s
+	lspAsync(func() func() {
+		c, err := lsp.Start(root, args[0], args[1:]...)
+		if err == nil {
+			c.OnDiagnostics = queueDiagnostics
+			c.OnMessage = func(msg string) {
+				shell.Jobs <- shell.JobFunction{Function: func(string, []interface{}) {
+					InfoBar.Message(c.Name + ": " + msg)
+				}}
+			}
+			if err = c.Initialize(root); err != nil {
+				go c.Shutdown()
+			}
+		}
+		return func() {
+			if lspClients[key] != s {
+				if err == nil {
+					go c.Shutdown()
+				}
+				return
+			}
+			if err != nil {
+				delete(lspClients, key)
+				InfoBar.Error(err)
+				return
+			}
+			c.SetTimeout(lspTimeout())
+			s.client = c
+			for _, b := range s.queue {
+				if ob := lspOpenBuffer(b.SharedBuffer); ob != nil {
+					lspOpen(c, ob)
+				}
+			}
+			s.queue = nil
+		}
+	})
+	return s, nil
+}
+func lspOpenBuffer(sb *buffer.SharedBuffer) *buffer.Buffer {
+	for _, b := range buffer.OpenBuffers {
+		if b.SharedBuffer == sb {
+			return b
+		}
+	}
+	return nil
+}
+func lspAttach(b *buffer.Buffer) {
+	command := b.Settings["lspserver"].(string)
+	if command == "" || b.Path == "" || b.Type.Kind != buffer.BTDefault.Kind || b.Type.Scratch || b.Locked() {
+		return
+	}
+	if _, ok := lspDocs[b.SharedBuffer]; ok {
+		return
+	}
+	if _, err := os.Stat(b.AbsPath); err != nil {
+		return
+	}
+	s, err := lspServerFor(command, b.AbsPath)
+	if err != nil {
+		InfoBar.Error(err)
+		return
+	}
+	if s.client == nil {
+		s.queue = append(s.queue, b)
+		return
+	}
+	lspOpen(s.client, b)
+}
+func lspOpen(c *lsp.Client, b *buffer.Buffer) {
+	if _, ok := lspDocs[b.SharedBuffer]; ok {
+		return
+	}
+	d := &lspDoc{client: c, buf: b, uri: lsp.PathToURI(b.AbsPath), version: 1}
+	lspDocs[b.SharedBuffer] = d
+	c.DidOpen(d.uri, b.Settings["filetype"].(string), d.version, string(b.Bytes()))
+}
+func lspDetach(b *buffer.Buffer) {
+	d, ok := lspDocs[b.SharedBuffer]
+	if !ok {
+		return
+	}
+	if ob := lspOpenBuffer(b.SharedBuffer); ob != nil {
+		d.buf = ob
+		return
+	}
+	delete(lspDocs, b.SharedBuffer)
+	d.client.DidClose(d.uri)
+}
+func lspChanged(b *buffer.SharedBuffer, delta buffer.Delta) {
+	d, ok := lspDocs[b]
+	if !ok {
+		return
+	}
+	if d.client.Capabilities.SyncKind() == lsp.SyncIncremental {
+		r := &lsp.Range{
+			Start: lspPosition(b.LineBytes(delta.Start.Y), delta.Start),
+			End:   lspPosition(b.LineBytes(delta.End.Y), delta.End),
+		}
+		d.changes = append(d.changes, lsp.TextDocumentContentChangeEvent{Range: r, Text: string(delta.Text)})
+	}
+	d.dirty = true
+	if !d.scheduled {
+		d.scheduled = true
+		time.AfterFunc(200*time.Millisecond, func() {
+			shell.Jobs <- shell.JobFunction{Function: func(string, []interface{}) {
+				d.flush()
+			}}
+		})
+	}
+}
+func (d *lspDoc) flush() {
+	d.scheduled = false
+	if !d.dirty || lspDocs[d.buf.SharedBuffer] != d {
+		return
+	}
+	changes := d.changes
+	d.changes, d.dirty = nil, false
+	switch d.client.Capabilities.SyncKind() {
+	case lsp.SyncNone:
+		return
+	case lsp.SyncFull:
+		changes = []lsp.TextDocumentContentChangeEvent{{Text: string(d.buf.Bytes())}}
+	}
+	d.version++
+	d.client.DidChange(d.uri, d.version, changes)
+}
+func lspSaved(b *buffer.Buffer) {
+	d, ok := lspDocs[b.SharedBuffer]
+	if ok && d.uri != lsp.PathToURI(b.AbsPath) {
+		delete(lspDocs, b.SharedBuffer)
+		d.client.DidClose(d.uri)
+		ok = false
+	}
+	if !ok {
+		lspAttach(b)
+		return
+	}
+	d.flush()
+	d.client.DidSave(d.uri)
+}
+func stopLanguageServers() {
+	for key, s := range lspClients {
+		if s.client != nil {
+			go s.client.Shutdown()
+		}
+		delete(lspClients, key)
+	}
+}
+func lspPosition(line []byte, loc buffer.Loc) lsp.Position {
+	return lsp.Position{Line: loc.Y, Character: lsp.UTF16Offset(line, loc.X)}
+}
+func lspLoc(b *buffer.Buffer, pos lsp.Position) buffer.Loc {
+	if pos.Line >= b.LinesNum() {
+		return b.End()
+	}
+	line := util.Clamp(pos.Line, 0, b.LinesNum()-1)
+	return buffer.Loc{X: lsp.CharOffset(b.LineBytes(line), pos.Character), Y: line}
+}
+func queueDiagnostics(p lsp.PublishDiagnosticsParams) {
+	lspDiagLock.Lock()
+	first := lspDiagPending == nil
+	if first {
+		lspDiagPending = make(map[string][]lsp.Diagnostic)
+	}
+	lspDiagPending[p.URI] = p.Diagnostics
+	lspDiagLock.Unlock()
+	if first {
+		shell.Jobs <- shell.JobFunction{Function: applyDiagnostics}
+	}
+}
+func applyDiagnostics(string, []interface{}) {
+	lspDiagLock.Lock()
+	pending := lspDiagPending
+	lspDiagPending = nil
+	lspDiagLock.Unlock()
+	for _, d := range lspDocs {
+		diags, ok := pending[d.uri]
+		if !ok {
+			continue
+		}
+		b := d.buf
+		b.ClearMessages("lsp")
+		for _, diag := range diags {
+			kind := buffer.MTInfo
+			switch diag.Severity {
+			case lsp.SeverityError:
+				kind = buffer.MTError
+			case lsp.SeverityWarning:
+				kind = buffer.MTWarning
+			}
+			msg := diag.Message
+			if diag.Source != "" {
+				msg = diag.Source + ": " + msg
+			}
+			start, end := lspLoc(b, diag.Range.Start), lspLoc(b, diag.Range.End)
+			if end == start {
+				end = start.Move(1, b)
+			}
+			b.AddMessage(buffer.NewMessage("lsp", msg, start, end, buffer.MsgType(kind)))
+		}
+	}
+}
+func (h *BufPane) lspDoc() (*lspDoc, lsp.Position, error) {
+	d, ok := lspDocs[h.Buf.SharedBuffer]
+	if !ok {
+		return nil, lsp.Position{}, errNoServer
+	}
+	d.flush()
+	return d, lspPosition(h.Buf.LineBytes(h.Cursor.Y), h.Cursor.Loc), nil
+}
+func (h *BufPane) lspCurrent(d *lspDoc, version int) bool {
+	if lspDocs[h.Buf.SharedBuffer] != d || d.version != version || d.dirty {
+		return false
+	}
+	for _, t := range Tabs.List {
+		for _, p := range t.Panes {
+			if p == h {
+				return true
+			}
+		}
+	}
+	return false
+}
+func (h *BufPane) lspComplete() bool {
+	d, pos, err := h.lspDoc()
+	if err != nil {
+		return false
+	}
+	b, uri, version, loc := h.Buf, d.uri, d.version, h.Cursor.Loc
+	lspAsync(func() func() {
+		items, err := d.client.Completion(uri, pos)
+		return func() {
+			if !h.lspCurrent(d, version) || h.Cursor.Loc != loc || b.HasSuggestions {
+				return
+			}
+			if err != nil || !b.Autocomplete(lspCompleter(items)) {
+				b.Autocomplete(buffer.BufferComplete)
+			}
+		}
+	})
+	return true
+}
+func lspCompleter(items []lsp.CompletionItem) buffer.Completer {
+	return func(b *buffer.Buffer) ([]string, []string) {
+		input, _ := b.GetWord()
+		var completions, suggestions []string
+		for _, item := range items {
+			text := item.Text()
+			if !strings.HasPrefix(text, string(input)) || text == string(input) {
+				continue
+			}
+			completions = append(completions, util.SliceEndStr(text, util.CharacterCount(input)))
+			suggestions = append(suggestions, item.Label)
+		}
+		return completions, suggestions
+	}
+}
+const (
+	hoverWidth  = 80
+	hoverHeight = 15
+)
+func (h *BufPane) Hover() bool {
+	d, pos, err := h.lspDoc()
+	if err != nil {
+		InfoBar.Error(err)
+		return false
+	}
+	uri, version := d.uri, d.version
+	lspAsync(func() func() {
+		text, err := d.client.Hover(uri, pos)
+		return func() {
+			if err != nil {
+				InfoBar.Error(err)
+			} else if h.lspCurrent(d, version) {
+				h.showHover(text)
+			}
+		}
+	})
+	return true
+}
+func (h *BufPane) showHover(text string) {
+	var lines []string
+	for _, l := range strings.Split(text, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(l), "```") {
+			lines = append(lines, strings.TrimRight(l, " \t"))
+		}
+	}
+	text = strings.Trim(strings.Join(lines, "\n"), "\n")
+	if strings.TrimSpace(text) == "" {
+		InfoBar.Message("No hover information")
+		return
+	}
+	lines = strings.Split(text, "\n")
+	width, height := 0, 0
+	for _, l := range lines {
+		lw := util.StringWidth([]byte(l), util.CharacterCountInString(l), 4)
+		width = util.Max(width, lw)
+		height += 1 + util.Max(lw-1, 0)/hoverWidth
+	}
+	b := buffer.NewBufferFromString(text, "", buffer.BTHelp)
+	b.SetOptionNative("filetype", "markdown")
+	b.SetOptionNative("softwrap", true)
+	p := h.OpenFloatAt(b, h.Cursor.Loc, util.Min(width, hoverWidth), util.Min(height, hoverHeight), false)
+	p.Float().Transient = true
+}
+func (h *BufPane) lspGoto(loc lsp.Location) {
+	if !h.jumpFrom(lsp.URIToPath(loc.URI)) {
+		return
+	}
+	h.GotoLoc(lspLoc(h.Buf, loc.Range.Start))
+}
+func lspLineText(path string, line int, files map[string][][]byte) []byte {
+	for _, b := range buffer.OpenBuffers {
+		if b.AbsPath == path && line < b.LinesNum() {
+			return b.LineBytes(line)
+		}
+	}
+	lines, ok := files[path]
+	if !ok {
+		data, _ := ioutil.ReadFile(path)
+		lines = bytes.Split(data, []byte{'\n'})
+		files[path] = lines
+	}
+	if line < len(lines) {
+		return bytes.TrimSuffix(lines[line], []byte{'\r'})
+	}
+	return nil
+}
+func lspQuickfix(title string, locs []lsp.Location) {
+	files := make(map[string][][]byte)
+	entries := make([]quickfix.Entry, len(locs))
+	for i, loc := range locs {
+		path := lsp.URIToPath(loc.URI)
+		line := lspLineText(path, loc.Range.Start.Line, files)
+		entries[i] = quickfix.Entry{
+			File: path,
+			Line: loc.Range.Start.Line + 1,
+			Col:  lsp.CharOffset(line, loc.Range.Start.Character) + 1,
+			Msg:  strings.TrimSpace(string(line)),
+		}
+	}
+	setQuickfixList(quickfix.NewList(title, entries))
+}
+func (h *BufPane) GotoDefinition() bool {
+	d, pos, err := h.lspDoc()
+	if err != nil {
+		InfoBar.Error(err)
+		return false
+	}
+	uri, version := d.uri, d.version
+	lspAsync(func() func() {
+		locs, err := d.client.Definition(uri, pos)
+		return func() {
+			if err != nil {
+				InfoBar.Error(err)
+				return
+			}
+			if !h.lspCurrent(d, version) {
+				return
+			}
+			switch len(locs) {
+			case 0:
+				InfoBar.Message("No definition found")
+			case 1:
+				h.lspGoto(locs[0])
+			default:
+				lspQuickfix("Definitions", locs)
+				h.OpenQuickfix()
+			}
+		}
+	})
+	return true
+}
+func (h *BufPane) FindReferences() bool {
+	d, pos, err := h.lspDoc()
+	if err != nil {
+		InfoBar.Error(err)
+		return false
+	}
+	uri, version := d.uri, d.version
+	lspAsync(func() func() {
+		locs, err := d.client.References(uri, pos)
+		return func() {
+			if err != nil {
+				InfoBar.Error(err)
+				return
+			}
+			if !h.lspCurrent(d, version) {
+				return
+			}
+			if len(locs) == 0 {
+				InfoBar.Message("No references found")
+				return
+			}
+			lspQuickfix("References", locs)
+			h.OpenQuickfix()
+			InfoBar.Message(fmt.Sprintf("%d references", len(locs)))
+		}
+	})
+	return true
+}
+func applyTextEdits(b *buffer.Buffer, edits []lsp.TextEdit) {
+	deltas := make([]buffer.Delta, len(edits))
+	for i, e := range edits {
+		deltas[i] = buffer.Delta{Text: []byte(e.NewText), Start: lspLoc(b, e.Range.Start), End: lspLoc(b, e.Range.End)}
+	}
+	order := make([]int, len(deltas))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		a, b := deltas[order[i]].Start, deltas[order[j]].Start
+		if a == b {
+			return order[i] > order[j]
+		}
+		return b.LessThan(a)
+	})
+	sorted := make([]buffer.Delta, len(deltas))
+	for i, k := range order {
+		sorted[i] = deltas[k]
+	}
+	b.MultipleReplace(sorted)
+	b.RelocateCursors()
+}
+func (h *BufPane) rename(name string) {
+	d, pos, err := h.lspDoc()
+	if err != nil {
+		InfoBar.Error(err)
+		return
+	}
+	uri, version := d.uri, d.version
+	lspAsync(func() func() {
+		edit, err := d.client.Rename(uri, pos, name)
+		return func() {
+			if err != nil {
+				InfoBar.Error(err)
+			} else if !h.lspCurrent(d, version) {
+				InfoBar.Error(errStaleEdit)
+			} else {
+				h.applyRename(name, edit)
+			}
+		}
+	})
+}
+func (h *BufPane) applyRename(name string, edit *lsp.WorkspaceEdit) {
+	files := edit.Files()
+	for uri, edits := range files {
+		path := lsp.URIToPath(uri)
+		var target *buffer.Buffer
+		for _, b := range buffer.OpenBuffers {
+			if b.AbsPath == path && b.Type.Kind == buffer.BTDefault.Kind {
+				target = b
+				break
+			}
+		}
+		if target == nil {
+			var err error
+			if target, err = openBufferFile(path); err != nil {
+				InfoBar.Error(err)
+				return
+			}
+		}
+		applyTextEdits(target, edits)
+	}
+	h.Relocate()
+	InfoBar.Message(fmt.Sprintf("Renamed to %s in %d files", name, len(files)))
+}
+func (h *BufPane) RenameSymbol() bool {
+	if _, ok := lspDocs[h.Buf.SharedBuffer]; !ok {
+		InfoBar.Error(errNoServer)
+		return false
+	}
+	InfoBar.Prompt("Rename to: ", "", "RenameSymbol", nil, func(resp string, canceled bool) {
+		if !canceled && resp != "" {
+			h.rename(resp)
+		}
+	})
+	return true
+}
+func (h *BufPane) FormatBuffer() bool {
+	d, _, err := h.lspDoc()
+	if err != nil {
+		InfoBar.Error(err)
+		return false
+	}
+	uri, version := d.uri, d.version
+	tabsize, spaces := int(h.Buf.Settings["tabsize"].(float64)), h.Buf.Settings["tabstospaces"].(bool)
+	lspAsync(func() func() {
+		edits, err := d.client.Formatting(uri, tabsize, spaces)
+		return func() {
+			if err != nil {
+				InfoBar.Error(err)
+			} else if !h.lspCurrent(d, version) {
+				InfoBar.Error(errStaleEdit)
+			} else {
+				applyTextEdits(h.Buf, edits)
+				h.Relocate()
+			}
+		}
+	})
+	return true
+}
+func (h *BufPane) HoverCmd(args []string) {
+	h.Hover()
+}
+func (h *BufPane) DefinitionCmd(args []string) {
+	h.GotoDefinition()
+}
+func (h *BufPane) ReferencesCmd(args []string) {
+	h.FindReferences()
+}
+func (h *BufPane) RenameSymbolCmd(args []string) {
+	if len(args) == 0 {
+		h.RenameSymbol()
+		return
+	}
+	h.rename(args[0])
+}
+func (h *BufPane) FormatCmd(args []string) {
+	h.FormatBuffer()
+}
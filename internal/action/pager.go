@@ -0,0 +1,52 @@
+package action
+import (
+	"time"
+	"github.com/zyedidia/micro/v2/internal/buffer"
+	"github.com/zyedidia/micro/v2/internal/shell"
+)
+var pagerBindings = map[string]string{
+	"q": "QuitAll",
+	" ": "PageDown",
+	"f": "PageDown",
+	"b": "PageUp",
+	"j": "ScrollDown",
+	"k": "ScrollUp",
+	"g": "CursorStart",
+	"G": "CursorEnd",
+	"/": "Find",
+	"n": "FindNext",
+	"N": "FindPrevious",
+	"F": "ToggleFollow",
+}
+func InitPager() {
+	for _, b := range buffer.OpenBuffers {
+		b.Type.Readonly = true
+		b.SetOptionNative("reload", "auto")
+	}
+	for k, v := range pagerBindings {
+		BindKey(k, v, Binder["buffer"])
+	}
+}
+func (h *BufPane) ToggleFollow() bool {
+	h.follow = !h.follow
+	h.followGen++
+	if !h.follow {
+		InfoBar.Message("Stopped following")
+		return true
+	}
+	InfoBar.Message("Following ", h.Buf.GetName())
+	h.CursorEnd()
+	h.pollFollow(h.followGen)
+	return true
+}
+func (h *BufPane) pollFollow(gen int) {
+	time.AfterFunc(500*time.Millisecond, func() {
+		shell.Jobs <- shell.JobFunction{Function: func(string, []interface{}) {
+			if !h.follow || h.followGen != gen {
+				return
+			}
+			h.checkReload()
+			h.pollFollow(gen)
+		}}
+	})
+}
\ No newline at end of file
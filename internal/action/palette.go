@@ -0,0 +1,227 @@
+package action
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"github.com/zyedidia/micro/v2/internal/config"
+	"github.com/zyedidia/micro/v2/internal/shell"
+	"github.com/zyedidia/micro/v2/internal/util"
+)
+const paletteRecent = 10
+type paletteEntry struct {
+	id   string
+	name string
+	keys []string
+	desc string
+	run  func(h *BufPane)
+}
+type commandDoc struct {
+	args bool
+	desc string
+}
+func parseCommandDocs(data string, docs map[string]commandDoc) {
+	var name string
+	var doc commandDoc
+	flush := func() {
+		if _, ok := docs[name]; name != "" && !ok {
+			docs[name] = doc
+		}
+		name = ""
+	}
+	for _, l := range strings.Split(data, "\n") {
+		if strings.HasPrefix(l, "* `") {
+			flush()
+			end := strings.Index(l[3:], "`")
+			if end < 0 {
+				continue
+			}
+			fields := strings.Fields(l[3 : 3+end])
+			if len(fields) == 0 {
+				continue
+			}
+			name = fields[0]
+			doc = commandDoc{desc: strings.TrimPrefix(l[4+end:], ":")}
+			for _, f := range fields[1:] {
+				if !strings.HasPrefix(f, "[") {
+					doc.args = true
+				}
+			}
+		} else if name != "" && strings.HasPrefix(l, "   ") {
+			doc.desc += " " + l
+		} else {
+			flush()
+		}
+	}
+	flush()
+}
+func commandDocs() map[string]commandDoc {
+	docs := make(map[string]commandDoc)
+	names := []string{"commands"}
+	for _, p := range config.Plugins {
+		if p.Loaded {
+			names = append(names, p.Name)
+		}
+	}
+	for _, n := range names {
+		if f := config.FindRuntimeFile(config.RTHelp, n); f != nil {
+			if data, err := f.Data(); err == nil {
+				parseCommandDocs(string(data), docs)
+			}
+		}
+	}
+	for n, d := range docs {
+		desc := strings.Join(strings.Fields(d.desc), " ")
+		if i := strings.Index(desc, ". "); i >= 0 {
+			desc = desc[:i]
+		}
+		d.desc = capitalize(strings.TrimSuffix(desc, "."))
+		docs[n] = d
+	}
+	return docs
+}
+func describeAction(name string) string {
+	var words []string
+	start := 0
+	r := []rune(name)
+	for i := 1; i <= len(r); i++ {
+		if i == len(r) || unicode.IsUpper(r[i]) && (!unicode.IsUpper(r[i-1]) || i+1 < len(r) && unicode.IsLower(r[i+1])) {
+			words = append(words, strings.ToLower(string(r[start:i])))
+			start = i
+		}
+	}
+	return capitalize(strings.Join(words, " "))
+}
+func capitalize(s string) string {
+	r := []rune(s)
+	if len(r) > 0 {
+		r[0] = unicode.ToUpper(r[0])
+	}
+	return string(r)
+}
+func bindingKeys() map[string][]string {
+	keys := make(map[string][]string)
+	for k, v := range config.Bindings["buffer"] {
+		for _, a := range strings.FieldsFunc(v, func(r rune) bool { return r == '&' || r == '|' || r == ',' }) {
+			if strings.HasPrefix(a, "command:") || strings.HasPrefix(a, "command-edit:") {
+				fields := strings.Fields(strings.SplitN(a, ":", 2)[1])
+				if len(fields) == 0 {
+					continue
+				}
+				a = "command:" + fields[0]
+			}
+			keys[a] = append(keys[a], k)
+		}
+	}
+	for _, k := range keys {
+		sort.Slice(k, func(i, j int) bool {
+			if len(k[i]) != len(k[j]) {
+				return len(k[i]) < len(k[j])
+			}
+			return k[i] < k[j]
+		})
+	}
+	return keys
+}
+func paletteEntries() []*paletteEntry {
+	keys := bindingKeys()
+	var entries []*paletteEntry
+	for name := range BufKeyActions {
+		name := name
+		entries = append(entries, &paletteEntry{
+			id:   name,
+			name: name,
+			keys: keys[name],
+			desc: describeAction(name),
+			run: func(h *BufPane) {
+				h.runAction(name)
+			},
+		})
+	}
+	docs := commandDocs()
+	for name := range commands {
+		name := name
+		doc, ok := docs[name]
+		entries = append(entries, &paletteEntry{
+			id:   "command:" + name,
+			name: "> " + name,
+			keys: keys["command:"+name],
+			desc: doc.desc,
+			run: func(h *BufPane) {
+				if doc.args || !ok {
+					h.commandPrompt(name + " ")
+				} else {
+					h.HandleCommand(name)
+				}
+			},
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return strings.ToLower(strings.TrimPrefix(entries[i].name, "> ")) < strings.ToLower(strings.TrimPrefix(entries[j].name, "> "))
+	})
+	history := InfoBar.History["Palette"]
+	var recent []*paletteEntry
+	for i := len(history) - 1; i >= 0 && len(recent) < paletteRecent; i-- {
+		for j, e := range entries {
+			if e.id == history[i] {
+				recent = append(recent, e)
+				entries = append(entries[:j], entries[j+1:]...)
+				break
+			}
+		}
+	}
+	return append(recent, entries...)
+}
+func (h *BufPane) runAction(name string) {
+	a := BufKeyActions[name]
+	for i, c := range h.Buf.GetCursors() {
+		if c == nil {
+			continue
+		}
+		h.Buf.SetCurCursor(c.Num)
+		h.Cursor = c
+		h.execAction(a, name, i, nil)
+	}
+}
+func (h *BufPane) commandPrompt(input string) {
+	InfoBar.Prompt("> ", input, "Command", nil, func(resp string, canceled bool) {
+		if !canceled {
+			MainTab().CurPane().HandleCommand(resp)
+		}
+	})
+}
+func (h *BufPane) CommandPalette() bool {
+	commands["palette"].action(h, nil)
+	return true
+}
+func (h *BufPane) PaletteCmd(args []string) {
+	entries := paletteEntries()
+	nameWidth, keyWidth := 0, 0
+	keys := make([]string, len(entries))
+	for i, e := range entries {
+		if len(e.keys) > 2 {
+			e.keys = e.keys[:2]
+		}
+		keys[i] = strings.Join(e.keys, " ")
+		nameWidth = util.Max(nameWidth, len(e.name))
+		keyWidth = util.Max(keyWidth, len(keys[i]))
+	}
+	width := h.GetView().Width - 8
+	items := make([]string, len(entries))
+	for i, e := range entries {
+		item := []rune(strings.TrimRight(fmt.Sprintf("%-*s  %-*s  %s", nameWidth, e.name, keyWidth, keys[i], e.desc), " "))
+		if width > 0 && len(item) > width {
+			item = append(item[:width-1], '…')
+		}
+		items[i] = string(item)
+	}
+	h.Pick("Palette", items, 0, nil, func(i int, canceled bool) {
+		if canceled {
+			return
+		}
+		InfoBar.AddToHistory("Palette", entries[i].id)
+		shell.Jobs <- shell.JobFunction{Function: func(string, []interface{}) {
+			entries[i].run(MainTab().CurPane())
+		}}
+	})
+}
\ No newline at end of file
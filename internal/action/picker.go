@@ -0,0 +1,90 @@
+package action
+import (
+	"sort"
+	"strings"
+	"github.com/zyedidia/micro/v2/internal/buffer"
+	"github.com/zyedidia/micro/v2/internal/util"
+)
+type picker struct {
+	input   string
+	items   []string
+	matches []int
+	sel     int
+	item    int
+	pane    *BufPane
+	onMove  func(int)
+}
+var activePicker *picker
+func (h *BufPane) Pick(title string, items []string, selected int, onMove func(int), onDone func(int, bool)) {
+	p := &picker{items: items, item: selected, onMove: onMove}
+	list := buffer.NewBufferFromString("", "", buffer.BTList)
+	list.SetName(title)
+	p.pane = h.HSplitIndex(list, true)
+	activePicker = p
+	p.update("")
+	InfoBar.Prompt(title+": ", "", "Picker", p.filter, func(resp string, canceled bool) {
+		activePicker = nil
+		i := -1
+		if !canceled && len(p.matches) > 0 {
+			i = p.matches[p.sel]
+		}
+		p.pane.ForceQuit()
+		h.tab.SetActive(h.tab.GetPane(h.ID()))
+		onDone(i, canceled || i < 0)
+	})
+}
+func (p *picker) filter(input string) {
+	if input != p.input {
+		p.update(input)
+	}
+}
+func (p *picker) update(input string) {
+	p.input = input
+	type match struct {
+		index int
+		score int
+	}
+	var matches []match
+	for i, item := range p.items {
+		if score, ok := util.FuzzyMatch(input, item); ok {
+			matches = append(matches, match{i, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	p.matches = p.matches[:0]
+	lines := make([]string, len(matches))
+	for i, m := range matches {
+		p.matches = append(p.matches, m.index)
+		lines[i] = p.items[m.index]
+	}
+	list := buffer.NewBufferFromString(strings.Join(lines, "\n"), "", buffer.BTList)
+	list.SetName(p.pane.Buf.GetName())
+	p.pane.OpenBuffer(list)
+	pos := 0
+	for i, m := range p.matches {
+		if m == p.item {
+			pos = i
+			break
+		}
+	}
+	p.sel = -1
+	p.move(pos + 1)
+}
+func (p *picker) move(n int) {
+	if len(p.matches) == 0 {
+		return
+	}
+	sel := util.Clamp(p.sel+n, 0, len(p.matches)-1)
+	if sel == p.sel {
+		return
+	}
+	p.sel = sel
+	p.item = p.matches[sel]
+	p.pane.Cursor.GotoLoc(buffer.Loc{X: 0, Y: sel})
+	p.pane.Relocate()
+	if p.onMove != nil {
+		p.onMove(p.matches[sel])
+	}
+}
@@ -0,0 +1,253 @@
+package action
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	shellquote "github.com/kballard/go-shellquote"
+	"github.com/zyedidia/micro/v2/internal/buffer"
+	"github.com/zyedidia/micro/v2/internal/quickfix"
+	"github.com/zyedidia/micro/v2/internal/shell"
+	"github.com/zyedidia/micro/v2/internal/util"
+)
+var QuickfixCmds = []string{"run", "load", "open", "close", "clear", "next", "prev"}
+func (h *BufPane) QuickfixCmd(args []string) {
+	if len(args) < 1 {
+		InfoBar.Error("Not enough arguments")
+		return
+	}
+	switch args[0] {
+	case "run":
+		if len(args) < 2 {
+			InfoBar.Error("Not enough arguments: provide a command to run")
+			return
+		}
+		h.quickfixRun(args[1:])
+	case "load":
+		h.quickfixLoad(args[1:])
+	case "open":
+		h.OpenQuickfix()
+	case "close":
+		closeQuickfix()
+	case "clear":
+		closeQuickfix()
+		setQuickfixList(quickfix.NewList("", nil))
+	case "next":
+		h.QuickfixNext()
+	case "prev":
+		h.QuickfixPrevious()
+	default:
+		InfoBar.Error("Unknown quickfix command: ", args[0])
+	}
+}
+func (h *BufPane) quickfixFormats() ([]*quickfix.Format, error) {
+	return quickfix.CompileErrorFormat(h.Buf.Settings["errorformat"].(string))
+}
+func (h *BufPane) quickfixRun(args []string) {
+	formats, err := h.quickfixFormats()
+	if err != nil {
+		InfoBar.Error(err)
+		return
+	}
+	dir, _ := os.Getwd()
+	cmd := shellquote.Join(args...)
+	InfoBar.Message("Running ", cmd, "...")
+	shell.JobStart(cmd, nil, nil, func(out string, userargs []interface{}) {
+		showQuickfixList(quickfix.NewList(cmd, quickfix.Parse(out, formats, dir)))
+	})
+}
+func (h *BufPane) quickfixLoad(args []string) {
+	formats, err := h.quickfixFormats()
+	if err != nil {
+		InfoBar.Error(err)
+		return
+	}
+	dir, _ := os.Getwd()
+	if len(args) == 0 {
+		showQuickfixList(quickfix.NewList(h.Buf.GetName(), quickfix.Parse(string(h.Buf.Bytes()), formats, dir)))
+		return
+	}
+	data, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		InfoBar.Error(err)
+		return
+	}
+	showQuickfixList(quickfix.NewList(args[0], quickfix.Parse(string(data), formats, dir)))
+}
+func showQuickfixList(l *quickfix.List) {
+	setQuickfixList(l)
+	if l.Empty() {
+		closeQuickfix()
+		InfoBar.Message("Quickfix: no entries")
+		return
+	}
+	if h := MainTab().CurPane(); h != nil {
+		h.OpenQuickfix()
+	}
+	InfoBar.Message(fmt.Sprintf("Quickfix: %d errors, %d warnings, %d notes", l.Count(quickfix.SevError), l.Count(quickfix.SevWarning), l.Count(quickfix.SevInfo)))
+}
+func setQuickfixList(l *quickfix.List) {
+	quickfix.Current = l
+	for _, b := range buffer.OpenBuffers {
+		applyQuickfixMessages(b)
+	}
+	for _, p := range quickfixPanes() {
+		p.OpenBuffer(newQuickfixBuf())
+	}
+}
+func applyQuickfixMessages(b *buffer.Buffer) {
+	if b.Type.Kind != buffer.BTDefault.Kind {
+		return
+	}
+	b.ClearMessages("quickfix")
+	for _, e := range quickfix.Current.EntriesFor(b.AbsPath) {
+		kind := buffer.MsgType(e.Kind)
+		if e.Col > 0 {
+			start := buffer.Loc{e.Col - 1, e.Line - 1}
+			end := buffer.Loc{e.Col, e.Line - 1}
+			b.AddMessage(buffer.NewMessage("quickfix", e.Msg, start, end, kind))
+		} else {
+			b.AddMessage(buffer.NewMessageAtLine("quickfix", e.Msg, e.Line, kind))
+		}
+	}
+}
+func newQuickfixBuf() *buffer.Buffer {
+	lines := make([]string, len(quickfix.Current.Entries))
+	for i, e := range quickfix.Current.Entries {
+		lines[i] = e.String()
+	}
+	b := buffer.NewBufferFromString(strings.Join(lines, "\n"), "", buffer.BTQuickfix)
+	b.SetName("Quickfix")
+	if quickfix.Current.Cur >= 0 {
+		b.GetActiveCursor().GotoLoc(buffer.Loc{0, quickfix.Current.Cur})
+	}
+	return b
+}
+func quickfixPanes() []*BufPane {
+	var panes []*BufPane
+	for _, t := range Tabs.List {
+		for _, p := range t.Panes {
+			if bp, ok := p.(*BufPane); ok && bp.Buf.Type == buffer.BTQuickfix {
+				panes = append(panes, bp)
+			}
+		}
+	}
+	return panes
+}
+func closeQuickfix() {
+	for _, p := range quickfixPanes() {
+		if len(p.tab.Panes) > 1 {
+			p.ForceQuit()
+		}
+	}
+}
+func (h *BufPane) OpenQuickfix() {
+	for i, p := range MainTab().Panes {
+		if bp, ok := p.(*BufPane); ok && bp.Buf.Type == buffer.BTQuickfix {
+			MainTab().SetActive(i)
+			return
+		}
+	}
+	h.HSplitIndex(newQuickfixBuf(), true)
+}
+func (h *BufPane) QuickfixJump() bool {
+	if h.Buf.Type != buffer.BTQuickfix {
+		return false
+	}
+	e, ok := quickfix.Current.Select(h.Cursor.Y)
+	if !ok {
+		return false
+	}
+	h.quickfixGoto(e)
+	return true
+}
+func (h *BufPane) QuickfixNext() bool {
+	if quickfix.Current.Empty() {
+		InfoBar.Error("No quickfix entries")
+		return false
+	}
+	e, ok := quickfix.Current.Next()
+	if !ok {
+		InfoBar.Message("No more quickfix entries")
+		return false
+	}
+	h.quickfixGoto(e)
+	return true
+}
+func (h *BufPane) QuickfixPrevious() bool {
+	if quickfix.Current.Empty() {
+		InfoBar.Error("No quickfix entries")
+		return false
+	}
+	e, ok := quickfix.Current.Prev()
+	if !ok {
+		InfoBar.Message("No previous quickfix entries")
+		return false
+	}
+	h.quickfixGoto(e)
+	return true
+}
+func (h *BufPane) quickfixGoto(e quickfix.Entry) {
+	abs := e.AbsPath()
+	var target *BufPane
+	for _, p := range h.tab.Panes {
+		bp, ok := p.(*BufPane)
+		if !ok || bp.Buf.Type == buffer.BTQuickfix {
+			continue
+		}
+		if bp.Buf.AbsPath == abs {
+			target = bp
+			break
+		}
+		if target == nil || bp == h {
+			target = bp
+		}
+	}
+	if target == nil || target.Buf.AbsPath != abs {
+		b, err := openBufferFile(e.Name())
+		if err != nil {
+			InfoBar.Error(err)
+			return
+		}
+		if target == nil {
+			target = h.HSplitIndex(b, false)
+		} else {
+			target.OpenBuffer(b)
+		}
+	}
+	h.tab.SetActive(h.tab.GetPane(target.ID()))
+	line := util.Clamp(e.Line-1, 0, target.Buf.LinesNum()-1)
+	col := util.Clamp(e.Col-1, 0, util.CharacterCount(target.Buf.LineBytes(line)))
+	target.RemoveAllMultiCursors()
+	target.GotoLoc(buffer.Loc{col, line})
+	for _, p := range quickfixPanes() {
+		p.GotoLoc(buffer.Loc{0, quickfix.Current.Cur})
+	}
+	InfoBar.Message(fmt.Sprintf("(%d of %d) %s: %s", quickfix.Current.Cur+1, len(quickfix.Current.Entries), e.Kind, e.Msg))
+}
+func QuickfixCmdComplete(b *buffer.Buffer) ([]string, []string) {
+	c := b.GetActiveCursor()
+	input, argstart := b.GetArg()
+	var suggestions []string
+	for _, cmd := range QuickfixCmds {
+		if strings.HasPrefix(cmd, input) {
+			suggestions = append(suggestions, cmd)
+		}
+	}
+	completions := make([]string, len(suggestions))
+	for i := range suggestions {
+		completions[i] = util.SliceEndStr(suggestions[i], c.X-argstart)
+	}
+	return completions, suggestions
+}
+func QuickfixComplete(b *buffer.Buffer) ([]string, []string) {
+	c := b.GetActiveCursor()
+	args := strings.Split(string(util.SliceStart(b.LineBytes(c.Y), c.X)), " ")
+	if len(args) >= 3 && args[len(args)-2] == "load" {
+		return buffer.FileComplete(b)
+	}
+	if len(args) > 2 {
+		return nil, nil
+	}
+	return QuickfixCmdComplete(b)
+}
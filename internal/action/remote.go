@@ -0,0 +1,117 @@
+package action
+import (
+	"os"
+	"path/filepath"
+	"github.com/zyedidia/micro/v2/internal/buffer"
+	"github.com/zyedidia/micro/v2/internal/config"
+	"github.com/zyedidia/micro/v2/internal/remote"
+	"github.com/zyedidia/micro/v2/internal/screen"
+	"github.com/zyedidia/micro/v2/internal/shell"
+	"github.com/zyedidia/micro/v2/internal/util"
+)
+var remoteWaits = make(map[*buffer.Buffer][]func())
+func remoteReleased(b *buffer.Buffer) {
+	for _, done := range remoteWaits[b] {
+		done()
+	}
+	delete(remoteWaits, b)
+}
+func ReleaseRemoteWaits() {
+	for b := range remoteWaits {
+		remoteReleased(b)
+	}
+}
+func HandleRemote(req remote.Request, reply func(remote.Response)) {
+	shell.Jobs <- shell.JobFunction{Function: func(string, []interface{}) {
+		switch req.Command {
+		case "open":
+			remoteOpen(req, reply)
+		case "eval":
+			remoteEval(req, reply)
+		default:
+			reply(remote.Response{Error: "Unknown request " + req.Command})
+		}
+		screen.Redraw()
+	}}
+}
+func remoteOpen(req remote.Request, reply func(remote.Response)) {
+	var bufs []*buffer.Buffer
+	for _, arg := range req.Args {
+		path, pos := util.GetPathAndCursorPosition(arg)
+		loc, err := buffer.ParseCursorLocation(pos)
+		if err != nil {
+			loc = buffer.Loc{X: -1, Y: -1}
+		}
+		if wd, _ := os.Getwd(); !filepath.IsAbs(path) && wd != req.Dir {
+			path = filepath.Join(req.Dir, path)
+		}
+		b, err := buffer.NewBufferFromFileAtLoc(path, buffer.BTDefault, loc)
+		if err != nil {
+			for _, b := range bufs {
+				b.Close()
+			}
+			reply(remote.Response{Error: err.Error()})
+			return
+		}
+		bufs = append(bufs, b)
+	}
+	if len(bufs) == 0 {
+		bufs = append(bufs, buffer.NewBufferFromString("", "", buffer.BTDefault))
+	}
+	width, height := screen.Screen.Size()
+	for _, b := range bufs {
+		h := MainTab().CurPane()
+		switch config.GetGlobalOption("multiopen").(string) {
+		case "vsplit":
+			if h != nil {
+				h.VSplitBuf(b)
+				continue
+			}
+		case "hsplit":
+			if h != nil {
+				h.HSplitBuf(b)
+				continue
+			}
+		}
+		tp := NewTabFromBuffer(0, 0, width, height-1-config.GetInfoBarOffset(), b)
+		Tabs.AddTab(tp)
+		Tabs.SetActive(len(Tabs.List) - 1)
+	}
+	if !req.Wait {
+		reply(remote.Response{})
+		return
+	}
+	pending := len(bufs)
+	for _, b := range bufs {
+		remoteWaits[b] = append(remoteWaits[b], func() {
+			pending--
+			if pending == 0 {
+				reply(remote.Response{})
+			}
+		})
+	}
+}
+func remoteEval(req remote.Request, reply func(remote.Response)) {
+	h := MainTab().CurPane()
+	if h == nil {
+		reply(remote.Response{Error: "The active pane is not a buffer"})
+		return
+	}
+	if InfoBar.HasPrompt {
+		reply(remote.Response{Error: "Mecro is waiting for input"})
+		return
+	}
+	var resp remote.Response
+	for _, cmd := range req.Args {
+		InfoBar.Reset()
+		h.HandleCommand(cmd)
+		if InfoBar.HasError {
+			resp.Error = InfoBar.Msg
+			break
+		}
+		if InfoBar.HasMessage {
+			resp.Output += InfoBar.Msg + "\n"
+		}
+	}
+	reply(resp)
+}
\ No newline at end of file
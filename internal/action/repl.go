@@ -0,0 +1,378 @@
+package action
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"github.com/zyedidia/micro/v2/internal/buffer"
+	ulua "github.com/zyedidia/micro/v2/internal/lua"
+	"github.com/zyedidia/micro/v2/internal/util"
+	"github.com/zyedidia/tcell/v2"
+	lua "github.com/yuin/gopher-lua"
+)
+const (
+	replPrompt   = "> "
+	replCont     = ".. "
+	replMaxItems = 20
+)
+var (
+	replEnvTable *lua.LTable
+	replOut      bytes.Buffer
+	replHistory  []string
+	replModules  = []string{"micro", "micro/buffer", "micro/config", "micro/shell", "micro/util"}
+)
+type replState struct {
+	hist int
+}
+func replEnv() *lua.LTable {
+	if replEnvTable != nil {
+		return replEnvTable
+	}
+	L := ulua.L
+	replEnvTable = L.NewTable()
+	meta := L.NewTable()
+	L.SetField(meta, "__index", L.G.Global)
+	L.SetMetatable(replEnvTable, meta)
+	L.SetField(replEnvTable, "print", L.NewFunction(func(L *lua.LState) int {
+		for i := 1; i <= L.GetTop(); i++ {
+			if i > 1 {
+				replOut.WriteByte('\t')
+			}
+			replOut.WriteString(L.ToStringMeta(L.Get(i)).String())
+		}
+		replOut.WriteByte('\n')
+		return 0
+	}))
+	for _, m := range replModules {
+		if err := L.CallByParam(lua.P{Fn: L.GetGlobal("import"), NRet: 1, Protect: true}, lua.LString(m)); err == nil {
+			L.SetField(replEnvTable, path.Base(m), L.Get(-1))
+			L.Pop(1)
+		}
+	}
+	return replEnvTable
+}
+func replEval(input string) (string, bool, error) {
+	L := ulua.L
+	fn, err := L.LoadString("return " + input)
+	if err != nil {
+		if fn, err = L.LoadString(input); err != nil {
+			msg := err.Error()
+			if strings.Contains(msg, "at EOF:") && !strings.HasSuffix(msg, "unterminated string") {
+				return "", true, nil
+			}
+			return "", false, errors.New(msg)
+		}
+	}
+	fn.Env = replEnv()
+	replOut.Reset()
+	top := L.GetTop()
+	L.Push(fn)
+	err = L.PCall(0, lua.MultRet, nil)
+	out := replOut.String()
+	if err != nil {
+		L.SetTop(top)
+		if e, ok := err.(*lua.ApiError); ok {
+			return out, false, errors.New(e.Object.String())
+		}
+		return out, false, err
+	}
+	var results []string
+	for i := top + 1; i <= L.GetTop(); i++ {
+		results = append(results, replFormat(L.Get(i), 0))
+	}
+	L.SetTop(top)
+	return out + strings.Join(results, "\t"), false, nil
+}
+func replFormat(v lua.LValue, depth int) string {
+	switch v := v.(type) {
+	case lua.LString:
+		return strconv.Quote(string(v))
+	case *lua.LTable:
+		if depth > 1 {
+			return "{...}"
+		}
+		var items []string
+		n := v.Len()
+		for i := 1; i <= n && len(items) < replMaxItems; i++ {
+			items = append(items, replFormat(v.RawGetInt(i), depth+1))
+		}
+		v.ForEach(func(k, val lua.LValue) {
+			if num, ok := k.(lua.LNumber); ok && float64(num) == float64(int(num)) && int(num) >= 1 && int(num) <= n {
+				return
+			}
+			if len(items) > replMaxItems {
+				return
+			}
+			if len(items) == replMaxItems {
+				items = append(items, "...")
+				return
+			}
+			key := "[" + replFormat(k, depth+1) + "]"
+			if s, ok := k.(lua.LString); ok && isLuaIdent(string(s)) {
+				key = string(s)
+			}
+			items = append(items, key+" = "+replFormat(val, depth+1))
+		})
+		return "{" + strings.Join(items, ", ") + "}"
+	case *lua.LUserData:
+		return replGo(reflect.ValueOf(v.Value), depth)
+	}
+	return v.String()
+}
+func replGo(v reflect.Value, depth int) string {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return "nil"
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return "nil"
+		}
+		if v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct {
+			if depth > 0 {
+				return v.Type().String()
+			}
+			return "&" + replGo(v.Elem(), depth)
+		}
+		return replGo(v.Elem(), depth)
+	case reflect.Struct:
+		t := v.Type()
+		if depth > 1 {
+			return t.String()
+		}
+		var fields []string
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.PkgPath == "" {
+				fields = append(fields, f.Name+": "+replGo(v.Field(i), depth+1))
+			}
+		}
+		return t.String() + "{" + strings.Join(fields, ", ") + "}"
+	case reflect.Map:
+		if depth > 0 || v.Len() > replMaxItems {
+			return fmt.Sprintf("%s(len %d)", v.Type(), v.Len())
+		}
+		var items []string
+		for _, k := range v.MapKeys() {
+			items = append(items, replGo(k, depth+1)+": "+replGo(v.MapIndex(k), depth+1))
+		}
+		sort.Strings(items)
+		return v.Type().String() + "{" + strings.Join(items, ", ") + "}"
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 && v.Kind() == reflect.Slice {
+			return strconv.Quote(string(v.Bytes()))
+		}
+		if depth > 0 || v.Len() > replMaxItems {
+			return fmt.Sprintf("%s(len %d)", v.Type(), v.Len())
+		}
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = replGo(v.Index(i), depth+1)
+		}
+		return v.Type().String() + "{" + strings.Join(items, ", ") + "}"
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return v.Type().String()
+	case reflect.String:
+		return strconv.Quote(v.String())
+	}
+	if !v.CanInterface() {
+		return v.Type().String()
+	}
+	return fmt.Sprint(v.Interface())
+}
+func isLuaIdent(s string) bool {
+	for i, r := range s {
+		if r != '_' && !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') && (i == 0 || !(r >= '0' && r <= '9')) {
+			return false
+		}
+	}
+	return s != ""
+}
+func replNames(v lua.LValue, methods bool) []string {
+	var names []string
+	switch v := v.(type) {
+	case *lua.LTable:
+		v.ForEach(func(k, _ lua.LValue) {
+			if s, ok := k.(lua.LString); ok && isLuaIdent(string(s)) {
+				names = append(names, string(s))
+			}
+		})
+		if mt, ok := ulua.L.GetMetatable(v).(*lua.LTable); ok {
+			names = append(names, replNames(mt.RawGetString("__index"), methods)...)
+		}
+	case *lua.LUserData:
+		rv := reflect.ValueOf(v.Value)
+		for i := 0; i < rv.NumMethod(); i++ {
+			names = append(names, rv.Type().Method(i).Name)
+		}
+		for rv.Kind() == reflect.Ptr {
+			rv = rv.Elem()
+		}
+		if !methods && rv.Kind() == reflect.Struct {
+			for i := 0; i < rv.NumField(); i++ {
+				if f := rv.Type().Field(i); f.PkgPath == "" {
+					names = append(names, f.Name)
+				}
+			}
+		}
+	}
+	return names
+}
+func replField(v lua.LValue, name string) lua.LValue {
+	L := ulua.L
+	get := L.NewFunction(func(L *lua.LState) int {
+		L.Push(L.GetField(L.Get(1), L.ToString(2)))
+		return 1
+	})
+	if err := L.CallByParam(lua.P{Fn: get, NRet: 1, Protect: true}, v, lua.LString(name)); err != nil {
+		return lua.LNil
+	}
+	v = L.Get(-1)
+	L.Pop(1)
+	return v
+}
+func (h *BufPane) replComplete(b *buffer.Buffer) ([]string, []string) {
+	c := b.GetActiveCursor()
+	line := []rune(string(b.LineBytes(c.Y)))
+	start := c.X
+	for start > 0 && (util.IsWordChar(line[start-1]) || line[start-1] == '.' || line[start-1] == ':') {
+		start--
+	}
+	expr := string(line[start:c.X])
+	if expr == "" {
+		return nil, nil
+	}
+	sep := strings.LastIndexAny(expr, ".:")
+	var v lua.LValue = replEnv()
+	if sep >= 0 {
+		for _, name := range strings.Split(expr[:sep], ".") {
+			if strings.Contains(name, ":") || name == "" {
+				return nil, nil
+			}
+			if v = replField(v, name); v == lua.LNil {
+				return nil, nil
+			}
+		}
+	}
+	input := expr[sep+1:]
+	seen := make(map[string]bool)
+	var suggestions []string
+	for _, name := range replNames(v, sep >= 0 && expr[sep] == ':') {
+		if strings.HasPrefix(name, input) && name != input && !seen[name] {
+			seen[name] = true
+			suggestions = append(suggestions, name)
+		}
+	}
+	sort.Strings(suggestions)
+	if len(suggestions) > 1 {
+		suggestions = append(suggestions, input)
+	}
+	completions := make([]string, len(suggestions))
+	for i := range suggestions {
+		completions[i] = util.SliceEndStr(suggestions[i], util.CharacterCountInString(input))
+	}
+	return completions, suggestions
+}
+func (h *BufPane) ReplCmd(args []string) {
+	b := buffer.NewBufferFromString("", "", buffer.BTRepl)
+	b.SetName("Lua")
+	b.SetOptionNative("softwrap", true)
+	b.Insert(b.End(), fmt.Sprintf("%s REPL, imported modules: %s\n%s", lua.LuaVersion, strings.Join(replModules, ", "), replPrompt))
+	b.ReadonlyBefore = b.End()
+	e := h.HSplitBuf(b)
+	e.repl = &replState{hist: len(replHistory)}
+	e.Cursor.GotoLoc(b.End())
+}
+func (h *BufPane) replStart() buffer.Loc {
+	if end := h.Buf.End(); end.LessThan(h.Buf.ReadonlyBefore) {
+		h.Buf.ReadonlyBefore = end
+	}
+	return h.Buf.ReadonlyBefore
+}
+func (h *BufPane) replInput() string {
+	text := string(h.Buf.Substr(h.replStart(), h.Buf.End()))
+	return strings.Replace(text, "\n"+replCont, "\n", -1)
+}
+func (h *BufPane) replSetInput(input string) {
+	h.Buf.Replace(h.replStart(), h.Buf.End(), strings.Replace(input, "\n", "\n"+replCont, -1))
+	h.Cursor.GotoLoc(h.Buf.End())
+	h.Relocate()
+}
+func (h *BufPane) replEnter() bool {
+	if h.Cursor.LessThan(h.replStart()) {
+		line := string(h.Buf.LineBytes(h.Cursor.Y))
+		for _, p := range []string{replPrompt, replCont} {
+			line = strings.TrimPrefix(line, p)
+		}
+		h.replSetInput(line)
+		return true
+	}
+	input := h.replInput()
+	out, incomplete, err := replEval(input)
+	if err != nil {
+		out += err.Error()
+	}
+	if incomplete {
+		h.Buf.Insert(h.Buf.End(), "\n"+replCont)
+		h.Cursor.GotoLoc(h.Buf.End())
+		h.Relocate()
+		return true
+	}
+	if strings.TrimSpace(input) != "" && (len(replHistory) == 0 || replHistory[len(replHistory)-1] != input) {
+		replHistory = append(replHistory, input)
+	}
+	h.repl.hist = len(replHistory)
+	out = strings.TrimSuffix(out, "\n")
+	if out != "" {
+		out += "\n"
+	}
+	h.Buf.Insert(h.Buf.End(), "\n"+out+replPrompt)
+	h.Buf.ReadonlyBefore = h.Buf.End()
+	h.Buf.UndoStack = new(buffer.TEStack)
+	h.Buf.RedoStack = new(buffer.TEStack)
+	h.Cursor.ResetSelection()
+	h.Cursor.GotoLoc(h.Buf.End())
+	h.Relocate()
+	return true
+}
+func (h *BufPane) replHistoryMove(n int) bool {
+	start := h.replStart()
+	if h.Cursor.LessThan(start) || (n < 0 && h.Cursor.Y != start.Y) || (n > 0 && h.Cursor.Y != h.Buf.End().Y) {
+		return false
+	}
+	i := h.repl.hist + n
+	if i < 0 || i > len(replHistory) {
+		return true
+	}
+	h.repl.hist = i
+	if i == len(replHistory) {
+		h.replSetInput("")
+	} else {
+		h.replSetInput(replHistory[i])
+	}
+	return true
+}
+func (h *BufPane) replGuard(event tcell.Event) bool {
+	switch e := event.(type) {
+	case *tcell.EventKey:
+		switch e.Key() {
+		case tcell.KeyRune, tcell.KeyBackspace, tcell.KeyBackspace2, tcell.KeyDelete:
+		default:
+			return false
+		}
+		if (e.Key() == tcell.KeyBackspace || e.Key() == tcell.KeyBackspace2) && h.Cursor.Loc == h.replStart() && !h.Cursor.HasSelection() {
+			return true
+		}
+	case *tcell.EventPaste:
+	default:
+		return false
+	}
+	start := h.replStart()
+	if h.Cursor.LessThan(start) || h.Cursor.CurSelection[0].LessThan(start) && h.Cursor.HasSelection() {
+		h.Cursor.ResetSelection()
+		h.Cursor.GotoLoc(h.Buf.End())
+	}
+	return false
+}
\ No newline at end of file
@@ -46,6 +46,7 @@ func (t *TabList) RemoveTab(id uint64) {
 			continue
 		}
 		if p.Panes[0].ID() == id {
+			p.closeFloats()
 			copy(t.List[i:], t.List[i+1:])
 			t.List[len(t.List)-1] = nil
 			t.List = t.List[:len(t.List)-1]
@@ -58,6 +59,12 @@ func (t *TabList) RemoveTab(id uint64) {
 		}
 	}
 }
+func (t *TabList) SetActive(a int) {
+	t.TabWindow.SetActive(a)
+	if h := t.List[a].CurPane(); h != nil && h.vim != nil {
+		h.vim.apply()
+	}
+}
 func (t *TabList) Resize() {
 	w, h := screen.Screen.Size()
 	iOffset := config.GetInfoBarOffset()
@@ -156,6 +163,8 @@ type Tab struct {
 	active int
 	resizing *views.Node
 	release bool
+	floats []*BufPane
+	focus  *BufPane
 }
 func NewTabFromBuffer(x, y, width, height int, b *buffer.Buffer) *Tab {
 	t := new(Tab)
@@ -178,6 +187,9 @@ func NewTabFromPane(x, y, width, height int, pane Pane) *Tab {
 	return t
 }
 func (t *Tab) HandleEvent(event tcell.Event) {
+	if t.handleFloatEvent(event) {
+		return
+	}
 	switch e := event.(type) {
 	case *tcell.EventMouse:
 		mx, my := e.Position()
@@ -232,10 +244,18 @@ func (t *Tab) HandleEvent(event tcell.Event) {
 			}
 		}
 	}
+	if t.focus != nil {
+		t.focus.HandleEvent(event)
+		return
+	}
 	t.Panes[t.active].HandleEvent(event)
 }
 func (t *Tab) SetActive(i int) {
 	t.active = i
+	if t.focus != nil {
+		t.focus.SetActive(false)
+		t.focus = nil
+	}
 	for j, p := range t.Panes {
 		if j == i {
 			p.SetActive(true)
@@ -257,6 +277,7 @@ func (t *Tab) GetPane(splitid uint64) int {
 	return 0
 }
 func (t *Tab) RemovePane(i int) {
+	t.closeOwnedFloats(t.Panes[i].ID())
 	copy(t.Panes[i:], t.Panes[i+1:])
 	t.Panes[len(t.Panes)-1] = nil
 	t.Panes = t.Panes[:len(t.Panes)-1]
@@ -274,7 +295,14 @@ func (t *Tab) Resize() {
 		p.Resize(n.W-offset, n.H)
 	}
 }
+func (t *Tab) Display() {
+	t.UIWindow.Display()
+	t.displayFloats()
+}
 func (t *Tab) CurPane() *BufPane {
+	if t.focus != nil {
+		return t.focus
+	}
 	p, ok := t.Panes[t.active].(*BufPane)
 	if !ok {
 		return nil
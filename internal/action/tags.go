@@ -0,0 +1,196 @@
+package action
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"github.com/zyedidia/micro/v2/internal/buffer"
+	"github.com/zyedidia/micro/v2/internal/tags"
+	"github.com/zyedidia/micro/v2/internal/util"
+	"github.com/zyedidia/micro/v2/internal/watcher"
+)
+type tagEntry struct {
+	path string
+	buf  *buffer.Buffer
+	loc  buffer.Loc
+}
+var tagIndexes = make(map[string]*tags.Index)
+func tagIndex(dir string) (*tags.Index, error) {
+	path, err := tags.Find(dir)
+	if err != nil {
+		return nil, err
+	}
+	if idx, ok := tagIndexes[path]; ok {
+		return idx, nil
+	}
+	idx, err := tags.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	tagIndexes[path] = idx
+	watcher.Watch(path)
+	return idx, nil
+}
+func tagsChanged(path string) {
+	if _, ok := tagIndexes[path]; !ok {
+		return
+	}
+	idx, err := tags.Load(path)
+	if err != nil {
+		delete(tagIndexes, path)
+		watcher.Unwatch(path)
+		return
+	}
+	tagIndexes[path] = idx
+}
+func tagDir(b *buffer.Buffer) string {
+	if b.Path != "" {
+		return filepath.Dir(b.AbsPath)
+	}
+	wd, _ := os.Getwd()
+	return wd
+}
+func (h *BufPane) wordUnderCursor() string {
+	if h.Cursor.HasSelection() {
+		return string(h.Cursor.GetSelection())
+	}
+	line := []rune(string(h.Buf.LineBytes(h.Cursor.Y)))
+	start, end := h.Cursor.X, h.Cursor.X
+	for start > 0 && start <= len(line) && util.IsWordChar(line[start-1]) {
+		start--
+	}
+	for end < len(line) && util.IsWordChar(line[end]) {
+		end++
+	}
+	return string(line[start:end])
+}
+func (h *BufPane) jumpFrom(path string) bool {
+	from := tagEntry{h.Buf.AbsPath, h.Buf, h.Cursor.Loc}
+	if path != h.Buf.AbsPath {
+		b, err := openBufferFile(path)
+		if err != nil {
+			InfoBar.Error(err)
+			return false
+		}
+		h.OpenBuffer(b)
+	}
+	h.tagStack = append(h.tagStack, from)
+	h.RemoveAllMultiCursors()
+	return true
+}
+func (h *BufPane) jumpTag(t tags.Tag) {
+	if !h.jumpFrom(t.File) {
+		return
+	}
+	lines := make([]string, h.Buf.LinesNum())
+	for i := range lines {
+		lines[i] = string(h.Buf.LineBytes(i))
+	}
+	y := util.Clamp(t.Locate(lines), 0, len(lines)-1)
+	x := 0
+	if i := strings.Index(lines[y], t.Name); i >= 0 {
+		x = util.CharacterCountInString(lines[y][:i])
+	}
+	h.GotoLoc(buffer.Loc{X: x, Y: y})
+}
+func (h *BufPane) gotoTag(name string) bool {
+	if name == "" {
+		InfoBar.Error("No tag name given")
+		return false
+	}
+	idx, err := tagIndex(tagDir(h.Buf))
+	if err != nil {
+		InfoBar.Error(err)
+		return false
+	}
+	matches := idx.Lookup(name)
+	switch len(matches) {
+	case 0:
+		InfoBar.Error("Tag not found: ", name)
+		return false
+	case 1:
+		h.jumpTag(matches[0])
+		return true
+	}
+	wd, _ := os.Getwd()
+	items := make([]string, len(matches))
+	for i, t := range matches {
+		file := t.File
+		if rel, err := filepath.Rel(wd, file); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+		if t.Line > 0 {
+			file = fmt.Sprintf("%s:%d", file, t.Line)
+		}
+		items[i] = fmt.Sprintf("%-2s %s  %s", t.Kind, file, strings.TrimSpace(t.Pattern))
+	}
+	h.Pick("Tag "+name, items, 0, nil, func(i int, canceled bool) {
+		if !canceled {
+			h.jumpTag(matches[i])
+		}
+	})
+	return true
+}
+func (h *BufPane) JumpToTag() bool {
+	return h.gotoTag(h.wordUnderCursor())
+}
+func (h *BufPane) PopTag() bool {
+	if len(h.tagStack) == 0 {
+		InfoBar.Message("Tag stack is empty")
+		return false
+	}
+	e := h.tagStack[len(h.tagStack)-1]
+	h.tagStack = h.tagStack[:len(h.tagStack)-1]
+	b := e.buf
+	open := false
+	for _, ob := range buffer.OpenBuffers {
+		if ob == b {
+			open = true
+			break
+		}
+	}
+	if !open {
+		if e.path == "" {
+			InfoBar.Error("Buffer was closed")
+			return false
+		}
+		var err error
+		if b, err = openBufferFile(e.path); err != nil {
+			InfoBar.Error(err)
+			return false
+		}
+	}
+	h.OpenBuffer(b)
+	h.RemoveAllMultiCursors()
+	y := util.Clamp(e.loc.Y, 0, b.LinesNum()-1)
+	x := util.Clamp(e.loc.X, 0, util.CharacterCount(b.LineBytes(y)))
+	h.GotoLoc(buffer.Loc{X: x, Y: y})
+	return true
+}
+func (h *BufPane) TagCmd(args []string) {
+	if len(args) == 0 {
+		h.JumpToTag()
+		return
+	}
+	h.gotoTag(args[0])
+}
+func (h *BufPane) PopTagCmd(args []string) {
+	h.PopTag()
+}
+func TagComplete(b *buffer.Buffer) ([]string, []string) {
+	c := b.GetActiveCursor()
+	input, argstart := b.GetArg()
+	idx, err := tagIndex(tagDir(MainTab().CurPane().Buf))
+	if err != nil {
+		return nil, nil
+	}
+	suggestions := idx.Complete(input)
+	if len(suggestions) > 100 {
+		suggestions = suggestions[:100]
+	}
+	completions := make([]string, len(suggestions))
+	for i := range suggestions {
+		completions[i] = util.SliceEndStr(suggestions[i], c.X-argstart)
+	}
+	return completions, suggestions
+}
@@ -77,7 +77,7 @@ func (t *TermPane) Quit() {
 	} else if len(Tabs.List) > 1 {
 		Tabs.RemoveTab(t.id)
 	} else {
-		screen.Screen.Fini()
+		screen.Fini()
 		InfoBar.Close()
 		runtime.Goexit()
 	}
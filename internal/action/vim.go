@@ -0,0 +1,1105 @@
+package action
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+	"github.com/zyedidia/micro/v2/internal/buffer"
+	"github.com/zyedidia/micro/v2/internal/clipboard"
+	"github.com/zyedidia/micro/v2/internal/config"
+	"github.com/zyedidia/micro/v2/internal/display"
+	"github.com/zyedidia/micro/v2/internal/screen"
+	"github.com/zyedidia/micro/v2/internal/util"
+	"github.com/zyedidia/tcell/v2"
+)
+const (
+	VimNormal   = "normal"
+	VimInsert   = "insert"
+	VimVisual   = "visual"
+	VimOperator = "operator"
+)
+var vimModes = []string{VimNormal, VimInsert, VimVisual}
+var vimCursorShapes = map[string]screen.CursorShape{
+	VimNormal:   screen.CursorBlock,
+	VimInsert:   screen.CursorBar,
+	VimVisual:   screen.CursorBlock,
+	VimOperator: screen.CursorUnderline,
+}
+type vimState struct {
+	mode    string
+	lines   bool
+	count   int
+	opCount int
+	op      string
+	prefix  string
+	find    string
+}
+var vimKeymap bool
+func vimMapEvent(mode string) func(Event, string) {
+	return func(k Event, action string) {
+		BufMapModeEvent(k, mode, action)
+	}
+}
+func setKeymap(name string) {
+	if vimKeymap == (name == "vim") {
+		return
+	}
+	vimKeymap = name == "vim"
+	if Tabs != nil {
+		for _, t := range Tabs.List {
+			for _, p := range t.Panes {
+				if bp, ok := p.(*BufPane); ok {
+					bp.vim = newVimState()
+				}
+			}
+		}
+	}
+	if vimKeymap {
+		if Tabs != nil {
+			if h := MainTab().CurPane(); h != nil && h.vim != nil {
+				h.vim.apply()
+			}
+		}
+		return
+	}
+	for _, m := range vimModes {
+		BufBindings.SetMode(m, false)
+	}
+	display.Mode = ""
+	display.PendingKeys = ""
+	screen.SetCursorShape(screen.CursorDefault)
+}
+func newVimState() *vimState {
+	if !vimKeymap {
+		return nil
+	}
+	return &vimState{mode: VimNormal}
+}
+func (h *BufPane) bindingDesc(name string) string {
+	if h.vim != nil {
+		if desc, ok := config.Bindings[h.vim.mode][name]; ok {
+			return desc
+		}
+	}
+	return config.Bindings["buffer"][name]
+}
+func (v *vimState) setMode(mode string) {
+	v.mode = mode
+	if mode != VimVisual {
+		v.lines = false
+	}
+	v.apply()
+}
+func (v *vimState) apply() {
+	for _, m := range vimModes {
+		BufBindings.SetMode(m, m == v.mode)
+	}
+	switch {
+	case v.mode == VimVisual && v.lines:
+		display.Mode = "VISUAL LINE"
+	case v.mode == VimOperator:
+		display.Mode = "NORMAL"
+	default:
+		display.Mode = strings.ToUpper(v.mode)
+	}
+	screen.SetCursorShape(vimCursorShapes[v.mode])
+}
+func (v *vimState) reset() {
+	v.count, v.opCount = 0, 0
+	v.op, v.prefix = "", ""
+	if v.mode == VimOperator {
+		v.setMode(VimNormal)
+	}
+}
+func (v *vimState) pending() bool {
+	return v.count > 0 || v.op != "" || v.prefix != ""
+}
+func (v *vimState) pendingKeys() string {
+	keys := ""
+	if v.opCount > 0 {
+		keys += strconv.Itoa(v.opCount)
+	}
+	keys += v.op
+	if v.count > 0 {
+		keys += strconv.Itoa(v.count)
+	}
+	return keys + v.prefix
+}
+func (v *vimState) total() int {
+	if v.opCount == 0 && v.count == 0 {
+		return 0
+	}
+	return util.Max(1, v.opCount) * util.Max(1, v.count)
+}
+func vimN(n int) int {
+	return util.Max(1, n)
+}
+func vimKeyName(e KeyEvent) string {
+	if e.code == tcell.KeyRune && e.mod&^tcell.ModShift == 0 {
+		return string(e.r)
+	}
+	switch e.code {
+	case tcell.KeyCtrlR:
+		return "<Ctrl-r>"
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		return "<Backspace>"
+	}
+	if e.mod != 0 {
+		return ""
+	}
+	switch e.code {
+	case tcell.KeyEscape:
+		return "<Esc>"
+	case tcell.KeyEnter:
+		return "<Enter>"
+	case tcell.KeyLeft:
+		return "<Left>"
+	case tcell.KeyRight:
+		return "<Right>"
+	case tcell.KeyUp:
+		return "<Up>"
+	case tcell.KeyDown:
+		return "<Down>"
+	case tcell.KeyHome:
+		return "<Home>"
+	case tcell.KeyEnd:
+		return "<End>"
+	case tcell.KeyDelete:
+		return "<Delete>"
+	}
+	return ""
+}
+var vimKeyAliases = map[string]string{
+	"<Left>":      "h",
+	"<Backspace>": "h",
+	"<Right>":     "l",
+	" ":           "l",
+	"<Down>":      "j",
+	"<Up>":        "k",
+	"<Enter>":     "+",
+	"<Home>":      "0",
+	"<End>":       "$",
+	"<Delete>":    "x",
+}
+var vimNormalAliases = map[string][]string{
+	"x": {"d", "l"},
+	"X": {"d", "h"},
+	"s": {"c", "l"},
+	"S": {"c", "c"},
+	"D": {"d", "$"},
+	"C": {"c", "$"},
+	"Y": {"y", "y"},
+}
+var vimVisualAliases = map[string]string{
+	"x": "d",
+	"s": "c",
+	"X": "d",
+	"D": "d",
+	"Y": "y",
+}
+func (h *BufPane) vimKeyEvent(e KeyEvent) bool {
+	v := h.vim
+	binds := h.Bindings()
+	if binds.HasModeBinding(e) {
+		v.reset()
+		return false
+	}
+	if v.mode == VimInsert {
+		if e.code == tcell.KeyEscape && e.mod == 0 {
+			whichKeyDone()
+			h.flushKeys(binds)
+			return h.NormalMode()
+		}
+		return false
+	}
+	if binds.Pending() {
+		v.reset()
+		return false
+	}
+	name := vimKeyName(e)
+	if name == "" {
+		v.reset()
+		display.PendingKeys = ""
+		return false
+	}
+	if v.mode != VimVisual {
+		h.Cursor.Deselect(true)
+	}
+	handled := v.feed(h, name)
+	if v.mode == VimVisual {
+		for _, c := range h.Buf.GetCursors() {
+			h.vimSelect(c)
+		}
+	}
+	if v.mode == VimNormal {
+		h.vimClampCursors()
+	}
+	display.PendingKeys = v.pendingKeys()
+	h.Relocate()
+	return handled
+}
+func (v *vimState) feed(h *BufPane, key string) bool {
+	if key == "<Esc>" {
+		if v.pending() {
+			v.reset()
+			return true
+		}
+		if v.mode == VimVisual {
+			h.vimExitVisual()
+			return true
+		}
+		return false
+	}
+	switch v.prefix {
+	case "f", "t", "F", "T":
+		cmd := v.prefix
+		v.prefix = ""
+		if utf8.RuneCountInString(key) != 1 {
+			v.reset()
+			return true
+		}
+		v.find = cmd + key
+		return v.motion(h, cmd)
+	case "r":
+		v.prefix = ""
+		if utf8.RuneCountInString(key) == 1 {
+			n := vimN(v.total())
+			r, _ := utf8.DecodeRuneInString(key)
+			h.vimEach(func(c *buffer.Cursor) {
+				h.vimReplace(c, r, n)
+			})
+		}
+		v.reset()
+		return true
+	}
+	if v.prefix == "" && len(key) == 1 && key[0] >= '0' && key[0] <= '9' && (key != "0" || v.count > 0) {
+		v.count = v.count*10 + int(key[0]-'0')
+		return true
+	}
+	if alias, ok := vimKeyAliases[key]; ok && v.prefix == "" {
+		key = alias
+	}
+	if v.prefix == "" && v.op == "" {
+		if seq, ok := vimNormalAliases[key]; ok && v.mode == VimNormal {
+			for _, k := range seq {
+				v.feed(h, k)
+			}
+			return true
+		}
+		if alias, ok := vimVisualAliases[key]; ok && v.mode == VimVisual {
+			key = alias
+		}
+	}
+	k := v.prefix + key
+	v.prefix = ""
+	textObjects := v.op != "" || v.mode == VimVisual
+	switch {
+	case k == "g" || k == "z" || (textObjects && (k == "i" || k == "a")):
+		v.prefix = k
+		return true
+	case k == "f" || k == "t" || k == "F" || k == "T" || (k == "r" && v.op == "" && v.mode == VimNormal):
+		v.prefix = k
+		return true
+	case k == ";" || k == ",":
+		if v.find == "" {
+			v.reset()
+			return true
+		}
+		cmd := v.find[:1]
+		if k == "," {
+			cmd = vimReverseFind[cmd]
+		}
+		return v.motion(h, cmd)
+	case textObjects && len(k) > 1 && (k[0] == 'i' || k[0] == 'a'):
+		return v.textObject(h, k)
+	}
+	if _, ok := vimMotions[k]; ok {
+		return v.motion(h, k)
+	}
+	if strings.Contains("dcy<>", k) && len(k) == 1 {
+		return v.operator(h, k)
+	}
+	if v.op == "" {
+		if cmd, ok := vimCommands[k]; ok && v.mode == VimNormal {
+			cmd(h, v.total())
+			v.reset()
+			return true
+		}
+		if cmd, ok := vimVisualCommands[k]; ok && v.mode == VimVisual {
+			cmd(h, v.total())
+			v.reset()
+			return true
+		}
+	}
+	pending := v.op != "" || v.count > 0 || k != key
+	v.reset()
+	return pending
+}
+func (v *vimState) operator(h *BufPane, op string) bool {
+	if v.mode == VimVisual {
+		lines := v.lines
+		h.vimEach(func(c *buffer.Cursor) {
+			start, end := c.OrigSelection[0], c.Loc
+			if end.LessThan(start) {
+				start, end = end, start
+			}
+			if !lines {
+				end = end.Move(1, h.Buf)
+			}
+			c.ResetSelection()
+			h.vimOperate(op, c, start, end, lines)
+		})
+		if v.mode == VimVisual {
+			v.setMode(VimNormal)
+		}
+		v.reset()
+		return true
+	}
+	if v.op == "" {
+		v.opCount, v.count = v.count, 0
+		v.op = op
+		v.setMode(VimOperator)
+		return true
+	}
+	if v.op == op {
+		n := vimN(v.total())
+		h.vimEach(func(c *buffer.Cursor) {
+			last := util.Min(c.Y+n-1, h.Buf.LinesNum()-1)
+			h.vimOperate(op, c, c.Loc, buffer.Loc{X: c.X, Y: last}, true)
+		})
+	}
+	v.reset()
+	return true
+}
+func (v *vimState) motion(h *BufPane, name string) bool {
+	n := v.total()
+	op := v.op
+	h.vimEach(func(c *buffer.Cursor) {
+		orig := c.Loc
+		motion, mname := vimMotions[name], name
+		if op == "c" && (name == "w" || name == "W") && vimClass(c.RuneUnder(c.X), false) != 0 {
+			mname = strings.Replace(name, "w", "e", 1)
+			mname = strings.Replace(mname, "W", "E", 1)
+			motion = vimMotions[mname]
+		}
+		if !motion.move(h, c, n) {
+			c.GotoLoc(orig)
+			return
+		}
+		if op == "" {
+			return
+		}
+		start, end := orig, c.Loc
+		if end.LessThan(start) {
+			start, end = end, start
+		}
+		if motion.inclusive && end.X < vimLineLen(h.Buf, end.Y) {
+			end = end.Move(1, h.Buf)
+		} else if (mname == "w" || mname == "W") && end.Y > start.Y && end.X <= vimIndent(h.Buf, end.Y) {
+			end = buffer.Loc{X: vimLineLen(h.Buf, end.Y-1), Y: end.Y - 1}
+		} else if !motion.linewise && end.X == 0 && end.Y > start.Y {
+			end = buffer.Loc{X: vimLineLen(h.Buf, end.Y-1), Y: end.Y - 1}
+		}
+		h.vimOperate(op, c, start, end, motion.linewise)
+	})
+	v.reset()
+	return true
+}
+func (v *vimState) textObject(h *BufPane, obj string) bool {
+	op := v.op
+	h.vimEach(func(c *buffer.Cursor) {
+		start, end, ok := vimTextObject(c, obj)
+		if !ok {
+			return
+		}
+		if op != "" && start.X == 0 && end.X == 0 && end.Y > start.Y {
+			h.vimOperate(op, c, start, buffer.Loc{X: 0, Y: end.Y - 1}, true)
+		} else if op != "" {
+			h.vimOperate(op, c, start, end, false)
+		} else if start != end {
+			c.OrigSelection[0] = start
+			c.GotoLoc(end.Move(-1, h.Buf))
+		}
+	})
+	v.reset()
+	return true
+}
+func (h *BufPane) vimEach(f func(c *buffer.Cursor)) {
+	active := h.Buf.GetActiveCursor()
+	for _, c := range h.Buf.GetCursors() {
+		h.Buf.SetCurCursor(c.Num)
+		h.Cursor = c
+		f(c)
+	}
+	h.Buf.SetCurCursor(active.Num)
+	h.Cursor = active
+}
+func (h *BufPane) vimOperate(op string, c *buffer.Cursor, start, end buffer.Loc, linewise bool) {
+	b := h.Buf
+	from := start
+	if linewise {
+		first, last := start.Y, end.Y
+		start = buffer.Loc{X: 0, Y: first}
+		if last < b.LinesNum()-1 {
+			end = buffer.Loc{X: 0, Y: last + 1}
+		} else {
+			end = b.End()
+		}
+		text := string(b.Substr(start, end))
+		if !strings.HasSuffix(text, "\n") {
+			text += "\n"
+		}
+		if op == "d" || op == "c" || op == "y" {
+			clipboard.WriteMulti(text, clipboard.ClipboardReg, c.Num, b.NumCursors())
+		}
+		switch op {
+		case "d":
+			if end == b.End() && first > 0 {
+				start = buffer.Loc{X: vimLineLen(b, first-1), Y: first - 1}
+			}
+			b.Remove(start, end)
+			c.GotoLoc(buffer.Loc{X: 0, Y: util.Min(first, b.LinesNum()-1)})
+			c.StartOfText()
+		case "c":
+			start = buffer.Loc{X: vimIndent(b, first), Y: first}
+			end = buffer.Loc{X: vimLineLen(b, last), Y: last}
+			b.Remove(start, end)
+			c.GotoLoc(start)
+			h.vim.setMode(VimInsert)
+		case "y":
+			c.GotoLoc(from)
+		case ">", "<":
+			c.SetSelectionStart(start)
+			c.SetSelectionEnd(end)
+			if op == ">" {
+				h.IndentSelection()
+			} else {
+				h.OutdentSelection()
+			}
+			c.ResetSelection()
+			c.GotoLoc(buffer.Loc{X: 0, Y: first})
+			c.StartOfText()
+		}
+		return
+	}
+	if start == end {
+		if op == "c" {
+			h.vim.setMode(VimInsert)
+		}
+		return
+	}
+	if op == "d" || op == "c" || op == "y" {
+		clipboard.WriteMulti(string(b.Substr(start, end)), clipboard.ClipboardReg, c.Num, b.NumCursors())
+	}
+	switch op {
+	case "d", "c":
+		b.Remove(start, end)
+		c.GotoLoc(start)
+		if op == "c" {
+			h.vim.setMode(VimInsert)
+		}
+	case "y":
+		c.GotoLoc(start)
+	case ">", "<":
+		h.vimOperate(op, c, start, end.Move(-1, b), true)
+	}
+}
+func (h *BufPane) vimExitVisual() {
+	for _, c := range h.Buf.GetCursors() {
+		c.ResetSelection()
+	}
+	h.vim.setMode(VimNormal)
+}
+func (h *BufPane) vimSelect(c *buffer.Cursor) {
+	b := c.Buf()
+	start, end := c.OrigSelection[0], c.Loc
+	if end.LessThan(start) {
+		start, end = end, start
+	}
+	if h.vim.lines {
+		start.X = 0
+		if end.Y < b.LinesNum()-1 {
+			end = buffer.Loc{X: 0, Y: end.Y + 1}
+		} else {
+			end = b.End()
+		}
+	} else {
+		end = end.Move(1, b)
+	}
+	c.SetSelectionStart(start)
+	c.SetSelectionEnd(end)
+}
+func (h *BufPane) vimClampCursors() {
+	for _, c := range h.Buf.GetCursors() {
+		if !c.HasSelection() {
+			vimClamp(c)
+		}
+	}
+}
+func vimClamp(c *buffer.Cursor) {
+	if n := vimLineLen(c.Buf(), c.Y); c.X >= n && n > 0 {
+		c.X = n - 1
+	}
+}
+func vimLineLen(b *buffer.Buffer, y int) int {
+	return util.CharacterCount(b.LineBytes(y))
+}
+func vimIndent(b *buffer.Buffer, y int) int {
+	return util.CharacterCount(util.GetLeadingWhitespace(b.LineBytes(y)))
+}
+func vimClass(r rune, big bool) int {
+	switch {
+	case unicode.IsSpace(r):
+		return 0
+	case big || util.IsWordChar(r):
+		return 1
+	}
+	return 2
+}
+func vimClassAt(c *buffer.Cursor, x int, big bool) int {
+	return vimClass(c.RuneUnder(x), big)
+}
+func vimWordStart(c *buffer.Cursor, big bool) {
+	end, y := c.Buf().End(), c.Y
+	if cls := vimClassAt(c, c.X, big); cls == 1 && !big {
+		c.WordRight()
+	} else if cls != 0 {
+		for c.Loc != end && vimClassAt(c, c.X, big) == cls {
+			c.Right()
+		}
+	}
+	for c.Loc != end && vimClassAt(c, c.X, big) == 0 {
+		if c.Y > y && vimLineLen(c.Buf(), c.Y) == 0 {
+			break
+		}
+		c.Right()
+	}
+}
+func vimWordEnd(c *buffer.Cursor, big bool) {
+	end := c.Buf().End()
+	c.Right()
+	for c.Loc != end && vimClassAt(c, c.X, big) == 0 {
+		c.Right()
+	}
+	cls := vimClassAt(c, c.X, big)
+	for cls != 0 && vimClassAt(c, c.X+1, big) == cls {
+		c.Right()
+	}
+}
+func vimWordBack(c *buffer.Cursor, big bool) {
+	start := c.Buf().Start()
+	c.Left()
+	for c.Loc != start && vimClassAt(c, c.X, big) == 0 && vimLineLen(c.Buf(), c.Y) > 0 {
+		c.Left()
+	}
+	cls := vimClassAt(c, c.X, big)
+	for cls != 0 && c.X > 0 && vimClassAt(c, c.X-1, big) == cls {
+		c.Left()
+	}
+}
+type vimMotion struct {
+	move      func(h *BufPane, c *buffer.Cursor, n int) bool
+	linewise  bool
+	inclusive bool
+}
+func vimWordMotion(f func(*buffer.Cursor, bool), big bool) func(*BufPane, *buffer.Cursor, int) bool {
+	return func(h *BufPane, c *buffer.Cursor, n int) bool {
+		for i := 0; i < vimN(n); i++ {
+			f(c, big)
+		}
+		return true
+	}
+}
+func vimLineMotion(line func(h *BufPane, c *buffer.Cursor, n int) int) func(*BufPane, *buffer.Cursor, int) bool {
+	return func(h *BufPane, c *buffer.Cursor, n int) bool {
+		y := util.Clamp(line(h, c, n), 0, h.Buf.LinesNum()-1)
+		c.GotoLoc(buffer.Loc{X: 0, Y: y})
+		c.StartOfText()
+		return true
+	}
+}
+func vimFindMotion(cmd string) func(*BufPane, *buffer.Cursor, int) bool {
+	return func(h *BufPane, c *buffer.Cursor, n int) bool {
+		r, _ := utf8.DecodeLastRuneInString(h.vim.find)
+		step := 1
+		if cmd == "F" || cmd == "T" {
+			step = -1
+		}
+		n = vimN(n)
+		for x, end := c.X+step, vimLineLen(h.Buf, c.Y); x >= 0 && x < end; x += step {
+			if c.RuneUnder(x) != r {
+				continue
+			}
+			n--
+			if n > 0 {
+				continue
+			}
+			if cmd == "t" || cmd == "T" {
+				x -= step
+			}
+			c.GotoLoc(buffer.Loc{X: x, Y: c.Y})
+			return true
+		}
+		return false
+	}
+}
+var vimReverseFind = map[string]string{"f": "F", "F": "f", "t": "T", "T": "t"}
+var vimMotions = map[string]vimMotion{
+	"h": {move: func(h *BufPane, c *buffer.Cursor, n int) bool {
+		c.X = util.Max(0, c.X-vimN(n))
+		c.StoreVisualX()
+		return true
+	}},
+	"l": {move: func(h *BufPane, c *buffer.Cursor, n int) bool {
+		c.X = util.Min(vimLineLen(h.Buf, c.Y), c.X+vimN(n))
+		c.StoreVisualX()
+		return true
+	}},
+	"j": {linewise: true, move: func(h *BufPane, c *buffer.Cursor, n int) bool {
+		h.MoveCursorDown(vimN(n))
+		return true
+	}},
+	"k": {linewise: true, move: func(h *BufPane, c *buffer.Cursor, n int) bool {
+		h.MoveCursorUp(vimN(n))
+		return true
+	}},
+	"+": {linewise: true, move: vimLineMotion(func(h *BufPane, c *buffer.Cursor, n int) int {
+		return c.Y + vimN(n)
+	})},
+	"-": {linewise: true, move: vimLineMotion(func(h *BufPane, c *buffer.Cursor, n int) int {
+		return c.Y - vimN(n)
+	})},
+	"gg": {linewise: true, move: vimLineMotion(func(h *BufPane, c *buffer.Cursor, n int) int {
+		return vimN(n) - 1
+	})},
+	"G": {linewise: true, move: vimLineMotion(func(h *BufPane, c *buffer.Cursor, n int) int {
+		if n == 0 {
+			return h.Buf.LinesNum() - 1
+		}
+		return n - 1
+	})},
+	"w": {move: vimWordMotion(vimWordStart, false)},
+	"W": {move: vimWordMotion(vimWordStart, true)},
+	"b": {move: vimWordMotion(vimWordBack, false)},
+	"B": {move: vimWordMotion(vimWordBack, true)},
+	"e": {inclusive: true, move: vimWordMotion(vimWordEnd, false)},
+	"E": {inclusive: true, move: vimWordMotion(vimWordEnd, true)},
+	"0": {move: func(h *BufPane, c *buffer.Cursor, n int) bool {
+		c.Start()
+		return true
+	}},
+	"^": {move: func(h *BufPane, c *buffer.Cursor, n int) bool {
+		c.StartOfText()
+		return true
+	}},
+	"$": {inclusive: true, move: func(h *BufPane, c *buffer.Cursor, n int) bool {
+		c.DownN(vimN(n) - 1)
+		c.End()
+		if c.X > 0 {
+			c.X--
+		}
+		return true
+	}},
+	"%": {inclusive: true, move: func(h *BufPane, c *buffer.Cursor, n int) bool {
+		for x := c.X; x < vimLineLen(h.Buf, c.Y); x++ {
+			r := c.RuneUnder(x)
+			for _, bp := range buffer.BracePairs {
+				if r == bp[0] || r == bp[1] {
+					loc, _, found := h.Buf.FindMatchingBrace(bp, buffer.Loc{X: x, Y: c.Y})
+					if found {
+						c.GotoLoc(loc)
+					}
+					return found
+				}
+			}
+		}
+		return false
+	}},
+	"{": {move: func(h *BufPane, c *buffer.Cursor, n int) bool {
+		for i := 0; i < vimN(n); i++ {
+			h.ParagraphPrevious()
+		}
+		return true
+	}},
+	"}": {move: func(h *BufPane, c *buffer.Cursor, n int) bool {
+		for i := 0; i < vimN(n); i++ {
+			h.ParagraphNext()
+		}
+		return true
+	}},
+	"f": {inclusive: true, move: vimFindMotion("f")},
+	"t": {inclusive: true, move: vimFindMotion("t")},
+	"F": {move: vimFindMotion("F")},
+	"T": {move: vimFindMotion("T")},
+}
+var vimBrackets = map[rune][2]rune{
+	'(': {'(', ')'}, ')': {'(', ')'}, 'b': {'(', ')'},
+	'{': {'{', '}'}, '}': {'{', '}'}, 'B': {'{', '}'},
+	'[': {'[', ']'}, ']': {'[', ']'},
+	'<': {'<', '>'}, '>': {'<', '>'},
+}
+func vimTextObject(c *buffer.Cursor, obj string) (buffer.Loc, buffer.Loc, bool) {
+	around := obj[0] == 'a'
+	kind, _ := utf8.DecodeRuneInString(obj[1:])
+	switch kind {
+	case 'w', 'W':
+		return vimWordObject(c, kind == 'W', around)
+	case '"', '\'', '`':
+		return vimQuoteObject(c, kind, around)
+	}
+	if bp, ok := vimBrackets[kind]; ok {
+		return vimBracketObject(c, bp, around)
+	}
+	return c.Loc, c.Loc, false
+}
+func vimWordObject(c *buffer.Cursor, big, around bool) (buffer.Loc, buffer.Loc, bool) {
+	n := vimLineLen(c.Buf(), c.Y)
+	if n == 0 {
+		return c.Loc, c.Loc, false
+	}
+	x := util.Min(c.X, n-1)
+	cls := vimClassAt(c, x, big)
+	start, end := x, x+1
+	if cls == 1 && !big {
+		word := buffer.NewCursor(c.Buf(), buffer.Loc{X: x, Y: c.Y})
+		word.SelectWord()
+		start, end = word.CurSelection[0].X, word.CurSelection[1].X
+	} else {
+		for start > 0 && vimClassAt(c, start-1, big) == cls {
+			start--
+		}
+		for end < n && vimClassAt(c, end, big) == cls {
+			end++
+		}
+	}
+	if around {
+		if cls != 0 {
+			e := end
+			for e < n && vimClassAt(c, e, big) == 0 {
+				e++
+			}
+			if e > end {
+				end = e
+			} else {
+				for start > 0 && vimClassAt(c, start-1, big) == 0 {
+					start--
+				}
+			}
+		} else if end < n {
+			next := vimClassAt(c, end, big)
+			for end < n && vimClassAt(c, end, big) == next {
+				end++
+			}
+		}
+	}
+	return buffer.Loc{X: start, Y: c.Y}, buffer.Loc{X: end, Y: c.Y}, true
+}
+func vimQuoteObject(c *buffer.Cursor, q rune, around bool) (buffer.Loc, buffer.Loc, bool) {
+	line := []rune(string(c.Buf().LineBytes(c.Y)))
+	var quotes []int
+	for i, r := range line {
+		if r == q && (i == 0 || line[i-1] != '\\') {
+			quotes = append(quotes, i)
+		}
+	}
+	for i := 0; i+1 < len(quotes); i += 2 {
+		if quotes[i+1] < c.X {
+			continue
+		}
+		start, end := quotes[i]+1, quotes[i+1]
+		if around {
+			start, end = start-1, end+1
+		}
+		return buffer.Loc{X: start, Y: c.Y}, buffer.Loc{X: end, Y: c.Y}, true
+	}
+	return c.Loc, c.Loc, false
+}
+func vimBracketObject(c *buffer.Cursor, bp [2]rune, around bool) (buffer.Loc, buffer.Loc, bool) {
+	b := c.Buf()
+	open := buffer.NewCursor(b, c.Loc)
+	for open.RuneUnder(open.X) != bp[0] {
+		if open.RuneUnder(open.X) == bp[1] && open.Loc != c.Loc {
+			if loc, _, found := b.FindMatchingBrace(bp, open.Loc); found {
+				open.GotoLoc(loc)
+			}
+		}
+		if open.Loc == b.Start() {
+			return c.Loc, c.Loc, false
+		}
+		open.Left()
+	}
+	close, _, found := b.FindMatchingBrace(bp, open.Loc)
+	if !found {
+		return c.Loc, c.Loc, false
+	}
+	if around {
+		return open.Loc, close.Move(1, b), true
+	}
+	start, end := open.Loc.Move(1, b), close
+	if start.X == vimLineLen(b, start.Y) && start.Y < end.Y {
+		start = buffer.Loc{X: 0, Y: start.Y + 1}
+		if end.X <= vimIndent(b, end.Y) {
+			end = buffer.Loc{X: 0, Y: end.Y}
+		}
+	}
+	return start, end, true
+}
+func (h *BufPane) vimReplace(c *buffer.Cursor, r rune, n int) {
+	if c.X+n > vimLineLen(h.Buf, c.Y) {
+		return
+	}
+	loc := c.Loc
+	h.Buf.Replace(loc, buffer.Loc{X: loc.X + n, Y: loc.Y}, strings.Repeat(string(r), n))
+	c.GotoLoc(buffer.Loc{X: loc.X + n - 1, Y: loc.Y})
+}
+func (h *BufPane) vimJoin(c *buffer.Cursor, n int) {
+	b := h.Buf
+	for i := 0; i < util.Max(1, n-1) && c.Y < b.LinesNum()-1; i++ {
+		line, next := b.LineBytes(c.Y), b.LineBytes(c.Y+1)
+		ws := util.GetLeadingWhitespace(next)
+		sep := " "
+		if len(line) == 0 || len(next) == len(ws) || next[len(ws)] == ')' || util.IsWhitespace(rune(line[len(line)-1])) {
+			sep = ""
+		}
+		end := buffer.Loc{X: util.CharacterCount(line), Y: c.Y}
+		b.Replace(end, buffer.Loc{X: len(ws), Y: c.Y + 1}, sep)
+		c.GotoLoc(end)
+	}
+}
+func (h *BufPane) vimPaste(after bool, n int) {
+	h.vimEach(func(c *buffer.Cursor) {
+		clip, err := clipboard.ReadMulti(clipboard.ClipboardReg, c.Num, h.Buf.NumCursors())
+		if err != nil {
+			InfoBar.Error(err)
+			return
+		}
+		text := strings.Repeat(clip, vimN(n))
+		if strings.HasSuffix(clip, "\n") {
+			y := c.Y
+			if after {
+				y++
+			}
+			if y >= h.Buf.LinesNum() {
+				h.Buf.Insert(h.Buf.End(), "\n"+strings.TrimSuffix(text, "\n"))
+			} else {
+				h.Buf.Insert(buffer.Loc{X: 0, Y: y}, text)
+			}
+			c.GotoLoc(buffer.Loc{X: 0, Y: y})
+			c.StartOfText()
+			return
+		}
+		loc := c.Loc
+		if after && loc.X < vimLineLen(h.Buf, loc.Y) {
+			loc.X++
+		}
+		h.Buf.Insert(loc, text)
+		c.GotoLoc(loc.Move(util.CharacterCountInString(text)-1, h.Buf))
+	})
+}
+func (h *BufPane) vimSearch(next bool) {
+	h.vimEach(func(c *buffer.Cursor) {
+		orig := c.Loc
+		if next {
+			c.Loc = c.Loc.Move(1, h.Buf)
+			h.FindNext()
+		} else {
+			h.FindPrevious()
+		}
+		if c.HasSelection() {
+			c.Deselect(true)
+		} else {
+			c.GotoLoc(orig)
+		}
+	})
+}
+var vimCommands map[string]func(*BufPane, int)
+var vimVisualCommands map[string]func(*BufPane, int)
+func init() {
+	insert := func(move func(h *BufPane, c *buffer.Cursor)) func(*BufPane, int) {
+		return func(h *BufPane, n int) {
+			h.vimEach(func(c *buffer.Cursor) {
+				move(h, c)
+			})
+			h.vim.setMode(VimInsert)
+		}
+	}
+	vimCommands = map[string]func(*BufPane, int){
+		"i": insert(func(h *BufPane, c *buffer.Cursor) {}),
+		"a": insert(func(h *BufPane, c *buffer.Cursor) {
+			c.X = util.Min(c.X+1, vimLineLen(h.Buf, c.Y))
+		}),
+		"I": insert(func(h *BufPane, c *buffer.Cursor) {
+			c.StartOfText()
+		}),
+		"A": insert(func(h *BufPane, c *buffer.Cursor) {
+			c.End()
+		}),
+		"o": insert(func(h *BufPane, c *buffer.Cursor) {
+			ws := ""
+			if h.Buf.Settings["autoindent"].(bool) {
+				ws = string(util.GetLeadingWhitespace(h.Buf.LineBytes(c.Y)))
+			}
+			y := c.Y
+			c.End()
+			h.Buf.Insert(c.Loc, "\n"+ws)
+			c.GotoLoc(buffer.Loc{X: util.CharacterCountInString(ws), Y: y + 1})
+		}),
+		"O": insert(func(h *BufPane, c *buffer.Cursor) {
+			ws := ""
+			if h.Buf.Settings["autoindent"].(bool) {
+				ws = string(util.GetLeadingWhitespace(h.Buf.LineBytes(c.Y)))
+			}
+			y := c.Y
+			h.Buf.Insert(buffer.Loc{X: 0, Y: y}, ws+"\n")
+			c.GotoLoc(buffer.Loc{X: util.CharacterCountInString(ws), Y: y})
+		}),
+		"v": func(h *BufPane, n int) {
+			h.VisualMode()
+		},
+		"V": func(h *BufPane, n int) {
+			h.VisualLineMode()
+		},
+		"p": func(h *BufPane, n int) {
+			h.vimPaste(true, n)
+		},
+		"P": func(h *BufPane, n int) {
+			h.vimPaste(false, n)
+		},
+		"u": func(h *BufPane, n int) {
+			for i := 0; i < vimN(n); i++ {
+				h.Undo()
+			}
+		},
+		"<Ctrl-r>": func(h *BufPane, n int) {
+			for i := 0; i < vimN(n); i++ {
+				h.Redo()
+			}
+		},
+		"J": func(h *BufPane, n int) {
+			h.vimEach(func(c *buffer.Cursor) {
+				h.vimJoin(c, n)
+			})
+		},
+		"n": func(h *BufPane, n int) {
+			for i := 0; i < vimN(n); i++ {
+				h.vimSearch(true)
+			}
+		},
+		"N": func(h *BufPane, n int) {
+			for i := 0; i < vimN(n); i++ {
+				h.vimSearch(false)
+			}
+		},
+		"/": func(h *BufPane, n int) {
+			h.Find()
+		},
+		":": func(h *BufPane, n int) {
+			h.CommandMode()
+		},
+		"zz": func(h *BufPane, n int) {
+			h.Center()
+		},
+	}
+	vimVisualCommands = map[string]func(*BufPane, int){
+		"v": func(h *BufPane, n int) {
+			if h.vim.lines {
+				h.VisualMode()
+			} else {
+				h.vimExitVisual()
+			}
+		},
+		"V": func(h *BufPane, n int) {
+			if h.vim.lines {
+				h.vimExitVisual()
+			} else {
+				h.VisualLineMode()
+			}
+		},
+		"o": func(h *BufPane, n int) {
+			for _, c := range h.Buf.GetCursors() {
+				c.OrigSelection[0], c.Loc = c.Loc, c.OrigSelection[0]
+				c.StoreVisualX()
+			}
+		},
+		"p": func(h *BufPane, n int) {
+			h.vimEach(func(c *buffer.Cursor) {
+				clip, err := clipboard.ReadMulti(clipboard.ClipboardReg, c.Num, h.Buf.NumCursors())
+				if err != nil {
+					InfoBar.Error(err)
+					return
+				}
+				h.vimSelect(c)
+				c.DeleteSelection()
+				c.ResetSelection()
+				h.Buf.Insert(c.Loc, clip)
+			})
+			h.vim.setMode(VimNormal)
+		},
+		"J": func(h *BufPane, n int) {
+			h.vimEach(func(c *buffer.Cursor) {
+				start, end := c.OrigSelection[0], c.Loc
+				if end.LessThan(start) {
+					start, end = end, start
+				}
+				c.ResetSelection()
+				c.GotoLoc(start)
+				h.vimJoin(c, end.Y-start.Y+1)
+			})
+			h.vim.setMode(VimNormal)
+		},
+		":": func(h *BufPane, n int) {
+			h.CommandMode()
+		},
+	}
+	vimVisualCommands["P"] = vimVisualCommands["p"]
+}
+func (h *BufPane) NormalMode() bool {
+	if h.vim == nil {
+		return false
+	}
+	for _, c := range h.Buf.GetCursors() {
+		c.ResetSelection()
+		if h.vim.mode == VimInsert && c.X > 0 {
+			c.X--
+			c.StoreVisualX()
+		}
+	}
+	h.vim.reset()
+	h.vim.setMode(VimNormal)
+	h.Relocate()
+	return true
+}
+func (h *BufPane) InsertMode() bool {
+	if h.vim == nil {
+		return false
+	}
+	for _, c := range h.Buf.GetCursors() {
+		c.ResetSelection()
+	}
+	h.vim.reset()
+	h.vim.setMode(VimInsert)
+	return true
+}
+func (h *BufPane) vimVisual(lines bool) bool {
+	if h.vim == nil {
+		return false
+	}
+	if h.vim.mode != VimVisual {
+		for _, c := range h.Buf.GetCursors() {
+			c.OrigSelection[0] = c.Loc
+		}
+	}
+	h.vim.lines = lines
+	h.vim.setMode(VimVisual)
+	for _, c := range h.Buf.GetCursors() {
+		h.vimSelect(c)
+	}
+	h.Relocate()
+	return true
+}
+func (h *BufPane) VisualMode() bool {
+	return h.vimVisual(false)
+}
+func (h *BufPane) VisualLineMode() bool {
+	return h.vimVisual(true)
+}
\ No newline at end of file
@@ -0,0 +1,174 @@
+package action
+import (
+	"testing"
+	"github.com/zyedidia/micro/v2/internal/buffer"
+	"github.com/zyedidia/micro/v2/internal/clipboard"
+	"github.com/zyedidia/tcell/v2"
+)
+func vimPane(text string, loc buffer.Loc) *BufPane {
+	vimKeymap = true
+	defer func() {
+		vimKeymap = false
+	}()
+	b := buffer.NewBufferFromString(text, "", buffer.BTDefault)
+	h := NewBufPaneFromBuf(b, nil)
+	h.Cursor.GotoLoc(loc)
+	return h
+}
+func vimType(h *BufPane, keys string) {
+	for _, r := range keys {
+		h.vimKeyEvent(KeyEvent{code: tcell.KeyRune, r: r})
+	}
+}
+func TestVimMotions(t *testing.T) {
+	tests := []struct {
+		text  string
+		start buffer.Loc
+		keys  string
+		want  buffer.Loc
+	}{
+		{"foo bar baz", buffer.Loc{X: 0, Y: 0}, "w", buffer.Loc{X: 4, Y: 0}},
+		{"foo bar baz", buffer.Loc{X: 0, Y: 0}, "2w", buffer.Loc{X: 8, Y: 0}},
+		{"foo.bar baz", buffer.Loc{X: 0, Y: 0}, "w", buffer.Loc{X: 3, Y: 0}},
+		{"foo.bar baz", buffer.Loc{X: 0, Y: 0}, "W", buffer.Loc{X: 8, Y: 0}},
+		{"foo\n\nbar", buffer.Loc{X: 0, Y: 0}, "w", buffer.Loc{X: 0, Y: 1}},
+		{"foo\n  bar", buffer.Loc{X: 1, Y: 0}, "w", buffer.Loc{X: 2, Y: 1}},
+		{"foo bar", buffer.Loc{X: 0, Y: 0}, "e", buffer.Loc{X: 2, Y: 0}},
+		{"foo bar", buffer.Loc{X: 2, Y: 0}, "e", buffer.Loc{X: 6, Y: 0}},
+		{"foo.bar", buffer.Loc{X: 0, Y: 0}, "e", buffer.Loc{X: 2, Y: 0}},
+		{"foo.bar", buffer.Loc{X: 2, Y: 0}, "e", buffer.Loc{X: 3, Y: 0}},
+		{"foo.bar", buffer.Loc{X: 0, Y: 0}, "E", buffer.Loc{X: 6, Y: 0}},
+		{"foo bar", buffer.Loc{X: 6, Y: 0}, "b", buffer.Loc{X: 4, Y: 0}},
+		{"foo bar", buffer.Loc{X: 4, Y: 0}, "b", buffer.Loc{X: 0, Y: 0}},
+		{"foo.bar", buffer.Loc{X: 4, Y: 0}, "b", buffer.Loc{X: 3, Y: 0}},
+		{"foo.bar", buffer.Loc{X: 6, Y: 0}, "B", buffer.Loc{X: 0, Y: 0}},
+		{"foo\nbar", buffer.Loc{X: 0, Y: 1}, "b", buffer.Loc{X: 0, Y: 0}},
+		{"f(a, b)", buffer.Loc{X: 0, Y: 0}, "f,", buffer.Loc{X: 3, Y: 0}},
+		{"f(a, b)", buffer.Loc{X: 0, Y: 0}, "t)", buffer.Loc{X: 5, Y: 0}},
+		{"f(a, b)", buffer.Loc{X: 6, Y: 0}, "F(", buffer.Loc{X: 1, Y: 0}},
+		{"f(a, b)", buffer.Loc{X: 6, Y: 0}, "T(", buffer.Loc{X: 2, Y: 0}},
+		{"a.b.c.d", buffer.Loc{X: 0, Y: 0}, "f.;", buffer.Loc{X: 3, Y: 0}},
+		{"a.b.c.d", buffer.Loc{X: 0, Y: 0}, "2f.,", buffer.Loc{X: 1, Y: 0}},
+		{"a.b", buffer.Loc{X: 0, Y: 0}, "fz", buffer.Loc{X: 0, Y: 0}},
+		{"f(a, b)", buffer.Loc{X: 0, Y: 0}, "%", buffer.Loc{X: 6, Y: 0}},
+		{"a\nb\n\nc", buffer.Loc{X: 0, Y: 0}, "}", buffer.Loc{X: 0, Y: 2}},
+		{"a\n\nb\nc", buffer.Loc{X: 0, Y: 3}, "{", buffer.Loc{X: 0, Y: 1}},
+		{"a\n  b\nc", buffer.Loc{X: 0, Y: 0}, "j$", buffer.Loc{X: 2, Y: 1}},
+		{"a\n  b\nc", buffer.Loc{X: 0, Y: 0}, "+", buffer.Loc{X: 2, Y: 1}},
+		{"a\nb\nc", buffer.Loc{X: 0, Y: 0}, "G", buffer.Loc{X: 0, Y: 2}},
+		{"a\nb\nc", buffer.Loc{X: 0, Y: 2}, "2gg", buffer.Loc{X: 0, Y: 1}},
+	}
+	for _, tt := range tests {
+		h := vimPane(tt.text, tt.start)
+		vimType(h, tt.keys)
+		if h.Cursor.Loc != tt.want {
+			t.Errorf("%q at %v, %q: cursor at %v, want %v", tt.text, tt.start, tt.keys, h.Cursor.Loc, tt.want)
+		}
+	}
+}
+func TestVimTextObjects(t *testing.T) {
+	tests := []struct {
+		text  string
+		start buffer.Loc
+		keys  string
+		want  string
+		clip  string
+	}{
+		{"foo bar baz", buffer.Loc{X: 5, Y: 0}, "diw", "foo  baz", "bar"},
+		{"foo bar baz", buffer.Loc{X: 5, Y: 0}, "daw", "foo baz", "bar "},
+		{"foo bar", buffer.Loc{X: 5, Y: 0}, "daw", "foo", " bar"},
+		{"foo   bar", buffer.Loc{X: 4, Y: 0}, "diw", "foobar", "   "},
+		{"foo.bar", buffer.Loc{X: 1, Y: 0}, "diW", "", "foo.bar"},
+		{`s := "a b"`, buffer.Loc{X: 7, Y: 0}, `di"`, `s := ""`, "a b"},
+		{`s := "a b"`, buffer.Loc{X: 0, Y: 0}, `da"`, "s := ", `"a b"`},
+		{`'a\'b' 'c'`, buffer.Loc{X: 8, Y: 0}, "di'", `'a\'b' ''`, "c"},
+		{"f(a, b)", buffer.Loc{X: 3, Y: 0}, "di(", "f()", "a, b"},
+		{"f(a, b)", buffer.Loc{X: 3, Y: 0}, "da)", "f", "(a, b)"},
+		{"f(a, b)", buffer.Loc{X: 1, Y: 0}, "dib", "f()", "a, b"},
+		{"(a (b) c)", buffer.Loc{X: 7, Y: 0}, "di(", "()", "a (b) c"},
+		{"(a (b) c)", buffer.Loc{X: 4, Y: 0}, "di(", "(a () c)", "b"},
+		{"x = [1, [2]]", buffer.Loc{X: 5, Y: 0}, "da[", "x = ", "[1, [2]]"},
+		{"<a>", buffer.Loc{X: 1, Y: 0}, "di<", "<>", "a"},
+		{"f {\n\tx\n\ty\n}", buffer.Loc{X: 1, Y: 1}, "diB", "f {\n}", "\tx\n\ty\n"},
+		{"f {\n\tx\n}", buffer.Loc{X: 1, Y: 1}, "daB", "f ", "{\n\tx\n}"},
+		{"f(a)", buffer.Loc{X: 0, Y: 0}, "di(", "f(a)", ""},
+	}
+	for _, tt := range tests {
+		clipboard.Write("", clipboard.ClipboardReg)
+		h := vimPane(tt.text, tt.start)
+		vimType(h, tt.keys)
+		if got := string(h.Buf.Bytes()); got != tt.want {
+			t.Errorf("%q at %v, %q: text is %q, want %q", tt.text, tt.start, tt.keys, got, tt.want)
+		}
+		if clip, _ := clipboard.Read(clipboard.ClipboardReg); clip != tt.clip {
+			t.Errorf("%q at %v, %q: clipboard is %q, want %q", tt.text, tt.start, tt.keys, clip, tt.clip)
+		}
+	}
+}
+func TestVimOperators(t *testing.T) {
+	tests := []struct {
+		text  string
+		start buffer.Loc
+		keys  string
+		want  string
+		clip  string
+		loc   buffer.Loc
+		mode  string
+	}{
+		{"one\ntwo\nthree", buffer.Loc{X: 1, Y: 0}, "dd", "two\nthree", "one\n", buffer.Loc{X: 0, Y: 0}, VimNormal},
+		{"one\ntwo\nthree", buffer.Loc{X: 0, Y: 0}, "2dd", "three", "one\ntwo\n", buffer.Loc{X: 0, Y: 0}, VimNormal},
+		{"one\ntwo\nthree", buffer.Loc{X: 0, Y: 2}, "dd", "one\ntwo", "three\n", buffer.Loc{X: 0, Y: 1}, VimNormal},
+		{"one\ntwo\nthree", buffer.Loc{X: 0, Y: 0}, "dj", "three", "one\ntwo\n", buffer.Loc{X: 0, Y: 0}, VimNormal},
+		{"foo bar", buffer.Loc{X: 0, Y: 0}, "dw", "bar", "foo ", buffer.Loc{X: 0, Y: 0}, VimNormal},
+		{"foo bar\nbaz", buffer.Loc{X: 4, Y: 0}, "dw", "foo \nbaz", "bar", buffer.Loc{X: 3, Y: 0}, VimNormal},
+		{"foo bar baz", buffer.Loc{X: 0, Y: 0}, "d2w", "baz", "foo bar ", buffer.Loc{X: 0, Y: 0}, VimNormal},
+		{"foo bar", buffer.Loc{X: 0, Y: 0}, "de", " bar", "foo", buffer.Loc{X: 0, Y: 0}, VimNormal},
+		{"foo bar", buffer.Loc{X: 4, Y: 0}, "db", "bar", "foo ", buffer.Loc{X: 0, Y: 0}, VimNormal},
+		{"foo bar", buffer.Loc{X: 2, Y: 0}, "d$", "fo", "o bar", buffer.Loc{X: 1, Y: 0}, VimNormal},
+		{"foo bar", buffer.Loc{X: 0, Y: 0}, "dt ", " bar", "foo", buffer.Loc{X: 0, Y: 0}, VimNormal},
+		{"foo bar", buffer.Loc{X: 0, Y: 0}, "df ", "bar", "foo ", buffer.Loc{X: 0, Y: 0}, VimNormal},
+		{"foo bar", buffer.Loc{X: 1, Y: 0}, "x", "fo bar", "o", buffer.Loc{X: 1, Y: 0}, VimNormal},
+		{"foo bar", buffer.Loc{X: 0, Y: 0}, "cw", " bar", "foo", buffer.Loc{X: 0, Y: 0}, VimInsert},
+		{"foo bar", buffer.Loc{X: 3, Y: 0}, "cw", "foobar", " ", buffer.Loc{X: 3, Y: 0}, VimInsert},
+		{"  one\ntwo", buffer.Loc{X: 3, Y: 0}, "cc", "  \ntwo", "  one\n", buffer.Loc{X: 2, Y: 0}, VimInsert},
+		{"foo bar", buffer.Loc{X: 4, Y: 0}, "C", "foo ", "bar", buffer.Loc{X: 4, Y: 0}, VimInsert},
+		{"foo bar", buffer.Loc{X: 4, Y: 0}, "yw", "foo bar", "bar", buffer.Loc{X: 4, Y: 0}, VimNormal},
+		{"foo bar", buffer.Loc{X: 4, Y: 0}, "yb", "foo bar", "foo ", buffer.Loc{X: 0, Y: 0}, VimNormal},
+		{"one\ntwo", buffer.Loc{X: 2, Y: 0}, "yy", "one\ntwo", "one\n", buffer.Loc{X: 2, Y: 0}, VimNormal},
+		{"one\ntwo", buffer.Loc{X: 0, Y: 1}, "yk", "one\ntwo", "one\ntwo\n", buffer.Loc{X: 0, Y: 0}, VimNormal},
+		{"one two", buffer.Loc{X: 0, Y: 0}, "veld", "two", "one ", buffer.Loc{X: 0, Y: 0}, VimNormal},
+		{"one\ntwo\nthree", buffer.Loc{X: 1, Y: 1}, "Vy", "one\ntwo\nthree", "two\n", buffer.Loc{X: 1, Y: 1}, VimNormal},
+	}
+	for _, tt := range tests {
+		clipboard.Write("", clipboard.ClipboardReg)
+		h := vimPane(tt.text, tt.start)
+		vimType(h, tt.keys)
+		if got := string(h.Buf.Bytes()); got != tt.want {
+			t.Errorf("%q at %v, %q: text is %q, want %q", tt.text, tt.start, tt.keys, got, tt.want)
+		}
+		if clip, _ := clipboard.Read(clipboard.ClipboardReg); clip != tt.clip {
+			t.Errorf("%q at %v, %q: clipboard is %q, want %q", tt.text, tt.start, tt.keys, clip, tt.clip)
+		}
+		if h.Cursor.Loc != tt.loc || h.vim.mode != tt.mode {
+			t.Errorf("%q at %v, %q: cursor at %v in %s mode, want %v in %s mode", tt.text, tt.start, tt.keys, h.Cursor.Loc, h.vim.mode, tt.loc, tt.mode)
+		}
+	}
+}
+func TestVimKeymapReload(t *testing.T) {
+	defer func() {
+		Tabs = nil
+	}()
+	defer setKeymap("default")
+	setKeymap("vim")
+	Tabs = NewTabList([]*buffer.Buffer{buffer.NewBufferFromString("foo", "", buffer.BTDefault)})
+	h := MainTab().CurPane()
+	vimType(h, "i")
+	setKeymap("vim")
+	if h.vim == nil || h.vim.mode != VimInsert {
+		t.Errorf("setting the same keymap again reset the pane to %+v", h.vim)
+	}
+	setKeymap("default")
+	if h.vim != nil || BufBindings.modes[VimNormal] || BufBindings.modes[VimInsert] {
+		t.Error("switching to the default keymap left vim modes enabled")
+	}
+}
\ No newline at end of file
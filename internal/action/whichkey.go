@@ -0,0 +1,141 @@
+package action
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"github.com/zyedidia/micro/v2/internal/buffer"
+	"github.com/zyedidia/micro/v2/internal/config"
+	"github.com/zyedidia/micro/v2/internal/display"
+	"github.com/zyedidia/micro/v2/internal/shell"
+	"github.com/zyedidia/micro/v2/internal/util"
+	"github.com/zyedidia/tcell/v2"
+)
+const whichKeyMaxRows = 10
+var (
+	whichKeyGen   int
+	whichKeyFloat *BufPane
+)
+func (k *KeyTree) countBindings(n *KeyTreeNode) int {
+	count := 0
+	if k.activeAction(n) != nil {
+		count++
+	}
+	for _, c := range n.children {
+		count += k.countBindings(c)
+	}
+	return count
+}
+func (h *BufPane) whichKeyItems(k *KeyTree) []string {
+	var items []string
+	for e, c := range k.cursor.node.children {
+		if !k.reachable(c) {
+			continue
+		}
+		desc := ""
+		if k.hasMore(c) {
+			desc = fmt.Sprintf("+%d bindings", k.countBindings(c))
+		} else {
+			seq := KeySequenceEvent{append(append([]Event{}, k.PendingKeys()...), e)}
+			desc = h.bindingDesc(seq.Name())
+			if desc == "" {
+				continue
+			}
+		}
+		items = append(items, e.Name()+" → "+desc)
+	}
+	sort.Strings(items)
+	return items
+}
+func whichKeyDone() {
+	whichKeyGen++
+	display.PendingKeys = ""
+	if whichKeyFloat != nil {
+		whichKeyFloat.tab.CloseFloat(whichKeyFloat)
+		whichKeyFloat = nil
+	}
+}
+func (h *BufPane) whichKeyPending(k *KeyTree) {
+	whichKeyDone()
+	var keys []string
+	for _, e := range k.cursor.recordedEvents {
+		keys = append(keys, e.Name())
+	}
+	display.PendingKeys = strings.Join(keys, " ")
+	gen := whichKeyGen
+	after := func(ms float64, f func()) {
+		time.AfterFunc(time.Duration(ms)*time.Millisecond, func() {
+			shell.Jobs <- shell.JobFunction{Function: func(string, []interface{}) {
+				if gen == whichKeyGen {
+					f()
+				}
+			}}
+		})
+	}
+	timeout := config.GetGlobalOption("keytimeout").(float64)
+	if t, ok := keyTimeouts[KeySequenceEvent{k.PendingKeys()}.Name()]; ok {
+		timeout = t
+	}
+	if timeout > 0 {
+		after(timeout, func() {
+			h.keyTimeout(k)
+		})
+	}
+	if config.GetGlobalOption("whichkey").(bool) {
+		after(config.GetGlobalOption("whichkeydelay").(float64), func() {
+			h.showWhichKey(k)
+		})
+	}
+}
+func (h *BufPane) keyTimeout(k *KeyTree) {
+	action := k.PendingAction()
+	if action == nil && whichKeyFloat != nil {
+		return
+	}
+	whichKeyDone()
+	k.ResetEvents()
+	if MainTab().CurPane() != h || InfoBar.HasPrompt {
+		return
+	}
+	if action != nil {
+		action(h)
+	}
+}
+func (h *BufPane) insertKeys(events []Event) {
+	if h.vim != nil && h.vim.mode != VimInsert {
+		return
+	}
+	for _, e := range events {
+		if ke, ok := e.(KeyEvent); ok && ke.code == tcell.KeyRune && ke.mod&^tcell.ModShift == 0 {
+			h.DoRuneInsert(ke.r)
+		}
+	}
+}
+func (h *BufPane) showWhichKey(k *KeyTree) {
+	items := h.whichKeyItems(k)
+	if len(items) == 0 {
+		return
+	}
+	t := h.tab
+	colWidth := 0
+	for _, item := range items {
+		colWidth = util.Max(colWidth, util.CharacterCountInString(item)+2)
+	}
+	cols := util.Max(1, (t.W-2)/colWidth)
+	rows := util.Min((len(items)+cols-1)/cols, whichKeyMaxRows)
+	lines := make([]string, rows)
+	for i, item := range items {
+		lines[i%rows] += fmt.Sprintf("%-*s", colWidth, item)
+	}
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " ")
+	}
+	b := buffer.NewBufferFromString(strings.Join(lines, "\n"), "", buffer.BTScratch)
+	b.SetOptionNative("softwrap", false)
+	w := display.NewFloatWindow(b, t.W-2, rows)
+	w.MoveTo(0, t.H-rows-3)
+	w.Title = display.PendingKeys
+	w.Focusable = false
+	w.Z = 100
+	whichKeyFloat = t.AddFloat(w, h.splitID, false)
+}
\ No newline at end of file
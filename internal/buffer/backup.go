@@ -53,7 +53,7 @@ func (b *Buffer) Backup() error {
 		os.Mkdir(backupdir, os.ModePerm)
 	}
 	name := filepath.Join(backupdir, util.EscapePath(b.AbsPath))
-	err = overwriteFile(name, encoding.Nop, func(file io.Writer) (e error) {
+	err = overwriteFile(name, encoding.Nop, nil, nil, func(file io.Writer) (e error) {
 		if len(b.lines) == 0 {
 			return
 		}
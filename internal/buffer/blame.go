@@ -0,0 +1,48 @@
+package buffer
+import "github.com/zyedidia/micro/v2/internal/util"
+func (b *Buffer) SetBlame(base []byte, lines []string, none string) {
+	b.blameBase = base
+	b.blameLines = lines
+	b.blameNone = none
+	b.UpdateBlame()
+}
+func (b *Buffer) HasBlame() bool {
+	return b.blameLines != nil
+}
+func (b *Buffer) BlameWidth() int {
+	width := util.CharacterCountInString(b.blameNone)
+	for _, l := range b.blameLines {
+		width = util.Max(width, util.CharacterCountInString(l))
+	}
+	return width
+}
+func (b *Buffer) Blame(lineN int) string {
+	if lineN < 0 || lineN >= len(b.blame) {
+		return ""
+	}
+	return b.blame[lineN]
+}
+func (b *Buffer) UpdateBlame() {
+	if b.blameLines == nil {
+		b.blame = nil
+		return
+	}
+	b.blame = make([]string, b.LinesNum())
+	baseN, lineN := 0, 0
+	annotate := func(end int) {
+		for ; lineN < end && lineN < len(b.blame); lineN++ {
+			if baseN < len(b.blameLines) {
+				b.blame[lineN] = b.blameLines[baseN]
+			}
+			baseN++
+		}
+	}
+	for _, h := range LineDiff(b.blameBase, b.Bytes()) {
+		annotate(h.Start)
+		for ; lineN < h.End; lineN++ {
+			b.blame[lineN] = b.blameNone
+		}
+		baseN = h.BaseEnd
+	}
+	annotate(len(b.blame))
+}
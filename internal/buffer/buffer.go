@@ -17,10 +17,13 @@ import (
 	"time"
 	luar "layeh.com/gopher-luar"
 	dmp "github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/zyedidia/micro/v2/internal/compression"
 	"github.com/zyedidia/micro/v2/internal/config"
+	"github.com/zyedidia/micro/v2/internal/encryption"
 	ulua "github.com/zyedidia/micro/v2/internal/lua"
 	"github.com/zyedidia/micro/v2/internal/screen"
 	"github.com/zyedidia/micro/v2/internal/util"
+	"github.com/zyedidia/micro/v2/internal/watcher"
 	"github.com/zyedidia/micro/v2/pkg/highlight"
 	"golang.org/x/text/encoding/htmlindex"
 	"golang.org/x/text/encoding/unicode"
@@ -30,6 +33,9 @@ const backupTime = 8000
 var (
 	OpenBuffers []*Buffer
 	LogBuf *Buffer
+	openCallbacks []func(*Buffer)
+	closeCallbacks []func(*Buffer)
+	lastBufNum int
 )
 type BufType struct {
 	Kind     int
@@ -45,6 +51,9 @@ var (
 	BTRaw = BufType{4, false, true, false}
 	BTInfo = BufType{5, false, true, false}
 	BTStdout = BufType{6, false, true, true}
+	BTQuickfix = BufType{7, true, true, false}
+	BTList = BufType{8, true, true, false}
+	BTRepl = BufType{9, false, true, false}
 	ErrFileTooLarge = errors.New("File is too large to hash")
 )
 type SharedBuffer struct {
@@ -53,6 +62,13 @@ type SharedBuffer struct {
 	Type BufType
 	Path string
 	AbsPath string
+	Num int
+	Compression *compression.Codec
+	Encryption *encryption.Key
+	watchPath string
+	watchRefs int
+	locked bool
+	GitDir string
 	name string
 	toStdout bool
 	Settings map[string]interface{}
@@ -60,17 +76,34 @@ type SharedBuffer struct {
 	Completions   []string
 	CurSuggestion int
 	Messages []*Message
+	decorations     []*Decoration
+	decorationLines map[int][]*Decoration
+	hasSigns        bool
 	updateDiffTimer   *time.Timer
 	diffBase          []byte
 	diffBaseLineCount int
 	diffLock          sync.RWMutex
 	diff              map[int]DiffStatus
+	hunks             []DiffHunk
+	blameBase  []byte
+	blameLines []string
+	blameNone  string
+	blame      []string
+	conflicts        []Conflict
+	conflictMarkers  []int
+	conflictsScanned bool
 	requestedBackup bool
 	ReloadDisabled bool
+	ReadonlyBefore Loc
 	isModified bool
 	HasSuggestions bool
 	Highlighter *highlight.Highlighter
 	SyntaxDef *highlight.Def
+	hlLock    sync.Mutex
+	hlGen     int
+	hlJob     highlightJob
+	hlVisible [2]int
+	hlRunning bool
 	ModifiedThisFrame bool
 	origHash [md5.Size]byte
 }
@@ -78,13 +111,17 @@ func (b *SharedBuffer) insert(pos Loc, value []byte) {
 	b.isModified = true
 	b.HasSuggestions = false
 	b.LineArray.insert(pos, value)
+	b.decorationsInserted(pos, value)
 	inslines := bytes.Count(value, []byte{'\n'})
+	b.conflictLinesChanged(pos.Y, pos.Y, pos.Y+inslines)
 	b.MarkModified(pos.Y, pos.Y+inslines)
 }
 func (b *SharedBuffer) remove(start, end Loc) []byte {
 	b.isModified = true
 	b.HasSuggestions = false
 	defer b.MarkModified(start.Y, end.Y)
+	defer b.conflictLinesChanged(start.Y, end.Y, start.Y)
+	b.decorationsRemoved(start, end)
 	return b.LineArray.remove(start, end)
 }
 func (b *SharedBuffer) MarkModified(start, end int) {
@@ -92,11 +129,13 @@ func (b *SharedBuffer) MarkModified(start, end int) {
 	start = util.Clamp(start, 0, len(b.lines)-1)
 	end = util.Clamp(end, 0, len(b.lines)-1)
 	if b.Settings["syntax"].(bool) && b.SyntaxDef != nil {
-		l := -1
+		changed := false
 		for i := start; i <= end; i++ {
-			l = util.Max(b.Highlighter.ReHighlightStates(b, i), l)
+			changed = b.Highlighter.HighlightLine(b, i, true)
+		}
+		if changed && end+1 < len(b.lines) {
+			b.queueHighlight(b.SyntaxDef, end+1, false)
 		}
-		b.Highlighter.HighlightMatches(b, start, l)
 	}
 	for i := start; i <= end; i++ {
 		b.LineArray.invalidateSearchMatches(i)
@@ -112,15 +151,22 @@ const (
 	DSDeletedAbove = 3
 )
 type DiffStatus byte
+type DiffHunk struct {
+	BaseStart, BaseEnd int
+	Start, End         int
+}
 type Buffer struct {
 	*EventHandler
 	*SharedBuffer
 	fini        int32
+	gitGen      int
+	gitWatch    string
 	cursors     []*Cursor
 	curCursor   int
 	StartCursor Loc
 	OptionCallback func(option string, nativeValue interface{})
 	GetVisualX func(loc Loc) int
+	GetCharPos func(y, visualX int) int
 	LastSearch      string
 	LastSearchRegex bool
 	HighlightSearch bool
@@ -160,10 +206,31 @@ func NewBufferFromFileAtLoc(path string, btype BufType, cursorLoc Loc) (*Buffer,
 	} else if err != nil {
 		return nil, err
 	} else {
-		buf = NewBuffer(file, util.FSize(file), filename, cursorLoc, btype)
+		br := bufio.NewReader(file)
+		header, _ := br.Peek(encryption.HeaderSize)
+		r, codec, err := compression.Open(br)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		if encryption.Detect(header) {
+			buf = newBuffer(strings.NewReader(""), 0, filename, cursorLoc, btype, true)
+		} else if codec == nil {
+			buf = NewBuffer(r, util.FSize(file), filename, cursorLoc, btype)
+		} else {
+			data, err := ioutil.ReadAll(r)
+			if err != nil {
+				return nil, errors.New("could not decompress " + codec.String() + " file: " + err.Error())
+			}
+			buf = NewBuffer(bytes.NewReader(data), int64(len(data)), filename, cursorLoc, btype)
+		}
 		if buf == nil {
 			return nil, errors.New("could not open file")
 		}
+		if codec != nil && buf.Compression == nil {
+			buf.Compression = codec
+			buf.UpdateRules()
+		}
 	}
 	if readonly && prompt != nil {
 		prompt.Message(fmt.Sprintf("Warning: file is readonly - %s will be attempted when saving", config.GlobalSettings["sucmd"].(string)))
@@ -180,6 +247,9 @@ func NewBufferFromString(text, path string, btype BufType) *Buffer {
 	return NewBuffer(strings.NewReader(text), int64(len(text)), path, Loc{-1, -1}, btype)
 }
 func NewBuffer(r io.Reader, size int64, path string, startcursor Loc, btype BufType) *Buffer {
+	return newBuffer(r, size, path, startcursor, btype, false)
+}
+func newBuffer(r io.Reader, size int64, path string, startcursor Loc, btype BufType, locked bool) *Buffer {
 	absPath, err := filepath.Abs(path)
 	if err != nil {
 		absPath = path
@@ -201,6 +271,10 @@ func NewBuffer(r io.Reader, size int64, path string, startcursor Loc, btype BufT
 		b.Type = btype
 		b.AbsPath = absPath
 		b.Path = path
+		if btype.Kind == BTDefault.Kind {
+			lastBufNum++
+			b.Num = lastBufNum
+		}
 		settings := config.DefaultCommonSettings()
 		b.Settings = config.DefaultCommonSettings()
 		for k, v := range config.GlobalSettings {
@@ -213,6 +287,10 @@ func NewBuffer(r io.Reader, size int64, path string, startcursor Loc, btype BufT
 		b.Settings["readonly"] = settings["readonly"]
 		b.Settings["filetype"] = settings["filetype"]
 		b.Settings["syntax"] = settings["syntax"]
+		if locked {
+			b.locked = true
+			b.disablePlaintextOptions()
+		}
 		enc, err := htmlindex.Get(settings["encoding"].(string))
 		if err != nil {
 			enc = unicode.UTF8
@@ -239,7 +317,7 @@ func NewBuffer(r io.Reader, size int64, path string, startcursor Loc, btype BufT
 		b.EventHandler = NewEventHandler(b.SharedBuffer, b.cursors)
 		b.UpdateModTime()
 	}
-	if b.Settings["readonly"].(bool) && b.Type == BTDefault {
+	if b.Settings["readonly"].(bool) && b.Type == BTDefault || b.locked {
 		b.Type.Readonly = true
 	}
 	switch b.Endings {
@@ -249,7 +327,8 @@ func NewBuffer(r io.Reader, size int64, path string, startcursor Loc, btype BufT
 		b.Settings["fileformat"] = "dos"
 	}
 	b.UpdateRules()
-	config.InitLocalSettings(b.Settings, b.Path)
+	b.UpdateConflicts()
+	b.initLocalSettings()
 	if _, err := os.Stat(filepath.Join(config.ConfigDir, "buffers")); os.IsNotExist(err) {
 		os.Mkdir(filepath.Join(config.ConfigDir, "buffers"), os.ModePerm)
 	}
@@ -275,8 +354,18 @@ func NewBuffer(r io.Reader, size int64, path string, startcursor Loc, btype BufT
 		screen.TermMessage(err)
 	}
 	OpenBuffers = append(OpenBuffers, b)
+	b.watch()
+	for _, cb := range openCallbacks {
+		cb(b)
+	}
 	return b
 }
+func AddOpenCallback(cb func(*Buffer)) {
+	openCallbacks = append(openCallbacks, cb)
+}
+func AddCloseCallback(cb func(*Buffer)) {
+	closeCallbacks = append(closeCallbacks, cb)
+}
 func CloseOpenBuffers() {
 	for i, buf := range OpenBuffers {
 		buf.Fini()
@@ -291,6 +380,9 @@ func (b *Buffer) Close() {
 			copy(OpenBuffers[i:], OpenBuffers[i+1:])
 			OpenBuffers[len(OpenBuffers)-1] = nil
 			OpenBuffers = OpenBuffers[:len(OpenBuffers)-1]
+			for _, cb := range closeCallbacks {
+				cb(b)
+			}
 			return
 		}
 	}
@@ -303,7 +395,34 @@ func (b *Buffer) Fini() {
 	if b.Type == BTStdout {
 		fmt.Fprint(util.Stdout, string(b.Bytes()))
 	}
-	atomic.StoreInt32(&(b.fini), int32(1))
+	if atomic.SwapInt32(&(b.fini), int32(1)) == 0 {
+		b.unwatch()
+	}
+}
+func (b *Buffer) watch() {
+	b.watchRefs++
+	b.rewatch()
+}
+func (b *Buffer) unwatch() {
+	b.watchRefs--
+	b.rewatch()
+	watcher.Unwatch(b.gitWatch)
+	b.gitWatch = ""
+}
+func (b *SharedBuffer) rewatch() {
+	path := ""
+	if b.watchRefs > 0 && b.Path != "" && b.Type.Kind == BTDefault.Kind {
+		path = b.AbsPath
+	}
+	if path == b.watchPath {
+		return
+	}
+	watcher.Unwatch(b.watchPath)
+	watcher.Watch(path)
+	b.watchPath = path
+}
+func (b *Buffer) Watched() bool {
+	return b.Path != "" && watcher.Watching(b.AbsPath)
 }
 func (b *Buffer) GetName() string {
 	name := b.name
@@ -352,20 +471,36 @@ func (b *Buffer) UpdateModTime() (err error) {
 	return
 }
 func (b *Buffer) ReOpen() error {
+	if b.locked {
+		return b.UpdateModTime()
+	}
 	file, err := os.Open(b.Path)
 	if err != nil {
 		return err
 	}
+	defer file.Close()
 	enc, err := htmlindex.Get(b.Settings["encoding"].(string))
 	if err != nil {
 		return err
 	}
-	reader := bufio.NewReader(transform.NewReader(file, enc.NewDecoder()))
+	var src io.Reader = file
+	if b.Encryption != nil {
+		if src, err = b.Encryption.Decrypt(file); err != nil {
+			return err
+		}
+	}
+	r, codec, err := compression.Open(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	reader := bufio.NewReader(transform.NewReader(r, enc.NewDecoder()))
 	data, err := ioutil.ReadAll(reader)
 	txt := string(data)
 	if err != nil {
 		return err
 	}
+	b.Compression = codec
 	b.EventHandler.ApplyDiff(txt)
 	err = b.UpdateModTime()
 	if !b.Settings["fastdirty"].(bool) {
@@ -514,6 +649,16 @@ func findRuntimeSyntaxDef(name string, header *highlight.Header) *highlight.Def
 	}
 	return nil
 }
+func (b *Buffer) syntaxPath() string {
+	path := b.Path
+	if b.Encrypted() {
+		path = strings.TrimSuffix(path, encryption.Ext)
+	}
+	if b.Compression != nil {
+		path = strings.TrimSuffix(path, b.Compression.Ext())
+	}
+	return path
+}
 func (b *Buffer) UpdateRules() {
 	if !b.Type.Syntax {
 		return
@@ -524,6 +669,37 @@ func (b *Buffer) UpdateRules() {
 		b.SyntaxDef = nil
 		return
 	}
+	limit := len(b.lines)
+	if detectlimit := util.IntOpt(b.Settings["detectlimit"]); detectlimit > 0 && limit > detectlimit {
+		limit = detectlimit
+	}
+	lines := make([][]byte, limit)
+	for i := range lines {
+		lines[i] = b.lines[i].data
+	}
+	syntaxDef, found := DetectSyntax(ft, b.syntaxPath(), lines)
+	if found {
+		b.SyntaxDef = syntaxDef
+	}
+	if b.Highlighter == nil || found {
+		if b.SyntaxDef != nil {
+			b.Settings["filetype"] = b.SyntaxDef.FileType
+		} else {
+			b.SyntaxDef = findRealRuntimeSyntaxDef("default", nil)
+			if b.SyntaxDef == nil {
+				b.SyntaxDef = findRuntimeSyntaxDef("default", nil)
+			}
+		}
+	}
+	if b.SyntaxDef != nil {
+		b.Highlighter = highlight.NewHighlighter(b.SyntaxDef)
+		if b.Settings["syntax"].(bool) {
+			b.queueHighlight(b.SyntaxDef, 0, true)
+		}
+	}
+}
+func DetectSyntax(ft, path string, lines [][]byte) (*highlight.Def, bool) {
+	var syntaxDef *highlight.Def
 	type syntaxFileInfo struct {
 		header    *highlight.Header
 		fileName  string
@@ -552,10 +728,10 @@ func (b *Buffer) UpdateRules() {
 		matchedFileName := false
 		matchedFileHeader := false
 		if ft == "unknown" || ft == "" {
-			if header.MatchFileName(b.Path) {
+			if header.MatchFileName(path) {
 				matchedFileName = true
 			}
-			if len(fnameMatches) == 0 && header.MatchFileHeader(b.lines[0].data) {
+			if len(fnameMatches) == 0 && header.MatchFileHeader(lines[0]) {
 				matchedFileHeader = true
 			}
 		} else if header.FileType == ft {
@@ -573,7 +749,7 @@ func (b *Buffer) UpdateRules() {
 				continue
 			}
 			if matchedFileType {
-				b.SyntaxDef = syndef
+				syntaxDef = syndef
 				syntaxFile = f.Name()
 				foundDef = true
 				break
@@ -598,10 +774,10 @@ func (b *Buffer) UpdateRules() {
 				continue
 			}
 			if ft == "unknown" || ft == "" {
-				if header.MatchFileName(b.Path) {
+				if header.MatchFileName(path) {
 					fnameMatches = append(fnameMatches, syntaxFileInfo{header, f.Name(), nil})
 				}
-				if len(fnameMatches) == 0 && header.MatchFileHeader(b.lines[0].data) {
+				if len(fnameMatches) == 0 && header.MatchFileHeader(lines[0]) {
 					headerMatches = append(headerMatches, syntaxFileInfo{header, f.Name(), nil})
 				}
 			} else if header.FileType == ft {
@@ -619,20 +795,14 @@ func (b *Buffer) UpdateRules() {
 		if length > 0 {
 			signatureMatch := false
 			if length > 1 {
-				detectlimit := util.IntOpt(b.Settings["detectlimit"])
-				lineCount := len(b.lines)
-				limit := lineCount
-				if detectlimit > 0 && lineCount > detectlimit {
-					limit = detectlimit
-				}
 			matchLoop:
 				for _, m := range matches {
 					if m.header.HasFileSignature() {
-						for i := 0; i < limit; i++ {
-							if m.header.MatchFileSignature(b.lines[i].data) {
+						for _, l := range lines {
+							if m.header.MatchFileSignature(l) {
 								syntaxFile = m.fileName
 								if m.syntaxDef != nil {
-									b.SyntaxDef = m.syntaxDef
+									syntaxDef = m.syntaxDef
 									foundDef = true
 								}
 								header = m.header
@@ -646,7 +816,7 @@ func (b *Buffer) UpdateRules() {
 			if length == 1 || !signatureMatch {
 				syntaxFile = matches[0].fileName
 				if matches[0].syntaxDef != nil {
-					b.SyntaxDef = matches[0].syntaxDef
+					syntaxDef = matches[0].syntaxDef
 					foundDef = true
 				}
 				header = matches[0].header
@@ -654,10 +824,10 @@ func (b *Buffer) UpdateRules() {
 		}
 	}
 	if syntaxFile != "" && !foundDef {
-		b.SyntaxDef = findRuntimeSyntaxDef(syntaxFile, header)
+		syntaxDef = findRuntimeSyntaxDef(syntaxFile, header)
 	}
-	if b.SyntaxDef != nil && highlight.HasIncludes(b.SyntaxDef) {
-		includes := highlight.GetIncludes(b.SyntaxDef)
+	if syntaxDef != nil && highlight.HasIncludes(syntaxDef) {
+		includes := highlight.GetIncludes(syntaxDef)
 		var files []*highlight.File
 		for _, f := range config.ListRuntimeFiles(config.RTSyntax) {
 			data, err := f.Data()
@@ -685,30 +855,12 @@ func (b *Buffer) UpdateRules() {
 				break
 			}
 		}
-		highlight.ResolveIncludes(b.SyntaxDef, files)
-	}
-	if b.Highlighter == nil || syntaxFile != "" {
-		if b.SyntaxDef != nil {
-			b.Settings["filetype"] = b.SyntaxDef.FileType
-		} else {
-			b.SyntaxDef = findRealRuntimeSyntaxDef("default", nil)
-			if b.SyntaxDef == nil {
-				b.SyntaxDef = findRuntimeSyntaxDef("default", nil)
-			}
-		}
-	}
-	if b.SyntaxDef != nil {
-		b.Highlighter = highlight.NewHighlighter(b.SyntaxDef)
-		if b.Settings["syntax"].(bool) {
-			go func() {
-				b.Highlighter.HighlightStates(b)
-				b.Highlighter.HighlightMatches(b, 0, b.End().Y)
-				screen.Redraw()
-			}()
-		}
+		highlight.ResolveIncludes(syntaxDef, files)
 	}
+	return syntaxDef, syntaxFile != ""
 }
 func (b *Buffer) ClearMatches() {
+	b.stopHighlight()
 	for i := range b.lines {
 		b.SetMatch(i, nil)
 		b.SetState(i, nil)
@@ -920,6 +1072,7 @@ func (b *Buffer) Retab() {
 		b.Lock()
 		b.lines[i].data = append(ws, l...)
 		b.Unlock()
+		b.conflictLinesChanged(i, i, i)
 		b.MarkModified(i, i)
 		dirty = true
 	}
@@ -950,35 +1103,54 @@ func (b *Buffer) Write(bytes []byte) (n int, err error) {
 	b.EventHandler.InsertBytes(b.End(), bytes)
 	return len(bytes), nil
 }
+func LineDiff(base, text []byte) []DiffHunk {
+	differ := dmp.New()
+	baseRunes, textRunes, _ := differ.DiffLinesToRunes(string(base), string(text))
+	var hunks []DiffHunk
+	var h *DiffHunk
+	baseN, lineN := 0, 0
+	for _, diff := range differ.DiffMainRunes(baseRunes, textRunes, false) {
+		lineCount := len([]rune(diff.Text))
+		if diff.Type == dmp.DiffEqual {
+			h = nil
+			baseN += lineCount
+			lineN += lineCount
+			continue
+		}
+		if h == nil {
+			hunks = append(hunks, DiffHunk{baseN, baseN, lineN, lineN})
+			h = &hunks[len(hunks)-1]
+		}
+		if diff.Type == dmp.DiffDelete {
+			baseN += lineCount
+			h.BaseEnd = baseN
+		} else {
+			lineN += lineCount
+			h.End = lineN
+		}
+	}
+	return hunks
+}
 func (b *Buffer) updateDiffSync() {
 	b.diffLock.Lock()
 	defer b.diffLock.Unlock()
 	b.diff = make(map[int]DiffStatus)
+	b.hunks = nil
 	if b.diffBase == nil {
 		return
 	}
-	differ := dmp.New()
-	baseRunes, bufferRunes, _ := differ.DiffLinesToRunes(string(b.diffBase), string(b.Bytes()))
-	diffs := differ.DiffMainRunes(baseRunes, bufferRunes, false)
-	lineN := 0
-	for _, diff := range diffs {
-		lineCount := len([]rune(diff.Text))
-		switch diff.Type {
-		case dmp.DiffEqual:
-			lineN += lineCount
-		case dmp.DiffInsert:
-			var status DiffStatus
-			if b.diff[lineN] == DSDeletedAbove {
-				status = DSModified
-			} else {
-				status = DSAdded
-			}
-			for i := 0; i < lineCount; i++ {
-				b.diff[lineN] = status
-				lineN++
-			}
-		case dmp.DiffDelete:
-			b.diff[lineN] = DSDeletedAbove
+	b.hunks = LineDiff(b.diffBase, b.Bytes())
+	for _, h := range b.hunks {
+		if h.Start == h.End {
+			b.diff[h.Start] = DSDeletedAbove
+			continue
+		}
+		var status DiffStatus = DSAdded
+		if h.BaseStart < h.BaseEnd {
+			status = DSModified
+		}
+		for lineN := h.Start; lineN < h.End; lineN++ {
+			b.diff[lineN] = status
 		}
 	}
 }
@@ -1002,6 +1174,7 @@ func (b *Buffer) UpdateDiff(callback func(bool)) {
 	} else {
 		b.diffLock.Lock()
 		b.diff = make(map[int]DiffStatus)
+		b.hunks = nil
 		b.diffLock.Unlock()
 		callback(true)
 	}
@@ -1017,11 +1190,27 @@ func (b *Buffer) SetDiffBase(diffBase []byte) {
 		screen.Redraw()
 	})
 }
+func (b *Buffer) DiffBase() []byte {
+	return b.diffBase
+}
 func (b *Buffer) DiffStatus(lineN int) DiffStatus {
 	b.diffLock.RLock()
 	defer b.diffLock.RUnlock()
 	return b.diff[lineN]
 }
+func FindDiffHunk(hunks []DiffHunk, lineN int) (DiffHunk, bool) {
+	for _, h := range hunks {
+		if lineN >= h.Start && lineN < h.End || h.Start == h.End && lineN == h.Start {
+			return h, true
+		}
+	}
+	return DiffHunk{}, false
+}
+func (b *Buffer) DiffHunkAt(lineN int) (DiffHunk, bool) {
+	b.diffLock.RLock()
+	defer b.diffLock.RUnlock()
+	return FindDiffHunk(b.hunks, lineN)
+}
 func (b *Buffer) FindNextDiffLine(startLine int, forward bool) (int, error) {
 	if b.diff == nil {
 		return 0, errors.New("no diff data")
@@ -0,0 +1,77 @@
+package buffer
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"github.com/zyedidia/micro/v2/internal/compression"
+	"github.com/zyedidia/micro/v2/internal/config"
+)
+func TestCompressedSaveReopen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mecro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.ConfigDir = dir
+	if err := config.InitGlobalSettings(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		codec compression.Codec
+	}{
+		{"fast.gz", compression.Codec{Format: compression.Gzip, Level: gzip.BestSpeed}},
+		{"best.gz", compression.Codec{Format: compression.Gzip, Level: gzip.BestCompression}},
+		{"file.bz2", compression.Codec{Format: compression.Bzip2, Level: 3}},
+		{"file.zst", compression.Codec{Format: compression.Zstd, Level: 3, CheckSum: 1}},
+		{"nosum.zst", compression.Codec{Format: compression.Zstd, Level: 3}},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		var data bytes.Buffer
+		w, err := tt.codec.NewWriter(&data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte("first line\nsecond line\n"))
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, data.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		b, err := NewBufferFromFile(path, BTDefault)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(b.Bytes()); got != "first line\nsecond line\n" {
+			t.Errorf("%s: opened as %q", tt.name, got)
+		}
+		if b.Compression == nil || *b.Compression != tt.codec {
+			t.Errorf("%s: detected %+v, want %+v", tt.name, b.Compression, tt.codec)
+		}
+		b.Insert(b.End(), "third line")
+		if err := b.Save(); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		b.Close()
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c := compression.Detect(raw); c == nil || *c != tt.codec {
+			t.Errorf("%s: saved as %+v, want %+v", tt.name, c, tt.codec)
+		}
+		b, err = NewBufferFromFile(path, BTDefault)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(b.Bytes()); got != "first line\nsecond line\nthird line\n" {
+			t.Errorf("%s: reopened as %q", tt.name, got)
+		}
+		b.Close()
+	}
+}
\ No newline at end of file
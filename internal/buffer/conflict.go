@@ -0,0 +1,115 @@
+package buffer
+import "sort"
+type Conflict struct {
+	Start, Base, Sep, End int
+}
+func (c Conflict) Ours() (int, int) {
+	if c.Base >= 0 {
+		return c.Start + 1, c.Base
+	}
+	return c.Start + 1, c.Sep
+}
+func (c Conflict) Ancestor() (int, int) {
+	if c.Base >= 0 {
+		return c.Base + 1, c.Sep
+	}
+	return c.Sep, c.Sep
+}
+func (c Conflict) Theirs() (int, int) {
+	return c.Sep + 1, c.End
+}
+func (c Conflict) Section(lineN int) string {
+	switch {
+	case lineN < c.Start || lineN > c.End:
+		return ""
+	case c.Base >= 0 && lineN >= c.Base && lineN < c.Sep:
+		return "base"
+	case lineN < c.Sep && (c.Base < 0 || lineN < c.Base):
+		return "ours"
+	}
+	return "theirs"
+}
+func isConflictMarker(line []byte, marker byte) bool {
+	if len(line) < 7 || len(line) > 7 && line[7] != ' ' {
+		return false
+	}
+	for _, c := range line[:7] {
+		if c != marker {
+			return false
+		}
+	}
+	return true
+}
+func isAnyConflictMarker(line []byte) bool {
+	if len(line) < 7 {
+		return false
+	}
+	switch line[0] {
+	case '<', '|', '=', '>':
+		return isConflictMarker(line, line[0])
+	}
+	return false
+}
+func (b *SharedBuffer) conflictMarkersIn(start, end int) []int {
+	var markers []int
+	for lineN := start; lineN <= end && lineN < b.LinesNum(); lineN++ {
+		if isAnyConflictMarker(b.LineBytes(lineN)) {
+			markers = append(markers, lineN)
+		}
+	}
+	return markers
+}
+func (b *SharedBuffer) conflictLinesChanged(start, oldEnd, newEnd int) {
+	if !b.conflictsScanned {
+		return
+	}
+	i := sort.SearchInts(b.conflictMarkers, start)
+	j := sort.SearchInts(b.conflictMarkers, oldEnd+1)
+	markers := append([]int{}, b.conflictMarkers[:i]...)
+	markers = append(markers, b.conflictMarkersIn(start, newEnd)...)
+	for _, m := range b.conflictMarkers[j:] {
+		markers = append(markers, m+newEnd-oldEnd)
+	}
+	b.conflictMarkers = markers
+}
+func (b *SharedBuffer) resetConflicts() {
+	b.conflictsScanned = false
+	b.conflictMarkers = nil
+}
+func (b *Buffer) UpdateConflicts() {
+	if !b.conflictsScanned {
+		b.conflictMarkers = b.conflictMarkersIn(0, b.LinesNum()-1)
+		b.conflictsScanned = true
+	}
+	b.conflicts = nil
+	c := Conflict{-1, -1, -1, -1}
+	for _, lineN := range b.conflictMarkers {
+		l := b.LineBytes(lineN)
+		switch {
+		case isConflictMarker(l, '<'):
+			c = Conflict{lineN, -1, -1, -1}
+		case c.Start >= 0 && c.Sep < 0 && c.Base < 0 && isConflictMarker(l, '|'):
+			c.Base = lineN
+		case c.Start >= 0 && c.Sep < 0 && isConflictMarker(l, '='):
+			c.Sep = lineN
+		case c.Sep >= 0 && isConflictMarker(l, '>'):
+			c.End = lineN
+			b.conflicts = append(b.conflicts, c)
+			c = Conflict{-1, -1, -1, -1}
+		}
+	}
+}
+func (b *Buffer) Conflicts() []Conflict {
+	return b.conflicts
+}
+func (b *Buffer) ConflictAt(lineN int) (Conflict, bool) {
+	for _, c := range b.conflicts {
+		if lineN >= c.Start && lineN <= c.End {
+			return c, true
+		}
+		if c.Start > lineN {
+			break
+		}
+	}
+	return Conflict{}, false
+}
@@ -169,7 +169,11 @@ func (c *Cursor) UpN(amount int) {
 		proposedY = len(c.buf.lines) - 1
 	}
 	bytes := c.buf.LineBytes(proposedY)
-	c.X = c.GetCharPosInLine(bytes, c.LastVisualX)
+	if c.buf.GetCharPos != nil {
+		c.X = c.buf.GetCharPos(proposedY, c.LastVisualX)
+	} else {
+		c.X = c.GetCharPosInLine(bytes, c.LastVisualX)
+	}
 	if c.X > util.CharacterCount(bytes) || (amount < 0 && proposedY == c.Y) {
 		c.X = util.CharacterCount(bytes)
 		c.StoreVisualX()
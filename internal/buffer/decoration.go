@@ -0,0 +1,211 @@
+package buffer
+import (
+	"bytes"
+	"sort"
+	"github.com/zyedidia/micro/v2/internal/config"
+	"github.com/zyedidia/micro/v2/internal/util"
+	"github.com/zyedidia/tcell/v2"
+)
+type DecorationKind int
+const (
+	DKHighlight DecorationKind = iota
+	DKVirtualText
+	DKInlineText
+	DKSign
+)
+const MessagePriority = 10
+type Decoration struct {
+	Namespace string
+	Kind DecorationKind
+	Start, End Loc
+	Group string
+	Style *tcell.Style
+	Text string
+	Priority int
+}
+func (d *Decoration) GetStyle() tcell.Style {
+	if d.Style != nil {
+		return *d.Style
+	}
+	return config.GetColor(d.Group)
+}
+func (d *Decoration) SetStyle(style string) {
+	s := config.StringToStyle(style)
+	d.Style = &s
+}
+func (d *Decoration) Merge(base tcell.Style) tcell.Style {
+	fg, bg, attr := d.GetStyle().Decompose()
+	defFg, defBg, _ := config.DefStyle.Decompose()
+	if fg != defFg {
+		base = base.Foreground(fg)
+	}
+	if bg != defBg {
+		base = base.Background(bg)
+	}
+	if attr&tcell.AttrBold != 0 {
+		base = base.Bold(true)
+	}
+	if attr&tcell.AttrItalic != 0 {
+		base = base.Italic(true)
+	}
+	if attr&tcell.AttrUnderline != 0 {
+		base = base.Underline(true)
+	}
+	if attr&tcell.AttrReverse != 0 {
+		base = base.Reverse(true)
+	}
+	if attr&tcell.AttrStrikeThrough != 0 {
+		base = base.StrikeThrough(true)
+	}
+	if attr&tcell.AttrDim != 0 {
+		base = base.Dim(true)
+	}
+	return base
+}
+func (b *SharedBuffer) addDecoration(d *Decoration) *Decoration {
+	b.decorations = append(b.decorations, d)
+	b.InvalidateDecorations()
+	return d
+}
+func (b *SharedBuffer) AddHighlight(ns string, start, end Loc, group string) *Decoration {
+	if end.LessThan(start) {
+		start, end = end, start
+	}
+	return b.addDecoration(&Decoration{Namespace: ns, Kind: DKHighlight, Start: start, End: end, Group: group})
+}
+func (b *SharedBuffer) AddHighlightStyle(ns string, start, end Loc, style string) *Decoration {
+	d := b.AddHighlight(ns, start, end, "")
+	d.SetStyle(style)
+	return d
+}
+func (b *SharedBuffer) AddVirtualText(ns string, loc Loc, text, group string, inline bool) *Decoration {
+	kind := DKVirtualText
+	if inline {
+		kind = DKInlineText
+	}
+	return b.addDecoration(&Decoration{Namespace: ns, Kind: kind, Start: loc, End: loc, Group: group, Text: text})
+}
+func (b *SharedBuffer) AddSign(ns string, line int, text, group string, priority int) *Decoration {
+	loc := Loc{0, line}
+	return b.addDecoration(&Decoration{Namespace: ns, Kind: DKSign, Start: loc, End: loc, Group: group, Text: text, Priority: priority})
+}
+func (b *SharedBuffer) RemoveDecoration(d *Decoration) {
+	for i, e := range b.decorations {
+		if e == d {
+			b.decorations = append(b.decorations[:i], b.decorations[i+1:]...)
+			b.InvalidateDecorations()
+			return
+		}
+	}
+}
+func (b *SharedBuffer) ClearDecorations(ns string) {
+	decorations := b.decorations[:0]
+	for _, d := range b.decorations {
+		if d.Namespace != ns {
+			decorations = append(decorations, d)
+		}
+	}
+	for i := len(decorations); i < len(b.decorations); i++ {
+		b.decorations[i] = nil
+	}
+	b.decorations = decorations
+	b.InvalidateDecorations()
+}
+func (b *SharedBuffer) Decorations(ns string) []*Decoration {
+	b.InvalidateDecorations()
+	var decorations []*Decoration
+	for _, d := range b.decorations {
+		if ns == "" || d.Namespace == ns {
+			decorations = append(decorations, d)
+		}
+	}
+	return decorations
+}
+func (b *SharedBuffer) InvalidateDecorations() {
+	b.decorationLines = nil
+}
+func (b *SharedBuffer) indexDecorations() {
+	if b.decorationLines != nil {
+		return
+	}
+	b.decorationLines = make(map[int][]*Decoration)
+	b.hasSigns = false
+	for _, d := range b.decorations {
+		if d.Kind == DKSign {
+			b.hasSigns = true
+		}
+		for y := d.Start.Y; y <= d.End.Y; y++ {
+			b.decorationLines[y] = append(b.decorationLines[y], d)
+		}
+	}
+	for _, decorations := range b.decorationLines {
+		sort.SliceStable(decorations, func(i, j int) bool {
+			return decorations[i].Priority < decorations[j].Priority
+		})
+	}
+}
+func (b *SharedBuffer) HasSigns() bool {
+	b.indexDecorations()
+	return b.hasSigns
+}
+func (b *SharedBuffer) LineDecorations(line int) []*Decoration {
+	b.indexDecorations()
+	return b.decorationLines[line]
+}
+func shiftInsert(l, pos, end Loc, right bool) Loc {
+	if l.Y != pos.Y || l.X < pos.X || (l.X == pos.X && !right) {
+		if l.Y > pos.Y {
+			l.Y += end.Y - pos.Y
+		}
+		return l
+	}
+	return Loc{end.X + l.X - pos.X, end.Y}
+}
+func shiftRemove(l, start, end Loc) Loc {
+	if l.LessEqual(start) {
+		return l
+	}
+	if l.LessThan(end) {
+		return start
+	}
+	if l.Y == end.Y {
+		return Loc{start.X + l.X - end.X, start.Y}
+	}
+	return Loc{l.X, l.Y - (end.Y - start.Y)}
+}
+func (b *SharedBuffer) decorationsInserted(pos Loc, value []byte) {
+	if len(b.decorations) == 0 {
+		return
+	}
+	end := pos
+	if nl := bytes.LastIndexByte(value, '\n'); nl >= 0 {
+		end = Loc{util.CharacterCount(value[nl+1:]), pos.Y + bytes.Count(value, []byte{'\n'})}
+	} else {
+		end.X += util.CharacterCount(value)
+	}
+	for _, d := range b.decorations {
+		point := d.Start == d.End
+		d.Start = shiftInsert(d.Start, pos, end, true)
+		d.End = shiftInsert(d.End, pos, end, point)
+	}
+	b.InvalidateDecorations()
+}
+func (b *SharedBuffer) decorationsRemoved(start, end Loc) {
+	if len(b.decorations) == 0 {
+		return
+	}
+	decorations := b.decorations[:0]
+	for _, d := range b.decorations {
+		empty := d.Start == d.End
+		d.Start = shiftRemove(d.Start, start, end)
+		d.End = shiftRemove(d.End, start, end)
+		if d.Kind != DKHighlight || empty || d.Start != d.End {
+			decorations = append(decorations, d)
+		}
+	}
+	for i := len(decorations); i < len(b.decorations); i++ {
+		b.decorations[i] = nil
+	}
+	b.decorations = decorations
+	b.InvalidateDecorations()
+}
\ No newline at end of file
@@ -0,0 +1,111 @@
+package buffer
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"github.com/zyedidia/micro/v2/internal/config"
+)
+func TestShiftInsert(t *testing.T) {
+	tests := []struct {
+		l, pos, end Loc
+		right       bool
+		want        Loc
+	}{
+		{Loc{X: 2, Y: 0}, Loc{X: 5, Y: 0}, Loc{X: 8, Y: 0}, true, Loc{X: 2, Y: 0}},
+		{Loc{X: 6, Y: 0}, Loc{X: 5, Y: 0}, Loc{X: 8, Y: 0}, true, Loc{X: 9, Y: 0}},
+		{Loc{X: 5, Y: 0}, Loc{X: 5, Y: 0}, Loc{X: 8, Y: 0}, true, Loc{X: 8, Y: 0}},
+		{Loc{X: 5, Y: 0}, Loc{X: 5, Y: 0}, Loc{X: 8, Y: 0}, false, Loc{X: 5, Y: 0}},
+		{Loc{X: 7, Y: 0}, Loc{X: 5, Y: 0}, Loc{X: 2, Y: 2}, true, Loc{X: 4, Y: 2}},
+		{Loc{X: 3, Y: 1}, Loc{X: 5, Y: 0}, Loc{X: 2, Y: 2}, true, Loc{X: 3, Y: 3}},
+		{Loc{X: 3, Y: 1}, Loc{X: 5, Y: 2}, Loc{X: 2, Y: 4}, true, Loc{X: 3, Y: 1}},
+	}
+	for _, tt := range tests {
+		if got := shiftInsert(tt.l, tt.pos, tt.end, tt.right); got != tt.want {
+			t.Errorf("shiftInsert(%v, %v, %v, %v) = %v, want %v", tt.l, tt.pos, tt.end, tt.right, got, tt.want)
+		}
+	}
+}
+func TestShiftRemove(t *testing.T) {
+	tests := []struct {
+		l, start, end Loc
+		want          Loc
+	}{
+		{Loc{X: 2, Y: 0}, Loc{X: 3, Y: 0}, Loc{X: 6, Y: 0}, Loc{X: 2, Y: 0}},
+		{Loc{X: 3, Y: 0}, Loc{X: 3, Y: 0}, Loc{X: 6, Y: 0}, Loc{X: 3, Y: 0}},
+		{Loc{X: 4, Y: 0}, Loc{X: 3, Y: 0}, Loc{X: 6, Y: 0}, Loc{X: 3, Y: 0}},
+		{Loc{X: 6, Y: 0}, Loc{X: 3, Y: 0}, Loc{X: 6, Y: 0}, Loc{X: 3, Y: 0}},
+		{Loc{X: 9, Y: 0}, Loc{X: 3, Y: 0}, Loc{X: 6, Y: 0}, Loc{X: 6, Y: 0}},
+		{Loc{X: 0, Y: 1}, Loc{X: 3, Y: 0}, Loc{X: 2, Y: 2}, Loc{X: 3, Y: 0}},
+		{Loc{X: 5, Y: 2}, Loc{X: 3, Y: 0}, Loc{X: 2, Y: 2}, Loc{X: 6, Y: 0}},
+		{Loc{X: 5, Y: 4}, Loc{X: 3, Y: 0}, Loc{X: 2, Y: 2}, Loc{X: 5, Y: 2}},
+	}
+	for _, tt := range tests {
+		if got := shiftRemove(tt.l, tt.start, tt.end); got != tt.want {
+			t.Errorf("shiftRemove(%v, %v, %v) = %v, want %v", tt.l, tt.start, tt.end, got, tt.want)
+		}
+	}
+}
+func TestLineDecorations(t *testing.T) {
+	b := NewBufferFromString("one\ntwo\nthree\nfour", "", BTScratch)
+	low := b.AddSign("a", 1, "L", "", 1)
+	high := b.AddSign("a", 1, "H", "", 5)
+	span := b.AddHighlight("b", Loc{X: 1, Y: 0}, Loc{X: 2, Y: 2}, "")
+	if !b.HasSigns() {
+		t.Fatal("HasSigns() = false")
+	}
+	if got := b.LineDecorations(1); len(got) != 3 || got[0] != span || got[1] != low || got[2] != high {
+		t.Errorf("line 1 decorations are not ordered by priority: %v", got)
+	}
+	b.Insert(Loc{X: 0, Y: 0}, "zero\n")
+	if got := b.LineDecorations(1); len(got) != 1 || got[0] != span {
+		t.Errorf("line 1 after insert: %v", got)
+	}
+	if got := b.LineDecorations(2); len(got) != 3 {
+		t.Errorf("line 2 after insert: %v", got)
+	}
+	b.ClearDecorations("a")
+	if b.HasSigns() {
+		t.Error("HasSigns() = true after clearing the signs")
+	}
+	for _, d := range b.Decorations("b") {
+		d.End = Loc{X: 0, Y: 4}
+	}
+	b.InvalidateDecorations()
+	if got := b.LineDecorations(4); len(got) != 1 || got[0] != span {
+		t.Errorf("line 4 after moving the highlight: %v", got)
+	}
+}
+func TestReOpenShiftsDecorations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mecro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.ConfigDir = dir
+	if err := config.InitGlobalSettings(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "file.txt")
+	if err := ioutil.WriteFile(path, []byte("alpha\nbeta\ngamma\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewBufferFromFile(path, BTDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	d := b.AddHighlight("test", Loc{X: 0, Y: 2}, Loc{X: 5, Y: 2}, "")
+	if err := ioutil.WriteFile(path, []byte("new\nalpha\nbeta\ngamma\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.ReOpen(); err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Decorations("test")) != 1 {
+		t.Fatal("reloading dropped the decoration")
+	}
+	if d.Start != (Loc{X: 0, Y: 3}) || d.End != (Loc{X: 5, Y: 3}) {
+		t.Errorf("decoration at %v-%v after reload, want line 3", d.Start, d.End)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,121 @@
+package buffer
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"github.com/zyedidia/micro/v2/internal/compression"
+	"github.com/zyedidia/micro/v2/internal/config"
+	"github.com/zyedidia/micro/v2/internal/encryption"
+	"github.com/zyedidia/micro/v2/internal/util"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/transform"
+)
+var PlaintextOptions = []string{"backup", "permbackup", "saveundo", "savecursor"}
+func (b *Buffer) Locked() bool {
+	return b.locked
+}
+func (b *Buffer) Encrypted() bool {
+	return b.locked || b.Encryption != nil
+}
+func (b *Buffer) disablePlaintextOptions() {
+	for _, o := range PlaintextOptions {
+		b.Settings[o] = false
+	}
+}
+func (b *Buffer) initLocalSettings() {
+	config.InitLocalSettings(b.Settings, b.Path)
+	if b.Encrypted() {
+		b.disablePlaintextOptions()
+	}
+}
+func (b *Buffer) removePlaintextFiles() {
+	if b.Path == "" {
+		return
+	}
+	name := util.EscapePath(b.AbsPath)
+	if backupdir, err := util.ReplaceHome(b.Settings["backupdir"].(string)); err == nil && backupdir != "" {
+		os.Remove(filepath.Join(backupdir, name))
+	}
+	os.Remove(filepath.Join(config.ConfigDir, "backups", name))
+	os.Remove(filepath.Join(config.ConfigDir, "buffers", name))
+}
+func (b *Buffer) Decrypt(passphrase string) error {
+	if !b.locked {
+		return nil
+	}
+	file, err := os.Open(b.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	enc, err := htmlindex.Get(b.Settings["encoding"].(string))
+	if err != nil {
+		return err
+	}
+	key := encryption.NewKey(passphrase)
+	src, err := key.Decrypt(file)
+	if err != nil {
+		return err
+	}
+	r, codec, err := compression.Open(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	data, err := ioutil.ReadAll(bufio.NewReader(transform.NewReader(r, enc.NewDecoder())))
+	if err != nil {
+		return err
+	}
+	b.LineArray = NewLineArray(uint64(len(data)), FFAuto, bytes.NewReader(data))
+	switch b.Endings {
+	case FFUnix:
+		b.Settings["fileformat"] = "unix"
+	case FFDos:
+		b.Settings["fileformat"] = "dos"
+	}
+	b.Encryption = key
+	b.Compression = codec
+	b.locked = false
+	b.Type.Readonly = b.Settings["readonly"].(bool)
+	b.UndoStack = new(TEStack)
+	b.RedoStack = new(TEStack)
+	err = b.UpdateModTime()
+	if !b.Settings["fastdirty"].(bool) {
+		calcHash(b, &b.origHash)
+	}
+	b.isModified = false
+	b.RelocateCursors()
+	b.UpdateRules()
+	b.resetConflicts()
+	b.UpdateConflicts()
+	return err
+}
+func (b *Buffer) Encrypt(passphrase string) error {
+	if b.locked {
+		return errors.New("Buffer is locked")
+	}
+	if passphrase == "" {
+		return errors.New("Empty passphrase")
+	}
+	armor := b.Encryption != nil && b.Encryption.Armor
+	b.Encryption = encryption.NewKey(passphrase)
+	b.Encryption.Armor = armor
+	b.disablePlaintextOptions()
+	b.removePlaintextFiles()
+	b.isModified = true
+	return nil
+}
+func (b *Buffer) RemoveEncryption() error {
+	if b.locked {
+		return errors.New("Buffer is locked")
+	}
+	if b.Encryption == nil {
+		return errors.New("Buffer is not encrypted")
+	}
+	b.Encryption = nil
+	b.isModified = true
+	return nil
+}
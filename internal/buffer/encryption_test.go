@@ -0,0 +1,53 @@
+package buffer
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"github.com/zyedidia/micro/v2/internal/config"
+	"github.com/zyedidia/micro/v2/internal/util"
+)
+func TestEncryptedBufferKeepsPlaintextOptionsOff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mecro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.ConfigDir = dir
+	settings := `{"*.txt": {"backup": true, "saveundo": true, "savecursor": true}, "ft:go": {"permbackup": true}}`
+	if err := ioutil.WriteFile(filepath.Join(dir, "settings.json"), []byte(settings), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.ReadSettings(); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.InitGlobalSettings(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "secret.txt")
+	b := NewBufferFromString("secret", path, BTDefault)
+	if !b.Settings["saveundo"].(bool) {
+		t.Fatal("glob settings were not applied")
+	}
+	name := util.EscapePath(b.AbsPath)
+	for _, d := range []string{"backups", "buffers"} {
+		os.MkdirAll(filepath.Join(dir, d), os.ModePerm)
+		if err := ioutil.WriteFile(filepath.Join(dir, d, name), []byte("secret"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.Encrypt("passphrase"); err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range []string{"backups", "buffers"} {
+		if _, err := os.Stat(filepath.Join(dir, d, name)); !os.IsNotExist(err) {
+			t.Errorf("plaintext file in %s was not removed", d)
+		}
+	}
+	b.SetOptionNative("filetype", "go")
+	for _, o := range PlaintextOptions {
+		if b.Settings[o].(bool) {
+			t.Errorf("%s was turned back on for an encrypted buffer", o)
+		}
+	}
+}
\ No newline at end of file
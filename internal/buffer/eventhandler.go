@@ -26,6 +26,15 @@ type Delta struct {
 	Start Loc
 	End   Loc
 }
+var changeCallbacks []func(*SharedBuffer, Delta)
+func AddChangeCallback(cb func(*SharedBuffer, Delta)) {
+	changeCallbacks = append(changeCallbacks, cb)
+}
+func (b *SharedBuffer) changed(d Delta) {
+	for _, cb := range changeCallbacks {
+		cb(b, d)
+	}
+}
 func (eh *EventHandler) DoTextEvent(t *TextEvent, useUndo bool) {
 	oldl := eh.buf.LinesNum()
 	if useUndo {
@@ -93,14 +102,17 @@ func (eh *EventHandler) DoTextEvent(t *TextEvent, useUndo bool) {
 func ExecuteTextEvent(t *TextEvent, buf *SharedBuffer) {
 	if t.EventType == TextEventInsert {
 		for _, d := range t.Deltas {
+			buf.changed(Delta{d.Text, d.Start, d.Start})
 			buf.insert(d.Start, d.Text)
 		}
 	} else if t.EventType == TextEventRemove {
 		for i, d := range t.Deltas {
+			buf.changed(Delta{nil, d.Start, d.End})
 			t.Deltas[i].Text = buf.remove(d.Start, d.End)
 		}
 	} else if t.EventType == TextEventReplace {
 		for i, d := range t.Deltas {
+			buf.changed(d)
 			t.Deltas[i].Text = buf.remove(d.Start, d.End)
 			buf.insert(d.Start, d.Text)
 			t.Deltas[i].Start = d.Start
@@ -154,6 +166,9 @@ func (eh *EventHandler) InsertBytes(start Loc, text []byte) {
 		return
 	}
 	start = clamp(start, eh.buf.LineArray)
+	if start.LessThan(eh.buf.ReadonlyBefore) {
+		return
+	}
 	e := &TextEvent{
 		C:         *eh.cursors[eh.active],
 		EventType: TextEventInsert,
@@ -168,6 +183,9 @@ func (eh *EventHandler) Remove(start, end Loc) {
 	}
 	start = clamp(start, eh.buf.LineArray)
 	end = clamp(end, eh.buf.LineArray)
+	if start.LessThan(eh.buf.ReadonlyBefore) {
+		return
+	}
 	e := &TextEvent{
 		C:         *eh.cursors[eh.active],
 		EventType: TextEventRemove,
@@ -177,6 +195,11 @@ func (eh *EventHandler) Remove(start, end Loc) {
 	eh.DoTextEvent(e, true)
 }
 func (eh *EventHandler) MultipleReplace(deltas []Delta) {
+	for _, d := range deltas {
+		if d.Start.LessThan(eh.buf.ReadonlyBefore) {
+			return
+		}
+	}
 	e := &TextEvent{
 		C:         *eh.cursors[eh.active],
 		EventType: TextEventReplace,
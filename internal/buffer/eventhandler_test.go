@@ -0,0 +1,18 @@
+package buffer
+import "testing"
+func TestReadonlyBefore(t *testing.T) {
+	b := NewBufferFromString("abc\ndef", "", BTScratch)
+	b.ReadonlyBefore = Loc{X: 1, Y: 1}
+	b.Insert(Loc{X: 1, Y: 0}, "x")
+	b.Remove(Loc{X: 2, Y: 0}, Loc{X: 2, Y: 1})
+	b.Replace(Loc{X: 0, Y: 1}, Loc{X: 3, Y: 1}, "y")
+	b.MultipleReplace([]Delta{{Text: []byte("z"), Start: Loc{X: 2, Y: 1}, End: Loc{X: 3, Y: 1}}, {Text: []byte("w"), Start: Loc{X: 0, Y: 0}, End: Loc{X: 1, Y: 0}}})
+	if got := string(b.Bytes()); got != "abc\ndef" {
+		t.Fatalf("protected text was edited: %q", got)
+	}
+	b.Insert(Loc{X: 1, Y: 1}, "x")
+	b.Remove(Loc{X: 2, Y: 1}, Loc{X: 3, Y: 1})
+	if got := string(b.Bytes()); got != "abc\ndxf" {
+		t.Fatalf("got %q, want %q", got, "abc\ndxf")
+	}
+}
\ No newline at end of file
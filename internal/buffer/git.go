@@ -0,0 +1,59 @@
+package buffer
+import (
+	"os"
+	"sync/atomic"
+	"github.com/zyedidia/micro/v2/internal/git"
+	"github.com/zyedidia/micro/v2/internal/watcher"
+)
+func (b *Buffer) usesGitBase() bool {
+	diff, _ := b.Settings["diff"].(bool)
+	return diff && b.Settings["diffgutter"].(bool) && !b.Type.Scratch && b.Path != "" && b.Compression == nil && !b.Encrypted()
+}
+func readGitBase(path string) (string, []byte, error) {
+	if _, err := os.Stat(path); err != nil {
+		return "", nil, nil
+	}
+	repo, err := git.Open(path)
+	if err != nil {
+		return "", nil, err
+	}
+	base, err := repo.Base(path)
+	return repo.Dir, base, err
+}
+func (b *Buffer) UpdateGitBase() {
+	if !b.usesGitBase() {
+		return
+	}
+	b.gitGen++
+	b.setGitBase(readGitBase(b.AbsPath))
+}
+func (b *Buffer) UpdateGitBaseAsync(post func(func())) {
+	if !b.usesGitBase() {
+		return
+	}
+	b.gitGen++
+	gen, path := b.gitGen, b.AbsPath
+	go func() {
+		dir, base, err := readGitBase(path)
+		post(func() {
+			if gen == b.gitGen && atomic.LoadInt32(&b.fini) == 0 {
+				b.setGitBase(dir, base, err)
+			}
+		})
+	}()
+}
+func (b *Buffer) setGitBase(dir string, base []byte, err error) {
+	if dir != b.gitWatch {
+		watcher.Unwatch(b.gitWatch)
+		watcher.Watch(dir)
+		b.gitWatch = dir
+	}
+	b.GitDir = dir
+	if err != nil {
+		if b.diffBase == nil {
+			b.SetDiffBase(b.Bytes())
+		}
+		return
+	}
+	b.SetDiffBase(base)
+}
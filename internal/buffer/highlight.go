@@ -0,0 +1,120 @@
+package buffer
+import (
+	"github.com/zyedidia/micro/v2/internal/screen"
+	"github.com/zyedidia/micro/v2/internal/util"
+	"github.com/zyedidia/micro/v2/pkg/highlight"
+)
+type highlightJob struct {
+	def     *highlight.Def
+	from    int
+	until   int
+	full    bool
+	pending bool
+}
+func (b *SharedBuffer) SetVisibleLines(start, end int) {
+	b.hlLock.Lock()
+	b.hlVisible = [2]int{start, end}
+	b.hlLock.Unlock()
+}
+func (b *SharedBuffer) queueHighlight(def *highlight.Def, from int, full bool) {
+	b.hlLock.Lock()
+	defer b.hlLock.Unlock()
+	b.hlGen++
+	b.mergeHighlight(highlightJob{def, from, from, full, true})
+	if !b.hlRunning {
+		b.hlRunning = true
+		go b.highlightWorker()
+	}
+}
+func (b *SharedBuffer) mergeHighlight(job highlightJob) {
+	cur := &b.hlJob
+	if cur.pending && cur.def == job.def {
+		cur.from = util.Min(cur.from, job.from)
+		cur.until = util.Max(cur.until, job.until)
+		cur.full = cur.full || job.full
+	} else {
+		*cur = job
+	}
+}
+func (b *SharedBuffer) stopHighlight() {
+	b.hlLock.Lock()
+	b.hlGen++
+	b.hlJob.pending = false
+	b.hlLock.Unlock()
+}
+func (b *SharedBuffer) highlightState() (int, [2]int) {
+	b.hlLock.Lock()
+	defer b.hlLock.Unlock()
+	return b.hlGen, b.hlVisible
+}
+func (b *SharedBuffer) highlightWorker() {
+	for {
+		b.hlLock.Lock()
+		job, gen := b.hlJob, b.hlGen
+		b.hlJob.pending = false
+		if !job.pending {
+			b.hlRunning = false
+			b.hlLock.Unlock()
+			return
+		}
+		b.hlLock.Unlock()
+		if rest, ok := b.runHighlight(job, gen); !ok {
+			b.hlLock.Lock()
+			if b.hlJob.pending && b.hlJob.def == rest.def {
+				b.mergeHighlight(rest)
+			}
+			b.hlLock.Unlock()
+		}
+	}
+}
+func (b *SharedBuffer) runHighlight(job highlightJob, gen int) (highlightJob, bool) {
+	h := highlight.NewHighlighter(job.def)
+	done := make([]bool, b.LinesNum())
+	last := [2]int{-1, -1}
+	matchVisible := func(visible [2]int, upto int) {
+		if visible == last {
+			return
+		}
+		start, end := util.Max(visible[0], job.from), util.Min(visible[1], upto)
+		if start > end || end >= len(done) {
+			return
+		}
+		last = visible
+		for j := start; j <= end; j++ {
+			if !done[j] {
+				h.HighlightLine(b, j, true)
+				done[j] = true
+			}
+		}
+		screen.Redraw()
+	}
+	stop := len(done) - 1
+	for i := job.from; i < len(done); i++ {
+		g, visible := b.highlightState()
+		if g != gen {
+			return job, false
+		}
+		changed := h.HighlightLine(b, i, false)
+		if i >= util.Min(visible[1], len(done)-1) {
+			matchVisible(visible, i)
+		}
+		if !job.full && !changed && i >= job.until {
+			stop = i
+			break
+		}
+	}
+	for i := job.from; i <= stop; i++ {
+		g, visible := b.highlightState()
+		if g != gen {
+			job.from, job.until = i, util.Max(job.until, stop)
+			return job, false
+		}
+		matchVisible(visible, stop)
+		if !done[i] {
+			h.HighlightLine(b, i, true)
+			done[i] = true
+		}
+	}
+	screen.Redraw()
+	return job, true
+}
\ No newline at end of file
@@ -10,7 +10,9 @@ import (
 	"path/filepath"
 	"runtime"
 	"unicode"
+	"github.com/zyedidia/micro/v2/internal/compression"
 	"github.com/zyedidia/micro/v2/internal/config"
+	"github.com/zyedidia/micro/v2/internal/encryption"
 	"github.com/zyedidia/micro/v2/internal/screen"
 	"github.com/zyedidia/micro/v2/internal/util"
 	"golang.org/x/text/encoding"
@@ -18,7 +20,40 @@ import (
 	"golang.org/x/text/transform"
 )
 const LargeFileThreshold = 50000
-func overwriteFile(name string, enc encoding.Encoding, fn func(io.Writer) error, withSudo bool) (err error) {
+func encodeFile(file io.Writer, enc encoding.Encoding, codec *compression.Codec, fn func(io.Writer) error) (err error) {
+	cw, err := codec.NewWriter(file)
+	if err != nil {
+		return
+	}
+	w := bufio.NewWriter(transform.NewWriter(cw, enc.NewEncoder()))
+	err = fn(w)
+	if err2 := w.Flush(); err2 != nil && err == nil {
+		err = err2
+	}
+	if err2 := cw.Close(); err2 != nil && err == nil {
+		err = err2
+	}
+	return
+}
+func overwriteFile(name string, enc encoding.Encoding, codec *compression.Codec, key *encryption.Key, fn func(io.Writer) error, withSudo bool) (err error) {
+	if key != nil {
+		var data bytes.Buffer
+		ew, err := key.NewWriter(&data)
+		if err != nil {
+			return err
+		}
+		err = encodeFile(ew, enc, codec, fn)
+		if err2 := ew.Close(); err2 != nil && err == nil {
+			err = err2
+		}
+		if err != nil {
+			return err
+		}
+		return overwriteFile(name, encoding.Nop, nil, nil, func(file io.Writer) error {
+			_, err := data.WriteTo(file)
+			return err
+		}, withSudo)
+	}
 	var writeCloser io.WriteCloser
 	var screenb bool
 	var cmd *exec.Cmd
@@ -41,11 +76,7 @@ func overwriteFile(name string, enc encoding.Encoding, fn func(io.Writer) error,
 	} else if writeCloser, err = os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666); err != nil {
 		return
 	}
-	w := bufio.NewWriter(transform.NewWriter(writeCloser, enc.NewEncoder()))
-	err = fn(w)
-	if err2 := w.Flush(); err2 != nil && err == nil {
-		err = err2
-	}
+	err = encodeFile(writeCloser, enc, codec, fn)
 	if !withSudo {
 		f := writeCloser.(*os.File)
 		if err2 := f.Sync(); err2 != nil && err == nil {
@@ -87,6 +118,9 @@ func (b *Buffer) saveToFile(filename string, withSudo bool, autoSave bool) error
 	if b.Type.Scratch {
 		return errors.New("Cannot save scratch buffer")
 	}
+	if b.locked {
+		return errors.New("Cannot save encrypted buffer before it is decrypted")
+	}
 	if withSudo && runtime.GOOS == "windows" {
 		return errors.New("Save with sudo not supported on Windows")
 	}
@@ -149,7 +183,7 @@ func (b *Buffer) saveToFile(filename string, withSudo bool, autoSave bool) error
 		}
 		return
 	}
-	if err = overwriteFile(absFilename, enc, fwriter, withSudo); err != nil {
+	if err = overwriteFile(absFilename, enc, b.Compression, b.Encryption, fwriter, withSudo); err != nil {
 		return err
 	}
 	if !b.Settings["fastdirty"].(bool) {
@@ -159,9 +193,12 @@ func (b *Buffer) saveToFile(filename string, withSudo bool, autoSave bool) error
 			calcHash(b, &b.origHash)
 		}
 	}
-	b.Path = filename
 	absPath, _ := filepath.Abs(filename)
-	b.AbsPath = absPath
+	if b.Path != filename || b.AbsPath != absPath {
+		b.Path = filename
+		b.AbsPath = absPath
+		b.rewatch()
+	}
 	b.isModified = false
 	b.UpdateRules()
 	return err
@@ -0,0 +1,63 @@
+package buffer
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	lua "github.com/yuin/gopher-lua"
+	"github.com/zyedidia/micro/v2/internal/config"
+	ulua "github.com/zyedidia/micro/v2/internal/lua"
+	"github.com/zyedidia/micro/v2/internal/watcher"
+)
+func init() {
+	ulua.L = lua.NewState()
+	config.InitRuntimeFiles(false)
+}
+func TestSaveAsMovesSharedWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mecro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.ConfigDir = dir
+	if err := config.InitGlobalSettings(); err != nil {
+		t.Fatal(err)
+	}
+	oldPath := filepath.Join(dir, "old.txt")
+	newPath := filepath.Join(dir, "new.txt")
+	if err := ioutil.WriteFile(oldPath, []byte("text\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	a, err := NewBufferFromFile(oldPath, BTDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewBufferFromFile(oldPath, BTDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.SharedBuffer != b.SharedBuffer {
+		t.Fatal("buffers for the same file are not shared")
+	}
+	if !watcher.Watching(a.AbsPath) {
+		t.Fatal("opened file is not watched")
+	}
+	oldAbs := a.AbsPath
+	if err := a.SaveAs(newPath); err != nil {
+		t.Fatal(err)
+	}
+	if watcher.Watching(oldAbs) {
+		t.Error("old path is still watched after save-as")
+	}
+	if !watcher.Watching(b.AbsPath) {
+		t.Error("new path is not watched after save-as")
+	}
+	a.Close()
+	if !watcher.Watching(b.AbsPath) {
+		t.Error("closing one view stopped the watch for the other")
+	}
+	b.Close()
+	if watcher.Watching(b.AbsPath) {
+		t.Error("new path is still watched after both views closed")
+	}
+}
\ No newline at end of file
@@ -23,7 +23,7 @@ func (b *Buffer) Serialize() error {
 		return nil
 	}
 	name := filepath.Join(config.ConfigDir, "buffers", util.EscapePath(b.AbsPath))
-	return overwriteFile(name, encoding.Nop, func(file io.Writer) error {
+	return overwriteFile(name, encoding.Nop, nil, nil, func(file io.Writer) error {
 		err := gob.NewEncoder(file).Encode(SerializedBuffer{
 			b.EventHandler,
 			b.GetActiveCursor().Loc,
@@ -1,9 +1,17 @@
 package buffer
 import (
+	"errors"
 	"github.com/zyedidia/micro/v2/internal/config"
 	"github.com/zyedidia/micro/v2/internal/screen"
 )
 func (b *Buffer) SetOptionNative(option string, nativeValue interface{}) error {
+	if b.Encrypted() && nativeValue == true {
+		for _, o := range PlaintextOptions {
+			if option == o {
+				return errors.New(option + " cannot be enabled for encrypted buffers")
+			}
+		}
+	}
 	b.Settings[option] = nativeValue
 	if option == "fastdirty" {
 		if !nativeValue.(bool) {
@@ -26,7 +34,7 @@ func (b *Buffer) SetOptionNative(option string, nativeValue interface{}) error {
 		if err != nil {
 			screen.TermMessage(err)
 		}
-		config.InitLocalSettings(b.Settings, b.Path)
+		b.initLocalSettings()
 		b.UpdateRules()
 	} else if option == "fileformat" {
 		switch b.Settings["fileformat"].(string) {
@@ -44,6 +52,8 @@ func (b *Buffer) SetOptionNative(option string, nativeValue interface{}) error {
 		}
 	} else if option == "encoding" {
 		b.isModified = true
+	} else if (option == "diffgutter" || option == "diff") && nativeValue.(bool) {
+		b.UpdateGitBase()
 	} else if option == "readonly" && b.Type.Kind == BTDefault.Kind {
 		b.Type.Readonly = nativeValue.(bool)
 	} else if option == "hlsearch" {
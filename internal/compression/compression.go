@@ -0,0 +1,131 @@
+package compression
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	dbzip2 "github.com/dsnet/compress/bzip2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+type Format int
+const (
+	None Format = iota
+	Gzip
+	Bzip2
+	Xz
+	Zstd
+)
+var formatNames = map[Format]string{
+	None:  "",
+	Gzip:  "gzip",
+	Bzip2: "bzip2",
+	Xz:    "xz",
+	Zstd:  "zstd",
+}
+var formatExts = map[Format]string{
+	Gzip:  ".gz",
+	Bzip2: ".bz2",
+	Xz:    ".xz",
+	Zstd:  ".zst",
+}
+type Codec struct {
+	Format   Format
+	Level    int
+	CheckSum byte
+}
+func (c *Codec) String() string {
+	if c == nil {
+		return ""
+	}
+	return formatNames[c.Format]
+}
+func (c *Codec) Ext() string {
+	if c == nil {
+		return ""
+	}
+	return formatExts[c.Format]
+}
+func Detect(header []byte) *Codec {
+	switch {
+	case len(header) >= 10 && header[0] == 0x1f && header[1] == 0x8b:
+		level := gzip.DefaultCompression
+		switch header[8] {
+		case 2:
+			level = gzip.BestCompression
+		case 4:
+			level = gzip.BestSpeed
+		}
+		return &Codec{Format: Gzip, Level: level}
+	case len(header) >= 4 && bytes.HasPrefix(header, []byte("BZh")) && header[3] >= '1' && header[3] <= '9':
+		return &Codec{Format: Bzip2, Level: int(header[3] - '0')}
+	case len(header) >= 8 && bytes.HasPrefix(header, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}):
+		return &Codec{Format: Xz, CheckSum: header[7] & 0x0f}
+	case len(header) >= 5 && bytes.HasPrefix(header, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		return &Codec{Format: Zstd, Level: 3, CheckSum: header[4] >> 2 & 1}
+	}
+	return nil
+}
+func Open(r io.Reader) (io.ReadCloser, *Codec, error) {
+	br := bufio.NewReader(r)
+	header, _ := br.Peek(10)
+	c := Detect(header)
+	if c == nil {
+		return ioutil.NopCloser(br), nil, nil
+	}
+	dr, err := c.NewReader(br)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dr, c, nil
+}
+func (c *Codec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	switch c.Format {
+	case Gzip:
+		return gzip.NewReader(r)
+	case Bzip2:
+		return ioutil.NopCloser(bzip2.NewReader(r)), nil
+	case Xz:
+		xr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.NopCloser(xr), nil
+	case Zstd:
+		d, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	}
+	return ioutil.NopCloser(r), nil
+}
+type nopWriteCloser struct {
+	io.Writer
+}
+func (nopWriteCloser) Close() error {
+	return nil
+}
+func (c *Codec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	if c == nil {
+		return nopWriteCloser{w}, nil
+	}
+	switch c.Format {
+	case Gzip:
+		return gzip.NewWriterLevel(w, c.Level)
+	case Bzip2:
+		return dbzip2.NewWriter(w, &dbzip2.WriterConfig{Level: c.Level})
+	case Xz:
+		conf := xz.WriterConfig{CheckSum: c.CheckSum}
+		if c.CheckSum == xz.None {
+			conf.NoCheckSum = true
+		}
+		return conf.NewWriter(w)
+	case Zstd:
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(c.Level)), zstd.WithEncoderCRC(c.CheckSum != 0))
+	}
+	return nil, errors.New("unknown compression format")
+}
@@ -8,5 +8,8 @@ func init() {
 		"command":  make(map[string]string),
 		"buffer":   make(map[string]string),
 		"terminal": make(map[string]string),
+		"normal":   make(map[string]string),
+		"insert":   make(map[string]string),
+		"visual":   make(map[string]string),
 	}
 }
\ No newline at end of file
@@ -8,6 +8,9 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
+	"time"
+	"github.com/zyedidia/micro/v2/pkg/highlight"
 	rt "github.com/zyedidia/micro/v2/runtime"
 )
 const (
@@ -46,6 +49,19 @@ type namedFile struct {
 	realFile
 	name string
 }
+type convertedFile struct {
+	path    string
+	ext     string
+	convert func([]byte) ([]byte, error)
+}
+type convertedData struct {
+	modTime time.Time
+	data    []byte
+}
+var (
+	convertLock  sync.Mutex
+	convertCache = make(map[string]convertedData)
+)
 type memoryFile struct {
 	name string
 	data []byte
@@ -70,6 +86,29 @@ func (af assetFile) Name() string {
 func (af assetFile) Data() ([]byte, error) {
 	return rt.Asset(string(af))
 }
+func (cf convertedFile) Name() string {
+	return strings.TrimSuffix(filepath.Base(cf.path), cf.ext)
+}
+func (cf convertedFile) Data() ([]byte, error) {
+	info, err := os.Stat(cf.path)
+	if err != nil {
+		return nil, err
+	}
+	convertLock.Lock()
+	defer convertLock.Unlock()
+	if c, ok := convertCache[cf.path]; ok && c.modTime.Equal(info.ModTime()) {
+		return c.data, nil
+	}
+	data, err := ioutil.ReadFile(cf.path)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = cf.convert(data); err != nil {
+		return nil, err
+	}
+	convertCache[cf.path] = convertedData{info.ModTime(), data}
+	return data, nil
+}
 func (nf namedFile) Name() string {
 	return nf.name
 }
@@ -89,6 +128,22 @@ func AddRuntimeFilesFromDirectory(fileType RTFiletype, directory, pattern string
 		}
 	}
 }
+func AddConvertedFilesFromDirectory(fileType RTFiletype, directory, ext string, convert func([]byte) ([]byte, error)) {
+	files, _ := ioutil.ReadDir(directory)
+fileLoop:
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ext) {
+			continue
+		}
+		cf := convertedFile{filepath.Join(directory, f.Name()), ext, convert}
+		for _, rf := range realFiles[fileType] {
+			if cf.Name() == rf.Name() {
+				continue fileLoop
+			}
+		}
+		AddRealRuntimeFile(fileType, cf)
+	}
+}
 func AddRuntimeFilesFromAssets(fileType RTFiletype, directory, pattern string) {
 	files, err := rt.AssetDir(directory)
 	if err != nil {
@@ -129,8 +184,16 @@ func InitRuntimeFiles(user bool) {
 		AddRuntimeFilesFromAssets(fileType, path.Join("runtime", dir), pattern)
 	}
 	initRuntimeVars()
-	add(RTColorscheme, "colorschemes", "*.micro")
-	add(RTSyntax, "syntax", "*.yaml")
+	if user {
+		AddRuntimeFilesFromDirectory(RTColorscheme, filepath.Join(ConfigDir, "colorschemes"), "*.micro")
+		for _, ext := range []string{".json", ".tmTheme", ".yaml"} {
+			AddConvertedFilesFromDirectory(RTColorscheme, filepath.Join(ConfigDir, "colorschemes"), ext, ConvertTheme)
+		}
+		AddRuntimeFilesFromDirectory(RTSyntax, filepath.Join(ConfigDir, "syntax"), "*.yaml")
+		AddConvertedFilesFromDirectory(RTSyntax, filepath.Join(ConfigDir, "syntax"), ".tmLanguage.json", highlight.ConvertTextMate)
+	}
+	AddRuntimeFilesFromAssets(RTColorscheme, path.Join("runtime", "colorschemes"), "*.micro")
+	AddRuntimeFilesFromAssets(RTSyntax, path.Join("runtime", "syntax"), "*.yaml")
 	add(RTSyntaxHeader, "syntax", "*.hdr")
 	add(RTHelp, "help", "*.md")
 }
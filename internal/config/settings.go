@@ -12,6 +12,7 @@ import (
 	"strings"
 	"github.com/zyedidia/glob"
 	"github.com/zyedidia/json5"
+	"github.com/zyedidia/micro/v2/internal/quickfix"
 	"github.com/zyedidia/micro/v2/internal/util"
 	"golang.org/x/text/encoding/htmlindex"
 )
@@ -23,17 +24,23 @@ var optionValidators = map[string]optionValidator{
 	"colorscheme":     validateColorscheme,
 	"detectlimit":     validateNonNegativeValue,
 	"encoding":        validateEncoding,
+	"errorformat":     validateErrorFormat,
 	"fileformat":      validateChoice,
+	"keymap":          validateChoice,
+	"keytimeout":      validateNonNegativeValue,
+	"lsptimeout":      validateNonNegativeValue,
 	"matchbracestyle": validateChoice,
 	"multiopen":       validateChoice,
 	"reload":          validateChoice,
 	"scrollmargin":    validateNonNegativeValue,
 	"scrollspeed":     validateNonNegativeValue,
 	"tabsize":         validatePositiveValue,
+	"whichkeydelay":   validateNonNegativeValue,
 }
 var OptionChoices = map[string][]string{
 	"clipboard":       {"internal", "external", "terminal"},
 	"fileformat":      {"unix", "dos"},
+	"keymap":          {"default", "vim"},
 	"matchbracestyle": {"underline", "highlight"},
 	"multiopen":       {"tab", "hsplit", "vsplit"},
 	"reload":          {"prompt", "auto", "disabled"},
@@ -50,6 +57,7 @@ var defaultCommonSettings = map[string]interface{}{
 	"diffgutter":      false,
 	"encoding":        "utf-8",
 	"eofnewline":      true,
+	"errorformat":     "%f:%l:%c: %t: %m,%f:%l:%c: %m,%f:%l: %t: %m,%f:%l: %m,%f(%l\\,%c): %t %m",
 	"fastdirty":       false,
 	"fileformat":      defaultFileFormat(),
 	"filetype":        "unknown",
@@ -60,6 +68,7 @@ var defaultCommonSettings = map[string]interface{}{
 	"ignorecase":      true,
 	"indentchar":      " ",
 	"keepautoindent":  false,
+	"lspserver":       "",
 	"matchbrace":      true,
 	"matchbracestyle": "underline",
 	"mkparents":       true,
@@ -78,7 +87,7 @@ var defaultCommonSettings = map[string]interface{}{
 	"softwrap":        true,
 	"splitbottom":     true,
 	"splitright":      true,
-	"statusformatl":   "$(filename) $(modified)($(line),$(col)) $(status.paste)| $(opt:filetype) | $(opt:fileformat) | $(opt:encoding)",
+	"statusformatl":   "$(filename) $(modified)($(line),$(col)) $(status.paste)| $(opt:filetype) | $(opt:fileformat) | $(opt:encoding)$(compression)$(encryption)$(conflicts)",
 	"statusformatr":   "",
 	"statusline":      true,
 	"syntax":          true,
@@ -94,10 +103,15 @@ var DefaultGlobalOnlySettings = map[string]interface{}{
 	"colorscheme":    "catppuccin-mocha",
 	"divchars":       "│—",
 	"divreverse":     true,
+	"exportruler":    false,
 	"fakecursor":     false,
 	"helpsplit":      "hsplit",
 	"infobar":        true,
+	"keymap":         "default",
 	"keymenu":        false,
+	"keytimeout":     float64(1000),
+	"leader":         "\\",
+	"lsptimeout":     float64(3000),
 	"mouse":          true,
 	"multiopen":      "vsplit",
 	"parsecursor":    false,
@@ -106,9 +120,12 @@ var DefaultGlobalOnlySettings = map[string]interface{}{
 	"pluginrepos":    []string{},
 	"savehistory":    true,
 	"scrollbarchar":  "¦",
+	"server":         true,
 	"sucmd":          "sudo",
 	"tabhighlight":   false,
 	"tabreverse":     true,
+	"whichkey":       true,
+	"whichkeydelay":  float64(500),
 	"xterm":          false,
 }
 var LocalSettings = []string{
@@ -405,4 +422,12 @@ func validateColorscheme(option string, value interface{}) error {
 func validateEncoding(option string, value interface{}) error {
 	_, err := htmlindex.Get(value.(string))
 	return err
+}
+func validateErrorFormat(option string, value interface{}) error {
+	efm, ok := value.(string)
+	if !ok {
+		return errors.New("Expected string type for " + option)
+	}
+	_, err := quickfix.CompileErrorFormat(efm)
+	return err
 }
\ No newline at end of file
@@ -0,0 +1,444 @@
+package config
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"gopkg.in/yaml.v2"
+)
+type themeRule struct {
+	scopes []string
+	fg     string
+	bg     string
+	style  string
+}
+type theme struct {
+	colors map[string]string
+	rules  []themeRule
+}
+var themeScopes = []struct {
+	group  string
+	scopes []string
+}{
+	{"comment", []string{"comment"}},
+	{"identifier", []string{"entity.name.function", "support.function"}},
+	{"identifier.class", []string{"entity.name.class", "support.class", "entity.name.type"}},
+	{"identifier.var", []string{"variable.other", "variable"}},
+	{"constant", []string{"constant", "support.constant"}},
+	{"constant.string", []string{"string"}},
+	{"constant.number", []string{"constant.numeric"}},
+	{"constant.bool", []string{"constant.language"}},
+	{"constant.specialChar", []string{"constant.character.escape", "constant.character"}},
+	{"statement", []string{"keyword.control", "keyword"}},
+	{"symbol", []string{"punctuation"}},
+	{"symbol.operator", []string{"keyword.operator"}},
+	{"symbol.brackets", []string{"punctuation.section", "meta.brace"}},
+	{"symbol.tag", []string{"entity.name.tag"}},
+	{"preproc", []string{"meta.preprocessor", "keyword.control.directive", "keyword.control.import"}},
+	{"type", []string{"storage.type", "entity.name.type", "support.type"}},
+	{"type.keyword", []string{"storage.modifier", "storage"}},
+	{"special", []string{"support.function", "variable.language"}},
+	{"underlined", []string{"markup.underline.link", "markup.underline"}},
+	{"error", []string{"invalid.illegal", "invalid"}},
+	{"todo", []string{"comment.todo", "keyword.other.todo"}},
+}
+var tmThemeColors = map[string]string{
+	"background":          "editor.background",
+	"foreground":          "editor.foreground",
+	"caret":               "editorCursor.foreground",
+	"selection":           "editor.selectionBackground",
+	"selectionForeground": "editor.selectionForeground",
+	"lineHighlight":       "editor.lineHighlightBackground",
+	"gutter":              "editorGutter.background",
+	"gutterForeground":    "editorLineNumber.foreground",
+	"invisibles":          "editorWhitespace.foreground",
+	"findHighlight":       "editor.findMatchHighlightBackground",
+	"guide":               "editorIndentGuide.background",
+}
+func ConvertTheme(data []byte) ([]byte, error) {
+	var t *theme
+	var err error
+	switch trimmed := bytes.TrimSpace(data); {
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		t, err = parseTmTheme(data)
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		t, err = parseVSCodeTheme(data)
+	default:
+		t, err = parseBase16(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t.micro(), nil
+}
+func parseVSCodeTheme(data []byte) (*theme, error) {
+	var src struct {
+		Colors      map[string]string `json:"colors"`
+		TokenColors json.RawMessage   `json:"tokenColors"`
+	}
+	if err := json.Unmarshal(stripJSONComments(data), &src); err != nil {
+		return nil, err
+	}
+	t := &theme{colors: src.Colors}
+	if t.colors == nil {
+		t.colors = make(map[string]string)
+	}
+	var tokens []struct {
+		Scope    interface{} `json:"scope"`
+		Settings struct {
+			Foreground string `json:"foreground"`
+			Background string `json:"background"`
+			FontStyle  string `json:"fontStyle"`
+		} `json:"settings"`
+	}
+	json.Unmarshal(src.TokenColors, &tokens)
+	for _, tok := range tokens {
+		r := themeRule{fg: tok.Settings.Foreground, bg: tok.Settings.Background, style: tok.Settings.FontStyle}
+		switch scope := tok.Scope.(type) {
+		case string:
+			r.scopes = splitScopes(scope)
+		case []interface{}:
+			for _, s := range scope {
+				if s, ok := s.(string); ok {
+					r.scopes = append(r.scopes, splitScopes(s)...)
+				}
+			}
+		}
+		t.add(r)
+	}
+	return t, nil
+}
+func parseTmTheme(data []byte) (*theme, error) {
+	root, err := parsePlist(data)
+	if err != nil {
+		return nil, err
+	}
+	dict, _ := root.(map[string]interface{})
+	settings, _ := dict["settings"].([]interface{})
+	if len(settings) == 0 {
+		return nil, errors.New("tmTheme has no settings")
+	}
+	t := &theme{colors: make(map[string]string)}
+	for _, s := range settings {
+		entry, _ := s.(map[string]interface{})
+		values, _ := entry["settings"].(map[string]interface{})
+		get := func(k string) string {
+			v, _ := values[k].(string)
+			return v
+		}
+		scope, _ := entry["scope"].(string)
+		if scope == "" {
+			for k, v := range tmThemeColors {
+				if c := get(k); c != "" && t.colors[v] == "" {
+					t.colors[v] = c
+				}
+			}
+			continue
+		}
+		t.add(themeRule{splitScopes(scope), get("foreground"), get("background"), get("fontStyle")})
+	}
+	return t, nil
+}
+func parseBase16(data []byte) (*theme, error) {
+	var src map[string]interface{}
+	if err := yaml.Unmarshal(data, &src); err != nil {
+		return nil, err
+	}
+	palette := src
+	if p, ok := src["palette"].(map[interface{}]interface{}); ok {
+		palette = make(map[string]interface{})
+		for k, v := range p {
+			palette[fmt.Sprint(k)] = v
+		}
+	}
+	var base [16]string
+	for i := range base {
+		v, ok := palette[fmt.Sprintf("base%02X", i)].(string)
+		if !ok {
+			return nil, errors.New("not a base16 scheme: missing " + fmt.Sprintf("base%02X", i))
+		}
+		base[i] = "#" + strings.TrimPrefix(v, "#")
+	}
+	t := &theme{colors: map[string]string{
+		"editor.background":                   base[0x0],
+		"editor.foreground":                   base[0x5],
+		"editor.selectionBackground":          base[0x2],
+		"editor.lineHighlightBackground":      base[0x1],
+		"editor.findMatchHighlightBackground": base[0xA],
+		"editorGutter.background":             base[0x1],
+		"editorLineNumber.foreground":         base[0x3],
+		"editorLineNumber.activeForeground":   base[0x4],
+		"editorWhitespace.foreground":         base[0x2],
+		"editorError.foreground":              base[0x8],
+		"editorWarning.foreground":            base[0xA],
+		"editorInfo.foreground":               base[0xD],
+		"editorBracketMatch.background":       base[0x3],
+		"editorGutter.addedBackground":        base[0xB],
+		"editorGutter.modifiedBackground":     base[0xE],
+		"editorGutter.deletedBackground":      base[0x8],
+		"statusBar.background":                base[0x2],
+		"statusBar.foreground":                base[0x4],
+		"tab.activeBackground":                base[0x2],
+		"tab.activeForeground":                base[0x6],
+		"editorGroupHeader.tabsBackground":    base[0x1],
+		"tab.inactiveForeground":              base[0x4],
+	}}
+	for _, r := range []struct {
+		scopes string
+		color  int
+	}{
+		{"comment", 0x3},
+		{"string", 0xB},
+		{"constant", 0x9},
+		{"constant.character.escape, string.regexp, support", 0xC},
+		{"keyword, storage", 0xE},
+		{"keyword.operator, punctuation", 0x5},
+		{"entity.name.function, support.function", 0xD},
+		{"entity.name.class, entity.name.type, support.class, support.type, meta.preprocessor", 0xA},
+		{"variable, entity.name.tag, invalid", 0x8},
+		{"markup.underline", 0xD},
+	} {
+		t.add(themeRule{scopes: splitScopes(r.scopes), fg: base[r.color]})
+	}
+	return t, nil
+}
+func (t *theme) add(r themeRule) {
+	if len(r.scopes) == 0 {
+		if t.colors["editor.foreground"] == "" {
+			t.colors["editor.foreground"] = r.fg
+		}
+		if t.colors["editor.background"] == "" {
+			t.colors["editor.background"] = r.bg
+		}
+		return
+	}
+	t.rules = append(t.rules, r)
+}
+func splitScopes(s string) []string {
+	var scopes []string
+	for _, sel := range strings.Split(s, ",") {
+		sel = strings.TrimSpace(sel)
+		if sel == "" || strings.Contains(sel, " ") || strings.HasPrefix(sel, "-") {
+			continue
+		}
+		scopes = append(scopes, sel)
+	}
+	return scopes
+}
+func (t *theme) match(scopes []string) (themeRule, bool) {
+	for _, scope := range scopes {
+		best, bestLen := -1, -1
+		for i, r := range t.rules {
+			for _, sel := range r.scopes {
+				if (scope == sel || strings.HasPrefix(scope, sel+".")) && len(sel) >= bestLen && r.fg != "" {
+					best, bestLen = i, len(sel)
+				}
+			}
+		}
+		if best >= 0 {
+			return t.rules[best], true
+		}
+	}
+	return themeRule{}, false
+}
+func (t *theme) color(keys ...string) string {
+	for _, k := range keys {
+		if c := normalizeColor(t.colors[k], t.colors["editor.background"]); c != "" {
+			return c
+		}
+	}
+	return ""
+}
+func (t *theme) micro() []byte {
+	var b bytes.Buffer
+	link := func(group, style string) {
+		if style != "" && !strings.HasPrefix(style, ",") && !strings.HasSuffix(style, ",") {
+			fmt.Fprintf(&b, "color-link %s \"%s\"\n", group, style)
+		}
+	}
+	fg, bg := t.color("editor.foreground"), t.color("editor.background")
+	pair := func(f, g string) string {
+		if g == "" {
+			return f
+		}
+		return f + "," + g
+	}
+	both := func(f, g string) string {
+		if f == "" || g == "" {
+			return ""
+		}
+		return f + "," + g
+	}
+	link("default", pair(fg, bg))
+	for _, s := range themeScopes {
+		r, ok := t.match(s.scopes)
+		if !ok || normalizeColor(r.fg, bg) == "" {
+			continue
+		}
+		style := pair(normalizeColor(r.fg, bg), normalizeColor(r.bg, bg))
+		if attrs := themeAttrs(r.style); attrs != "" {
+			style = attrs + " " + style
+		}
+		link(s.group, style)
+	}
+	gutter := t.color("editorGutter.background", "editor.background")
+	link("line-number", pair(t.color("editorLineNumber.foreground"), gutter))
+	link("current-line-number", pair(t.color("editorLineNumber.activeForeground", "editor.foreground"), gutter))
+	link("cursor-line", t.color("editor.lineHighlightBackground"))
+	link("color-column", t.color("editorRuler.foreground", "editor.lineHighlightBackground"))
+	if sel := t.color("editor.selectionBackground"); sel != "" {
+		link("selection", both(t.color("editor.selectionForeground", "editor.foreground"), sel))
+	}
+	if hl := t.color("editor.findMatchHighlightBackground", "editor.findMatchBackground"); hl != "" {
+		link("hlsearch", both(fg, hl))
+	}
+	if mb := t.color("editorBracketMatch.background"); mb != "" {
+		link("match-brace", both(fg, mb))
+	}
+	link("statusline", both(t.color("statusBar.foreground", "editor.foreground"), t.color("statusBar.background")))
+	link("tabbar", both(t.color("tab.inactiveForeground", "editor.foreground"), t.color("editorGroupHeader.tabsBackground", "tab.inactiveBackground")))
+	link("tabbar.active", both(t.color("tab.activeForeground"), t.color("tab.activeBackground")))
+	link("indent-char", pair(t.color("editorWhitespace.foreground", "editorIndentGuide.background"), bg))
+	link("gutter-error", pair(t.color("editorError.foreground"), gutter))
+	link("gutter-warning", pair(t.color("editorWarning.foreground"), gutter))
+	link("gutter-info", pair(t.color("editorInfo.foreground"), gutter))
+	link("diff-added", t.color("editorGutter.addedBackground", "gitDecoration.addedResourceForeground"))
+	link("diff-modified", t.color("editorGutter.modifiedBackground", "gitDecoration.modifiedResourceForeground"))
+	link("diff-deleted", t.color("editorGutter.deletedBackground", "gitDecoration.deletedResourceForeground"))
+	link("scrollbar", pair(t.color("scrollbarSlider.background"), bg))
+	link("divider", pair(t.color("editorGroup.border", "panel.border"), bg))
+	return b.Bytes()
+}
+func themeAttrs(fontStyle string) string {
+	var attrs []string
+	for _, a := range []string{"bold", "italic", "underline"} {
+		if strings.Contains(fontStyle, a) {
+			attrs = append(attrs, a)
+		}
+	}
+	return strings.Join(attrs, " ")
+}
+func normalizeColor(c, bg string) string {
+	c = strings.TrimPrefix(strings.TrimSpace(c), "#")
+	if _, err := strconv.ParseUint(c, 16, 32); err != nil {
+		return ""
+	}
+	if len(c) == 3 || len(c) == 4 {
+		long := make([]byte, 0, 2*len(c))
+		for i := range c {
+			long = append(long, c[i], c[i])
+		}
+		c = string(long)
+	}
+	switch len(c) {
+	case 6:
+		return "#" + strings.ToUpper(c)
+	case 8:
+		alpha, _ := strconv.ParseUint(c[6:], 16, 8)
+		base := normalizeColor(bg, "")
+		if base == "" {
+			return "#" + strings.ToUpper(c[:6])
+		}
+		blended := make([]string, 3)
+		for i := range blended {
+			fg, _ := strconv.ParseUint(c[2*i:2*i+2], 16, 8)
+			under, _ := strconv.ParseUint(base[1+2*i:3+2*i], 16, 8)
+			blended[i] = fmt.Sprintf("%02X", (fg*alpha+under*(255-alpha))/255)
+		}
+		return "#" + strings.Join(blended, "")
+	}
+	return ""
+}
+func stripJSONComments(data []byte) []byte {
+	var out []byte
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				return out
+			}
+			i += end + 3
+		case c == '}' || c == ']':
+			trimmed := bytes.TrimRight(out, " \t\r\n")
+			if len(trimmed) > 0 && trimmed[len(trimmed)-1] == ',' {
+				out = append(trimmed[:len(trimmed)-1], out[len(trimmed):]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+func parsePlist(data []byte) (interface{}, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		if se, ok := tok.(xml.StartElement); ok && se.Name.Local != "plist" {
+			return plistValue(d, se)
+		}
+	}
+}
+func plistValue(d *xml.Decoder, se xml.StartElement) (interface{}, error) {
+	switch se.Name.Local {
+	case "dict", "array":
+		dict := make(map[string]interface{})
+		var array []interface{}
+		key := ""
+		for {
+			tok, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				if t.Name.Local == "key" {
+					if err := d.DecodeElement(&key, &t); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				v, err := plistValue(d, t)
+				if err != nil {
+					return nil, err
+				}
+				dict[key] = v
+				array = append(array, v)
+			case xml.EndElement:
+				if se.Name.Local == "dict" {
+					return dict, nil
+				}
+				return array, nil
+			}
+		}
+	case "true", "false":
+		return se.Name.Local == "true", d.Skip()
+	}
+	var s string
+	err := d.DecodeElement(&s, &se)
+	return s, err
+}
\ No newline at end of file
@@ -18,7 +18,11 @@ type BufWindow struct {
 	gutterOffset     int
 	hasMessage       bool
 	maxLineNumLength int
+	blameWidth       int
 	drawDivider      bool
+	diffView       *DiffView
+	diffTopFillers int
+	floating bool
 }
 func NewBufWindow(x, y, width, height int, buf *buffer.Buffer) *BufWindow {
 	w := new(BufWindow)
@@ -30,6 +34,9 @@ func NewBufWindow(x, y, width, height int, buf *buffer.Buffer) *BufWindow {
 	return w
 }
 func (w *BufWindow) SetBuffer(b *buffer.Buffer) {
+	if w.diffView != nil && w.Buf != b {
+		w.diffView.Close()
+	}
 	w.Buf = b
 	b.OptionCallback = func(option string, nativeValue interface{}) {
 		if option == "softwrap" {
@@ -49,6 +56,9 @@ func (w *BufWindow) SetBuffer(b *buffer.Buffer) {
 	b.GetVisualX = func(loc buffer.Loc) int {
 		return w.VLocFromLoc(loc).VisualX
 	}
+	b.GetCharPos = func(y, visualX int) int {
+		return w.charPos(y, visualX)
+	}
 }
 func (w *BufWindow) GetView() *View {
 	return w.View
@@ -80,7 +90,8 @@ func (w *BufWindow) BufView() View {
 func (w *BufWindow) updateDisplayInfo() {
 	b := w.Buf
 	w.drawDivider = false
-	if !b.Settings["statusline"].(bool) {
+	statusline := b.Settings["statusline"].(bool) && !w.floating
+	if !statusline && !w.floating {
 		_, h := screen.Screen.Size()
 		infoY := h
 		if config.GetGlobalOption("infobar").(bool) {
@@ -91,19 +102,24 @@ func (w *BufWindow) updateDisplayInfo() {
 		}
 	}
 	w.bufHeight = w.Height
-	if b.Settings["statusline"].(bool) || w.drawDivider {
+	if statusline || w.drawDivider {
 		w.bufHeight--
 	}
 	scrollbarWidth := 0
 	if w.Buf.Settings["scrollbar"].(bool) && w.Buf.LinesNum() > w.Height && w.Width > 0 {
 		scrollbarWidth = 1
 	}
-	w.hasMessage = len(b.Messages) > 0
+	w.hasMessage = len(b.Messages) > 0 || b.HasSigns()
 	w.maxLineNumLength = len(strconv.Itoa(b.LinesNum()))
 	w.gutterOffset = 0
 	if w.hasMessage {
 		w.gutterOffset += 2
 	}
+	w.blameWidth = 0
+	if b.HasBlame() {
+		w.blameWidth = b.BlameWidth() + 1
+		w.gutterOffset += w.blameWidth
+	}
 	if b.Settings["diffgutter"].(bool) {
 		w.gutterOffset++
 	}
@@ -126,6 +142,7 @@ func (w *BufWindow) getStartInfo(n, lineN int) ([]byte, int, int, *tcell.Style)
 	width := 0
 	bloc := buffer.Loc{0, lineN}
 	b := w.Buf.LineBytes(lineN)
+	decorations := w.Buf.LineDecorations(lineN)
 	curStyle := config.DefStyle
 	var s *tcell.Style
 	for len(b) > 0 {
@@ -134,6 +151,8 @@ func (w *BufWindow) getStartInfo(n, lineN int) ([]byte, int, int, *tcell.Style)
 		if found {
 			s = &curStyle
 		}
+		before := width
+		width += inlineWidth(decorations, bloc)
 		w := 0
 		switch r {
 		case '\t':
@@ -143,7 +162,7 @@ func (w *BufWindow) getStartInfo(n, lineN int) ([]byte, int, int, *tcell.Style)
 			w = runewidth.RuneWidth(r)
 		}
 		if width+w > n {
-			return b, n - width, bloc.X, s
+			return b, n - before, bloc.X, s
 		}
 		width += w
 		b = b[size:]
@@ -167,19 +186,23 @@ func (w *BufWindow) Relocate() bool {
 	c := w.SLocFromLoc(activeC.Loc)
 	bStart := SLoc{0, 0}
 	bEnd := w.SLocFromLoc(b.End())
-	if c.LessThan(w.Scroll(w.StartLine, scrollmargin)) && c.GreaterThan(w.Scroll(bStart, scrollmargin-1)) {
-		w.StartLine = w.Scroll(c, -scrollmargin)
-		ret = true
-	} else if c.LessThan(w.StartLine) {
-		w.StartLine = c
-		ret = true
-	}
-	if c.GreaterThan(w.Scroll(w.StartLine, height-1-scrollmargin)) && c.LessEqual(w.Scroll(bEnd, -scrollmargin)) {
-		w.StartLine = w.Scroll(c, -height+1+scrollmargin)
-		ret = true
-	} else if c.GreaterThan(w.Scroll(bEnd, -scrollmargin)) && c.GreaterThan(w.Scroll(w.StartLine, height-1)) {
-		w.StartLine = w.Scroll(bEnd, -height+1)
-		ret = true
+	if w.diffView != nil {
+		ret = w.relocateDiff()
+	} else {
+		if c.LessThan(w.Scroll(w.StartLine, scrollmargin)) && c.GreaterThan(w.Scroll(bStart, scrollmargin-1)) {
+			w.StartLine = w.Scroll(c, -scrollmargin)
+			ret = true
+		} else if c.LessThan(w.StartLine) {
+			w.StartLine = c
+			ret = true
+		}
+		if c.GreaterThan(w.Scroll(w.StartLine, height-1-scrollmargin)) && c.LessEqual(w.Scroll(bEnd, -scrollmargin)) {
+			w.StartLine = w.Scroll(c, -height+1+scrollmargin)
+			ret = true
+		} else if c.GreaterThan(w.Scroll(bEnd, -scrollmargin)) && c.GreaterThan(w.Scroll(w.StartLine, height-1)) {
+			w.StartLine = w.Scroll(bEnd, -height+1)
+			ret = true
+		}
 	}
 	if !b.Settings["softwrap"].(bool) {
 		cx := activeC.GetVisualX()
@@ -207,20 +230,53 @@ func (w *BufWindow) LocFromVisual(svloc buffer.Loc) buffer.Loc {
 		SLoc:    w.Scroll(w.StartLine, svloc.Y-w.Y),
 		VisualX: vx + w.StartCol,
 	}
+	if w.diffView != nil {
+		vloc.SLoc = SLoc{w.diffLineAtVisual(svloc.Y - w.Y), 0}
+	}
 	return w.LocFromVLoc(vloc)
 }
+func (w *BufWindow) VisualFromLoc(loc buffer.Loc) (buffer.Loc, bool) {
+	vloc := w.VLocFromLoc(loc)
+	x := vloc.VisualX - w.StartCol
+	y := w.Diff(w.StartLine, vloc.SLoc)
+	visible := x >= 0 && x < w.bufWidth && y >= 0 && y < w.bufHeight
+	return buffer.Loc{w.X + w.gutterOffset + x, w.Y + y}, visible
+}
 func (w *BufWindow) drawGutter(vloc *buffer.Loc, bloc *buffer.Loc) {
-	char := ' '
+	text := []rune{' ', ' '}
 	s := config.DefStyle
+	priority, found := 0, false
 	for _, m := range w.Buf.Messages {
 		if m.Start.Y == bloc.Y || m.End.Y == bloc.Y {
 			s = m.Style()
-			char = '>'
+			text = []rune{'>', '>'}
+			priority, found = buffer.MessagePriority, true
 			break
 		}
 	}
+	for _, d := range w.Buf.LineDecorations(bloc.Y) {
+		if d.Kind == buffer.DKSign && d.Start.Y == bloc.Y && (!found || d.Priority > priority) {
+			s = d.GetStyle()
+			text = append([]rune(d.Text), ' ', ' ')
+			priority, found = d.Priority, true
+		}
+	}
 	for i := 0; i < 2 && vloc.X < w.gutterOffset; i++ {
-		screen.SetContent(w.X+vloc.X, w.Y+vloc.Y, char, nil, s)
+		screen.SetContent(w.X+vloc.X, w.Y+vloc.Y, text[i], nil, s)
+		vloc.X++
+	}
+}
+func (w *BufWindow) drawBlameGutter(style tcell.Style, softwrapped bool, vloc *buffer.Loc, bloc *buffer.Loc) {
+	var text []rune
+	if !softwrapped {
+		text = []rune(w.Buf.Blame(bloc.Y))
+	}
+	for i := 0; i < w.blameWidth && vloc.X < w.gutterOffset; i++ {
+		r := ' '
+		if i < len(text) {
+			r = text[i]
+		}
+		screen.SetContent(w.X+vloc.X, w.Y+vloc.Y, r, nil, style)
 		vloc.X++
 	}
 }
@@ -307,6 +363,10 @@ func (w *BufWindow) displayBuffer() {
 				}
 			})
 		}
+		if b.HasBlame() {
+			b.UpdateBlame()
+		}
+		b.UpdateConflicts()
 		b.ModifiedThisFrame = false
 	}
 	var matchingBraces []buffer.Loc
@@ -357,9 +417,17 @@ func (w *BufWindow) displayBuffer() {
 		vloc.Y = -w.StartLine.Row
 	}
 	bloc := buffer.Loc{X: -1, Y: w.StartLine.Line}
+	b.SetVisibleLines(w.StartLine.Line, w.StartLine.Line+w.bufHeight)
 	cursors := b.GetCursors()
 	curStyle := config.DefStyle
 	for ; vloc.Y < w.bufHeight; vloc.Y++ {
+		if w.diffView != nil {
+			vloc.Y = w.drawDiffFillers(vloc.Y, bloc.Y, bloc.Y == w.StartLine.Line)
+			if vloc.Y >= w.bufHeight {
+				break
+			}
+		}
+		conflictBg, conflicted := w.conflictBackground(bloc.Y)
 		vloc.X = 0
 		currentLine := false
 		for _, c := range cursors {
@@ -376,6 +444,9 @@ func (w *BufWindow) displayBuffer() {
 			if w.hasMessage {
 				w.drawGutter(&vloc, &bloc)
 			}
+			if w.blameWidth > 0 {
+				w.drawBlameGutter(lineNumStyle, false, &vloc, &bloc)
+			}
 			if b.Settings["diffgutter"].(bool) {
 				w.drawDiffGutter(s, false, &vloc, &bloc)
 			}
@@ -389,6 +460,7 @@ func (w *BufWindow) displayBuffer() {
 		blineLen := util.CharacterCount(bline)
 		leadingwsEnd := len(util.GetLeadingWhitespace(bline))
 		trailingwsStart := blineLen - util.CharacterCount(util.GetTrailingWhitespace(bline))
+		decorations := b.LineDecorations(bloc.Y)
 		line, nColsBeforeStart, bslice, startStyle := w.getStartInfo(w.StartCol, bloc.Y)
 		if startStyle != nil {
 			curStyle = *startStyle
@@ -397,6 +469,11 @@ func (w *BufWindow) displayBuffer() {
 		draw := func(r rune, combc []rune, style tcell.Style, highlight bool, showcursor bool) {
 			if nColsBeforeStart <= 0 && vloc.Y >= 0 {
 				if highlight {
+					for _, d := range decorations {
+						if d.Kind == buffer.DKHighlight && bloc.GreaterEqual(d.Start) && bloc.LessThan(d.End) {
+							style = d.Merge(style)
+						}
+					}
 					if w.Buf.HighlightSearch && w.Buf.SearchMatch(bloc) {
 						style = config.DefStyle.Reverse(true)
 						if s, ok := config.Colorscheme["hlsearch"]; ok {
@@ -435,6 +512,15 @@ func (w *BufWindow) displayBuffer() {
 							}
 						}
 					}
+					if w.diffView != nil {
+						var diffed bool
+						style, diffed = w.diffStyle(style, bloc, false)
+						dontOverrideBackground = dontOverrideBackground || diffed
+					}
+					if conflicted {
+						style = style.Background(conflictBg)
+						dontOverrideBackground = true
+					}
 					for _, c := range cursors {
 						if c.HasSelection() &&
 							(bloc.GreaterEqual(c.CurSelection[0]) && bloc.LessThan(c.CurSelection[1]) ||
@@ -509,6 +595,9 @@ func (w *BufWindow) displayBuffer() {
 			if w.hasMessage {
 				w.drawGutter(&vloc, &bloc)
 			}
+			if w.blameWidth > 0 {
+				w.drawBlameGutter(lineNumStyle, true, &vloc, &bloc)
+			}
 			if b.Settings["diffgutter"].(bool) {
 				w.drawDiffGutter(lineNumStyle, true, &vloc, &bloc)
 			}
@@ -517,10 +606,11 @@ func (w *BufWindow) displayBuffer() {
 			}
 		}
 		type glyph struct {
-			r     rune
-			combc []rune
-			style tcell.Style
-			width int
+			r       rune
+			combc   []rune
+			style   tcell.Style
+			width   int
+			virtual bool
 		}
 		var word []glyph
 		if wordwrap {
@@ -529,11 +619,26 @@ func (w *BufWindow) displayBuffer() {
 			word = make([]glyph, 0, 1)
 		}
 		wordwidth := 0
+		wordlen := 0
 		totalwidth := w.StartCol - nColsBeforeStart
+		virtualText := func(x int) {
+			for _, d := range decorations {
+				if isInlineText(d, buffer.Loc{X: x, Y: bloc.Y}) {
+					style := d.GetStyle()
+					for _, r := range d.Text {
+						width := runewidth.RuneWidth(r)
+						word = append(word, glyph{r, nil, style, width, true})
+						wordwidth += width
+						totalwidth += width
+					}
+				}
+			}
+		}
 		for len(line) > 0 && vloc.X < maxWidth {
 			r, combc, size := util.DecodeCharacter(line)
 			line = line[size:]
-			loc := buffer.Loc{X: bloc.X + len(word), Y: bloc.Y}
+			loc := buffer.Loc{X: bloc.X + wordlen, Y: bloc.Y}
+			virtualText(loc.X)
 			curStyle, _ = w.getStyle(curStyle, loc)
 			width := 0
 			switch r {
@@ -545,7 +650,8 @@ func (w *BufWindow) displayBuffer() {
 				width = runewidth.RuneWidth(r)
 				totalwidth += width
 			}
-			word = append(word, glyph{r, combc, curStyle, width})
+			word = append(word, glyph{r, combc, curStyle, width, false})
+			wordlen++
 			wordwidth += width
 			if wordwrap {
 				if !util.IsWhitespace(r) && len(line) > 0 && wordwidth < w.bufWidth {
@@ -567,6 +673,12 @@ func (w *BufWindow) displayBuffer() {
 				}
 			}
 			for _, r := range word {
+				if r.virtual {
+					if vloc.X < maxWidth {
+						draw(r.r, nil, r.style, false, false)
+					}
+					continue
+				}
 				draw(r.r, r.combc, r.style, true, true)
 				if r.width > 1 {
 					char := ' '
@@ -581,6 +693,7 @@ func (w *BufWindow) displayBuffer() {
 			}
 			word = word[:0]
 			wordwidth = 0
+			wordlen = 0
 			if vloc.X >= maxWidth {
 				if !softwrap {
 					break
@@ -593,6 +706,16 @@ func (w *BufWindow) displayBuffer() {
 				}
 			}
 		}
+		if len(line) == 0 && bloc.X == blineLen {
+			virtualText(bloc.X)
+			for _, r := range word {
+				if vloc.X+r.width > maxWidth {
+					break
+				}
+				draw(r.r, nil, r.style, false, false)
+			}
+			word = word[:0]
+		}
 		style := config.DefStyle
 		for _, c := range cursors {
 			if b.Settings["cursorline"].(bool) && w.active &&
@@ -603,6 +726,12 @@ func (w *BufWindow) displayBuffer() {
 				}
 			}
 		}
+		if w.diffView != nil {
+			style, _ = w.diffStyle(style, bloc, true)
+		}
+		if conflicted {
+			style = style.Background(conflictBg)
+		}
 		for i := vloc.X; i < maxWidth; i++ {
 			curStyle := style
 			if s, ok := config.Colorscheme["color-column"]; ok {
@@ -616,14 +745,37 @@ func (w *BufWindow) displayBuffer() {
 		if vloc.X != maxWidth {
 			draw(' ', nil, config.DefStyle, true, true)
 		}
+		if vloc.Y >= 0 && vloc.Y < w.bufHeight {
+			x := vloc.X + 1
+			for _, d := range decorations {
+				if d.Kind != buffer.DKVirtualText || d.Start.Y != bloc.Y {
+					continue
+				}
+				style := d.GetStyle()
+				for _, r := range d.Text {
+					if x+runewidth.RuneWidth(r) > maxWidth {
+						break
+					}
+					screen.SetContent(w.X+x, w.Y+vloc.Y, r, nil, style)
+					x += runewidth.RuneWidth(r)
+				}
+				x++
+			}
+		}
 		bloc.X = w.StartCol
 		bloc.Y++
 		if bloc.Y >= b.LinesNum() {
+			if w.diffView != nil {
+				w.drawDiffFillers(vloc.Y+1, bloc.Y, false)
+			}
 			break
 		}
 	}
 }
 func (w *BufWindow) displayStatusLine() {
+	if w.floating {
+		return
+	}
 	if w.Buf.Settings["statusline"].(bool) {
 		w.sline.Display()
 	} else if w.drawDivider {
@@ -669,6 +821,10 @@ func (w *BufWindow) displayScrollBar() {
 	}
 }
 func (w *BufWindow) Display() {
+	if w.diffView != nil {
+		w.syncDiff()
+	}
+	w.Buf.InvalidateDecorations()
 	w.updateDisplayInfo()
 	w.displayStatusLine()
 	w.displayScrollBar()
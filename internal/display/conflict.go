@@ -0,0 +1,23 @@
+package display
+import (
+	"github.com/zyedidia/micro/v2/internal/config"
+	"github.com/zyedidia/tcell/v2"
+)
+var conflictGroups = map[string][2]string{
+	"ours":   {"conflict-ours", "diff-added"},
+	"base":   {"conflict-base", "diff-deleted"},
+	"theirs": {"conflict-theirs", "diff-modified"},
+}
+func (w *BufWindow) conflictBackground(lineN int) (tcell.Color, bool) {
+	c, ok := w.Buf.ConflictAt(lineN)
+	if !ok {
+		return 0, false
+	}
+	for _, group := range conflictGroups[c.Section(lineN)] {
+		if s, ok := config.Colorscheme[group]; ok {
+			fg, _, _ := s.Decompose()
+			return fg, true
+		}
+	}
+	return 0, false
+}
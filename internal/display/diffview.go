@@ -0,0 +1,347 @@
+package display
+import (
+	"unicode/utf8"
+	dmp "github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/zyedidia/micro/v2/internal/buffer"
+	"github.com/zyedidia/micro/v2/internal/config"
+	"github.com/zyedidia/micro/v2/internal/screen"
+	"github.com/zyedidia/micro/v2/internal/util"
+	"github.com/zyedidia/tcell/v2"
+)
+type DiffRow struct {
+	Lines   [2]int
+	Changes [2][][2]int
+}
+func (r *DiffRow) Equal() bool {
+	return r.Lines[0] >= 0 && r.Lines[1] >= 0 && r.Changes[0] == nil && r.Changes[1] == nil
+}
+type DiffView struct {
+	Windows [2]*BufWindow
+	Rows    []DiffRow
+	rowOf   [2][]int
+	leader  int
+	dirty   bool
+	wrapped [2]bool
+}
+func NewDiffView(a, b *BufWindow) *DiffView {
+	d := &DiffView{Windows: [2]*BufWindow{a, b}, dirty: true}
+	for i, w := range d.Windows {
+		if w.diffView != nil {
+			w.diffView.Close()
+		}
+		w.diffView = d
+		w.diffTopFillers = 0
+		if w.Buf.Settings["softwrap"].(bool) {
+			d.wrapped[i] = true
+			w.Buf.SetOptionNative("softwrap", false)
+		}
+		if w.active {
+			d.leader = i
+		}
+	}
+	d.Update()
+	return d
+}
+func (d *DiffView) Close() {
+	for i, w := range d.Windows {
+		if w.diffView == d {
+			w.diffView = nil
+			w.diffTopFillers = 0
+			if d.wrapped[i] {
+				w.Buf.SetOptionNative("softwrap", true)
+			}
+		}
+	}
+}
+func (d *DiffView) Side(w *BufWindow) int {
+	if d.Windows[1] == w {
+		return 1
+	}
+	return 0
+}
+func (d *DiffView) Invalidate() {
+	d.dirty = true
+}
+func diffLineRunes(b *buffer.Buffer, ids map[string]rune) []rune {
+	runes := make([]rune, b.LinesNum())
+	for i := range runes {
+		l := string(b.LineBytes(i))
+		id, ok := ids[l]
+		if !ok {
+			id = rune(len(ids) + 1)
+			if id >= 0xD800 {
+				id += 0x800
+			}
+			ids[l] = id
+		}
+		runes[i] = id
+	}
+	return runes
+}
+func diffChanges(a, b string) [2][][2]int {
+	var changes [2][][2]int
+	differ := dmp.New()
+	diffs := differ.DiffCleanupSemantic(differ.DiffMain(a, b, false))
+	var pos [2]int
+	for _, df := range diffs {
+		n := util.CharacterCountInString(df.Text)
+		switch df.Type {
+		case dmp.DiffEqual:
+			pos[0] += n
+			pos[1] += n
+		case dmp.DiffDelete:
+			changes[0] = append(changes[0], [2]int{pos[0], pos[0] + n})
+			pos[0] += n
+		case dmp.DiffInsert:
+			changes[1] = append(changes[1], [2]int{pos[1], pos[1] + n})
+			pos[1] += n
+		}
+	}
+	for i := range changes {
+		if changes[i] == nil {
+			changes[i] = [][2]int{}
+		}
+	}
+	return changes
+}
+func (d *DiffView) Update() {
+	bufs := [2]*buffer.Buffer{d.Windows[0].Buf, d.Windows[1].Buf}
+	ids := make(map[string]rune)
+	a := diffLineRunes(bufs[0], ids)
+	b := diffLineRunes(bufs[1], ids)
+	d.Rows = d.Rows[:0]
+	var pending [2][]int
+	flush := func() {
+		n := util.Max(len(pending[0]), len(pending[1]))
+		for i := 0; i < n; i++ {
+			row := DiffRow{Lines: [2]int{-1, -1}}
+			for s := range pending {
+				if i < len(pending[s]) {
+					row.Lines[s] = pending[s][i]
+				}
+			}
+			if row.Lines[0] >= 0 && row.Lines[1] >= 0 {
+				row.Changes = diffChanges(string(bufs[0].LineBytes(row.Lines[0])), string(bufs[1].LineBytes(row.Lines[1])))
+			}
+			d.Rows = append(d.Rows, row)
+		}
+		pending[0], pending[1] = nil, nil
+	}
+	var line [2]int
+	for _, df := range dmp.New().DiffMainRunes(a, b, false) {
+		n := utf8.RuneCountInString(df.Text)
+		switch df.Type {
+		case dmp.DiffEqual:
+			flush()
+			for i := 0; i < n; i++ {
+				d.Rows = append(d.Rows, DiffRow{Lines: line})
+				line[0]++
+				line[1]++
+			}
+		case dmp.DiffDelete:
+			for i := 0; i < n; i++ {
+				pending[0] = append(pending[0], line[0])
+				line[0]++
+			}
+		case dmp.DiffInsert:
+			for i := 0; i < n; i++ {
+				pending[1] = append(pending[1], line[1])
+				line[1]++
+			}
+		}
+	}
+	flush()
+	for s := range d.rowOf {
+		d.rowOf[s] = make([]int, bufs[s].LinesNum()+1)
+		d.rowOf[s][bufs[s].LinesNum()] = len(d.Rows)
+	}
+	for i, r := range d.Rows {
+		for s := range r.Lines {
+			if r.Lines[s] >= 0 && r.Lines[s] < len(d.rowOf[s]) {
+				d.rowOf[s][r.Lines[s]] = i
+			}
+		}
+	}
+	d.dirty = false
+}
+func (d *DiffView) RowOf(side, line int) int {
+	rows := d.rowOf[side]
+	return rows[util.Clamp(line, 0, len(rows)-1)]
+}
+func (d *DiffView) LineAt(side, row int) int {
+	for ; row < len(d.Rows); row++ {
+		if l := d.Rows[row].Lines[side]; l >= 0 {
+			return l
+		}
+	}
+	return d.Windows[side].Buf.LinesNum() - 1
+}
+func (d *DiffView) Hunks() [][2]int {
+	var hunks [][2]int
+	start := -1
+	for i := range d.Rows {
+		if !d.Rows[i].Equal() {
+			if start < 0 {
+				start = i
+			}
+		} else if start >= 0 {
+			hunks = append(hunks, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		hunks = append(hunks, [2]int{start, len(d.Rows)})
+	}
+	return hunks
+}
+func (d *DiffView) HunkAt(side, row int) ([2]int, bool) {
+	hunks := d.Hunks()
+	for _, h := range hunks {
+		if row >= h[0] && row < h[1] {
+			return h, true
+		}
+	}
+	for _, h := range hunks {
+		if s, e := d.HunkLines(h, side); s == e && (h[1] == row || h[0] == row+1) {
+			return h, true
+		}
+	}
+	return [2]int{}, false
+}
+func (d *DiffView) HunkLines(h [2]int, side int) (int, int) {
+	start, end := -1, -1
+	for _, r := range d.Rows[h[0]:h[1]] {
+		if l := r.Lines[side]; l >= 0 {
+			if start < 0 {
+				start = l
+			}
+			end = l + 1
+		}
+	}
+	if start < 0 {
+		start = d.LineAt(side, h[1])
+		if h[1] >= len(d.Rows) {
+			start = d.Windows[side].Buf.LinesNum()
+		}
+		end = start
+	}
+	return start, end
+}
+func (d *DiffView) check() {
+	for i, w := range d.Windows {
+		if w.Buf.ModifiedThisFrame {
+			d.dirty = true
+		}
+		if w.active {
+			d.leader = i
+		}
+	}
+	if d.dirty {
+		d.Update()
+	}
+}
+func (w *BufWindow) DiffView() *DiffView {
+	return w.diffView
+}
+func (w *BufWindow) diffSide() int {
+	return w.diffView.Side(w)
+}
+func (w *BufWindow) fillersAbove(line int) int {
+	d, side := w.diffView, w.diffSide()
+	row := d.RowOf(side, line)
+	n := 0
+	for row-n-1 >= 0 && d.Rows[row-n-1].Lines[side] < 0 {
+		n++
+	}
+	return n
+}
+func (w *BufWindow) DiffTop() int {
+	w.diffTopFillers = util.Clamp(w.diffTopFillers, 0, w.fillersAbove(w.StartLine.Line))
+	return w.diffView.RowOf(w.diffSide(), w.StartLine.Line) - w.diffTopFillers
+}
+func (w *BufWindow) SetDiffTop(row int) {
+	d, side := w.diffView, w.diffSide()
+	row = util.Clamp(row, 0, util.Max(len(d.Rows)-1, 0))
+	w.StartLine = SLoc{d.LineAt(side, row), 0}
+	w.diffTopFillers = util.Max(d.RowOf(side, w.StartLine.Line)-row, 0)
+}
+func (w *BufWindow) syncDiff() {
+	d := w.diffView
+	d.check()
+	leader := d.Windows[d.leader]
+	if leader != w {
+		w.SetDiffTop(leader.DiffTop())
+		w.StartCol = leader.StartCol
+	}
+}
+func (w *BufWindow) relocateDiff() bool {
+	d := w.diffView
+	d.check()
+	height := w.bufHeight
+	scrollmargin := util.Min(int(w.Buf.Settings["scrollmargin"].(float64)), (height-1)/2)
+	top := w.DiffTop()
+	cur := d.RowOf(w.diffSide(), w.Buf.GetActiveCursor().Y)
+	if cur < top+scrollmargin {
+		w.SetDiffTop(util.Max(cur-scrollmargin, 0))
+		return true
+	}
+	if cur > top+height-1-scrollmargin {
+		w.SetDiffTop(cur - height + 1 + scrollmargin)
+		return true
+	}
+	return false
+}
+func (w *BufWindow) diffLineAtVisual(y int) int {
+	return w.diffView.LineAt(w.diffSide(), w.DiffTop()+y)
+}
+func (w *BufWindow) diffStyle(style tcell.Style, bloc buffer.Loc, eol bool) (tcell.Style, bool) {
+	d, side := w.diffView, w.diffSide()
+	if bloc.Y >= len(d.rowOf[side])-1 {
+		return style, false
+	}
+	row := d.Rows[d.RowOf(side, bloc.Y)]
+	group := ""
+	if row.Lines[1-side] < 0 {
+		group = "diff-added"
+	} else if !eol {
+		for _, c := range row.Changes[side] {
+			if bloc.X >= c[0] && bloc.X < c[1] {
+				group = "diff-modified"
+				break
+			}
+		}
+	}
+	if s, ok := config.Colorscheme[group]; ok {
+		fg, _, _ := s.Decompose()
+		return style.Background(fg), true
+	}
+	return style, false
+}
+func (w *BufWindow) drawDiffFillers(y, line int, first bool) int {
+	n := w.fillersAbove(line)
+	if line >= w.Buf.LinesNum() {
+		n = len(w.diffView.Rows) - w.diffView.RowOf(w.diffSide(), w.Buf.LinesNum()-1) - 1
+	}
+	if first {
+		n = w.diffTopFillers
+	}
+	style := config.DefStyle
+	if s, ok := config.Colorscheme["diff-deleted"]; ok {
+		fg, _, _ := s.Decompose()
+		style = style.Foreground(fg)
+	}
+	lineNumStyle := config.DefStyle
+	if s, ok := config.Colorscheme["line-number"]; ok {
+		lineNumStyle = s
+	}
+	for ; n > 0 && y < w.bufHeight; n-- {
+		for x := 0; x < w.gutterOffset; x++ {
+			screen.SetContent(w.X+x, w.Y+y, ' ', nil, lineNumStyle)
+		}
+		for x := w.gutterOffset; x < w.gutterOffset+w.bufWidth; x++ {
+			screen.SetContent(w.X+x, w.Y+y, '-', nil, style)
+		}
+		y++
+	}
+	return y
+}
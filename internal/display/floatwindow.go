@@ -0,0 +1,120 @@
+package display
+import (
+	runewidth "github.com/mattn/go-runewidth"
+	"github.com/zyedidia/micro/v2/internal/buffer"
+	"github.com/zyedidia/micro/v2/internal/config"
+	"github.com/zyedidia/micro/v2/internal/screen"
+	"github.com/zyedidia/micro/v2/internal/util"
+)
+type FloatWindow struct {
+	*BufWindow
+	Anchor    *BufWindow
+	AnchorLoc buffer.Loc
+	Row, Col  int
+	Border    bool
+	Title     string
+	Z         int
+	Focusable bool
+	Transient bool
+	width, height int
+	hidden        bool
+}
+func NewFloatWindow(buf *buffer.Buffer, width, height int) *FloatWindow {
+	w := new(FloatWindow)
+	w.BufWindow = NewBufWindow(0, 0, width, height, buf)
+	w.BufWindow.floating = true
+	w.Border = true
+	w.Focusable = true
+	w.width, w.height = width, height
+	return w
+}
+func (w *FloatWindow) SetSize(width, height int) {
+	w.width, w.height = width, height
+}
+func (w *FloatWindow) Size() (int, int) {
+	return w.width, w.height
+}
+func (w *FloatWindow) MoveTo(x, y int) {
+	w.Anchor = nil
+	w.Col, w.Row = x, y
+}
+func (w *FloatWindow) AnchorTo(win *BufWindow, loc buffer.Loc, row, col int) {
+	w.Anchor, w.AnchorLoc = win, loc
+	w.Row, w.Col = row, col
+}
+func (w *FloatWindow) border() int {
+	if w.Border {
+		return 1
+	}
+	return 0
+}
+func (w *FloatWindow) Place(x, y, width, height int) {
+	border := w.border()
+	fw := util.Min(w.width+2*border, width)
+	fh := util.Min(w.height+2*border, height)
+	fx, fy := x+w.Col, y+w.Row
+	w.hidden = fw <= 2*border || fh <= 2*border
+	if w.Anchor != nil {
+		loc, visible := w.Anchor.VisualFromLoc(w.AnchorLoc)
+		w.hidden = w.hidden || !visible
+		fx, fy = loc.X+w.Col-border, loc.Y+w.Row
+		if w.Row > 0 && fy+fh > y+height && loc.Y-fh >= y {
+			fy = loc.Y - fh
+		}
+	}
+	fx = util.Clamp(fx, x, x+width-fw)
+	fy = util.Clamp(fy, y, y+height-fh)
+	w.X, w.Y = fx+border, fy+border
+	if w.Width != fw-2*border || w.Height != fh-2*border {
+		w.BufWindow.Resize(fw-2*border, fh-2*border)
+	}
+}
+func (w *FloatWindow) IsHidden() bool {
+	return w.hidden
+}
+func (w *FloatWindow) Contains(x, y int) bool {
+	border := w.border()
+	return !w.hidden && x >= w.X-border && x < w.X+w.Width+border && y >= w.Y-border && y < w.Y+w.Height+border
+}
+func (w *FloatWindow) drawBorder() {
+	style := config.DefStyle
+	if s, ok := config.Colorscheme["float-border"]; ok {
+		style = s
+	}
+	left, right := w.X-1, w.X+w.Width
+	top, bottom := w.Y-1, w.Y+w.Height
+	for x := left + 1; x < right; x++ {
+		screen.SetContent(x, top, '─', nil, style)
+		screen.SetContent(x, bottom, '─', nil, style)
+	}
+	for y := top + 1; y < bottom; y++ {
+		screen.SetContent(left, y, '│', nil, style)
+		screen.SetContent(right, y, '│', nil, style)
+	}
+	screen.SetContent(left, top, '┌', nil, style)
+	screen.SetContent(right, top, '┐', nil, style)
+	screen.SetContent(left, bottom, '└', nil, style)
+	screen.SetContent(right, bottom, '┘', nil, style)
+	if w.Title == "" {
+		return
+	}
+	x := left + 2
+	for _, r := range " " + w.Title + " " {
+		rw := runewidth.RuneWidth(r)
+		if x+rw > right-1 {
+			break
+		}
+		screen.SetContent(x, top, r, nil, style)
+		x += rw
+	}
+}
+func (w *FloatWindow) Display() {
+	if w.hidden {
+		return
+	}
+	if w.Border {
+		w.drawBorder()
+	}
+	w.Clear()
+	w.BufWindow.Display()
+}
\ No newline at end of file
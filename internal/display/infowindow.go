@@ -113,6 +113,9 @@ func (i *InfoWindow) displayBuffer() {
 		curVX := vlocX
 		curBX := blocX
 		r, combc, size := util.DecodeCharacter(line)
+		if i.Masked {
+			r, combc = '*', nil
+		}
 		draw(r, combc, i.defStyle())
 		width := 0
 		char := ' '
@@ -48,24 +48,94 @@ type SoftWrap interface {
 	VLocFromLoc(loc buffer.Loc) VLoc
 	LocFromVLoc(vloc VLoc) buffer.Loc
 }
+func isInlineText(d *buffer.Decoration, loc buffer.Loc) bool {
+	return d.Kind == buffer.DKInlineText && d.Start == loc
+}
+func inlineWidth(decorations []*buffer.Decoration, loc buffer.Loc) int {
+	width := 0
+	for _, d := range decorations {
+		if isInlineText(d, loc) {
+			width += runewidth.StringWidth(d.Text)
+		}
+	}
+	return width
+}
+func (w *BufWindow) visualX(loc buffer.Loc) int {
+	decorations := w.Buf.LineDecorations(loc.Y)
+	tabsize := util.IntOpt(w.Buf.Settings["tabsize"])
+	if len(decorations) == 0 {
+		return util.StringWidth(w.Buf.LineBytes(loc.Y), loc.X, tabsize)
+	}
+	line := w.Buf.LineBytes(loc.Y)
+	width := 0
+	for x := 0; ; x++ {
+		width += inlineWidth(decorations, buffer.Loc{X: x, Y: loc.Y})
+		if x >= loc.X || len(line) == 0 {
+			return width
+		}
+		r, _, size := util.DecodeCharacter(line)
+		line = line[size:]
+		switch r {
+		case '\t':
+			width += tabsize - (width % tabsize)
+		default:
+			width += runewidth.RuneWidth(r)
+		}
+	}
+}
+func (w *BufWindow) charPos(y, visualX int) int {
+	decorations := w.Buf.LineDecorations(y)
+	tabsize := util.IntOpt(w.Buf.Settings["tabsize"])
+	if len(decorations) == 0 {
+		return util.GetCharPosInLine(w.Buf.LineBytes(y), visualX, tabsize)
+	}
+	line := w.Buf.LineBytes(y)
+	x, width := 0, 0
+	for len(line) > 0 {
+		r, _, size := util.DecodeCharacter(line)
+		line = line[size:]
+		width += inlineWidth(decorations, buffer.Loc{X: x, Y: y})
+		switch r {
+		case '\t':
+			width += tabsize - (width % tabsize)
+		default:
+			width += runewidth.RuneWidth(r)
+		}
+		if width >= visualX {
+			if width == visualX {
+				x++
+			}
+			break
+		}
+		x++
+	}
+	return x
+}
 func (w *BufWindow) getVLocFromLoc(loc buffer.Loc) VLoc {
 	vloc := VLoc{SLoc: SLoc{loc.Y, 0}, VisualX: 0}
-	if loc.X <= 0 {
+	if w.bufWidth <= 0 {
 		return vloc
 	}
-	if w.bufWidth <= 0 {
+	decorations := w.Buf.LineDecorations(loc.Y)
+	if loc.X <= 0 {
+		vloc.VisualX = util.Min(inlineWidth(decorations, buffer.Loc{X: 0, Y: loc.Y}), w.bufWidth-1)
 		return vloc
 	}
 	wordwrap := w.Buf.Settings["wordwrap"].(bool)
 	tabsize := util.IntOpt(w.Buf.Settings["tabsize"])
 	line := w.Buf.LineBytes(loc.Y)
 	x := 0
+	i := 0
 	totalwidth := 0
 	wordwidth := 0
 	wordoffset := 0
 	for len(line) > 0 {
 		r, _, size := util.DecodeCharacter(line)
 		line = line[size:]
+		iw := inlineWidth(decorations, buffer.Loc{X: i, Y: loc.Y})
+		i++
+		totalwidth += iw
+		wordwidth += iw
 		width := 0
 		switch r {
 		case '\t':
@@ -80,8 +150,10 @@ func (w *BufWindow) getVLocFromLoc(loc buffer.Loc) VLoc {
 		if wordwrap {
 			if !util.IsWhitespace(r) && len(line) > 0 && wordwidth < w.bufWidth {
 				if x < loc.X {
-					wordoffset += width
+					wordoffset += iw + width
 					x++
+				} else if i-1 == loc.X {
+					wordoffset += iw
 				}
 				continue
 			}
@@ -91,6 +163,9 @@ func (w *BufWindow) getVLocFromLoc(loc buffer.Loc) VLoc {
 			vloc.VisualX = 0
 		}
 		if x == loc.X {
+			if i-1 == loc.X {
+				wordoffset += iw
+			}
 			vloc.VisualX += wordoffset
 			return vloc
 		}
@@ -103,6 +178,9 @@ func (w *BufWindow) getVLocFromLoc(loc buffer.Loc) VLoc {
 			vloc.VisualX = 0
 		}
 	}
+	if iw := inlineWidth(decorations, buffer.Loc{X: i, Y: loc.Y}); iw > 0 {
+		vloc.VisualX = util.Min(vloc.VisualX+iw, w.bufWidth-1)
+	}
 	return vloc
 }
 func (w *BufWindow) getLocFromVLoc(svloc VLoc) buffer.Loc {
@@ -113,7 +191,9 @@ func (w *BufWindow) getLocFromVLoc(svloc VLoc) buffer.Loc {
 	wordwrap := w.Buf.Settings["wordwrap"].(bool)
 	tabsize := util.IntOpt(w.Buf.Settings["tabsize"])
 	line := w.Buf.LineBytes(svloc.Line)
+	decorations := w.Buf.LineDecorations(svloc.Line)
 	vloc := VLoc{SLoc: SLoc{svloc.Line, 0}, VisualX: 0}
+	i := 0
 	totalwidth := 0
 	var widths []int
 	if wordwrap {
@@ -125,6 +205,9 @@ func (w *BufWindow) getLocFromVLoc(svloc VLoc) buffer.Loc {
 	for len(line) > 0 {
 		r, _, size := util.DecodeCharacter(line)
 		line = line[size:]
+		iw := inlineWidth(decorations, buffer.Loc{X: i, Y: svloc.Line})
+		i++
+		totalwidth += iw
 		width := 0
 		switch r {
 		case '\t':
@@ -135,6 +218,7 @@ func (w *BufWindow) getLocFromVLoc(svloc VLoc) buffer.Loc {
 			width = runewidth.RuneWidth(r)
 			totalwidth += width
 		}
+		width += iw
 		widths = append(widths, width)
 		wordwidth += width
 		if wordwrap {
@@ -255,17 +339,13 @@ func (w *BufWindow) SLocFromLoc(loc buffer.Loc) SLoc {
 }
 func (w *BufWindow) VLocFromLoc(loc buffer.Loc) VLoc {
 	if !w.Buf.Settings["softwrap"].(bool) {
-		tabsize := util.IntOpt(w.Buf.Settings["tabsize"])
-		visualx := util.StringWidth(w.Buf.LineBytes(loc.Y), loc.X, tabsize)
-		return VLoc{SLoc{loc.Y, 0}, visualx}
+		return VLoc{SLoc{loc.Y, 0}, w.visualX(loc)}
 	}
 	return w.getVLocFromLoc(loc)
 }
 func (w *BufWindow) LocFromVLoc(vloc VLoc) buffer.Loc {
 	if !w.Buf.Settings["softwrap"].(bool) {
-		tabsize := util.IntOpt(w.Buf.Settings["tabsize"])
-		x := util.GetCharPosInLine(w.Buf.LineBytes(vloc.Line), vloc.VisualX, tabsize)
-		return buffer.Loc{x, vloc.Line}
+		return buffer.Loc{X: w.charPos(vloc.Line, vloc.VisualX), Y: vloc.Line}
 	}
 	return w.getLocFromVLoc(vloc)
 }
\ No newline at end of file
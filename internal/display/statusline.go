@@ -43,6 +43,31 @@ var statusInfo = map[string]func(*buffer.Buffer) string{
 	"percentage": func(b *buffer.Buffer) string {
 		return strconv.Itoa((b.GetActiveCursor().Y + 1) * 100 / b.LinesNum())
 	},
+	"compression": func(b *buffer.Buffer) string {
+		if b.Compression == nil {
+			return ""
+		}
+		return " | " + b.Compression.String()
+	},
+	"encryption": func(b *buffer.Buffer) string {
+		if b.Locked() {
+			return " | age (locked)"
+		}
+		if b.Encryption == nil {
+			return ""
+		}
+		return " | " + b.Encryption.String()
+	},
+	"conflicts": func(b *buffer.Buffer) string {
+		switch n := len(b.Conflicts()); n {
+		case 0:
+			return ""
+		case 1:
+			return " | 1 conflict"
+		default:
+			return " | " + strconv.Itoa(n) + " conflicts"
+		}
+	},
 }
 func SetStatusInfoFnLua(fn string) {
 	luaFn := strings.Split(fn, ".")
@@ -82,6 +107,8 @@ func (s *StatusLine) FindOpt(opt string) interface{} {
 	return "null"
 }
 var formatParser = regexp.MustCompile(`\$\(.+?\)`)
+var PendingKeys string
+var Mode string
 func (s *StatusLine) Display() {
 	y := s.win.Height + s.win.Y - 1
 	winX := s.win.X
@@ -142,6 +169,12 @@ func (s *StatusLine) Display() {
 	leftText = formatParser.ReplaceAllFunc(leftText, formatter)
 	rightText := []byte(s.win.Buf.Settings["statusformatr"].(string))
 	rightText = formatParser.ReplaceAllFunc(rightText, formatter)
+	if Mode != "" && s.win.IsActive() {
+		leftText = append([]byte(Mode+" | "), leftText...)
+	}
+	if PendingKeys != "" && s.win.IsActive() {
+		rightText = []byte(PendingKeys + " …")
+	}
 	statusLineStyle := config.DefStyle.Reverse(true)
 	if s.win.IsActive() {
 		if style, ok := config.Colorscheme["statusline"]; ok {
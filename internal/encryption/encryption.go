@@ -0,0 +1,87 @@
+package encryption
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+const Ext = ".age"
+const HeaderSize = len(armor.Header)
+var magic = []byte("age-encryption.org/")
+type Key struct {
+	passphrase string
+	Armor      bool
+}
+func NewKey(passphrase string) *Key {
+	return &Key{passphrase: passphrase}
+}
+func Detect(header []byte) bool {
+	return bytes.HasPrefix(header, magic) || bytes.HasPrefix(header, []byte(armor.Header))
+}
+func (k *Key) String() string {
+	if k == nil {
+		return ""
+	}
+	return "age"
+}
+func (k *Key) Decrypt(file io.Reader) (io.Reader, error) {
+	if k.passphrase == "" {
+		return nil, errors.New("Empty passphrase")
+	}
+	br := bufio.NewReader(file)
+	header, _ := br.Peek(HeaderSize)
+	if !Detect(header) {
+		return nil, errors.New("File is not encrypted")
+	}
+	var src io.Reader = br
+	k.Armor = bytes.HasPrefix(header, []byte(armor.Header))
+	if k.Armor {
+		src = armor.NewReader(br)
+	}
+	id, err := age.NewScryptIdentity(k.passphrase)
+	if err != nil {
+		return nil, err
+	}
+	r, err := age.Decrypt(src, id)
+	if _, ok := err.(*age.NoIdentityMatchError); ok {
+		return nil, errors.New("Incorrect passphrase")
+	}
+	return r, err
+}
+type writeCloser struct {
+	io.WriteCloser
+	next io.Closer
+}
+func (w writeCloser) Close() error {
+	err := w.WriteCloser.Close()
+	if err2 := w.next.Close(); err2 != nil && err == nil {
+		err = err2
+	}
+	return err
+}
+type nopWriteCloser struct {
+	io.Writer
+}
+func (nopWriteCloser) Close() error {
+	return nil
+}
+func (k *Key) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	if k == nil {
+		return nopWriteCloser{w}, nil
+	}
+	recipient, err := age.NewScryptRecipient(k.passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if !k.Armor {
+		return age.Encrypt(w, recipient)
+	}
+	aw := armor.NewWriter(w)
+	ew, err := age.Encrypt(aw, recipient)
+	if err != nil {
+		return nil, err
+	}
+	return writeCloser{ew, aw}, nil
+}
@@ -0,0 +1,272 @@
+package export
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	runewidth "github.com/mattn/go-runewidth"
+	"github.com/zyedidia/micro/v2/internal/config"
+	"github.com/zyedidia/micro/v2/pkg/highlight"
+	"github.com/zyedidia/tcell/v2"
+)
+var Formats = []string{"html", "ansi", "svg"}
+var TrueColor = true
+var palette256 = func() []tcell.Color {
+	palette := make([]tcell.Color, 256)
+	for i := range palette {
+		palette[i] = tcell.PaletteColor(i)
+	}
+	return palette
+}()
+type Range struct {
+	StartX, StartY int
+	EndX, EndY     int
+}
+type segment struct {
+	text  string
+	style tcell.Style
+	col   int
+	width int
+}
+type line struct {
+	num  int
+	segs []segment
+}
+func Render(format string, def *highlight.Def, lines []string, r Range, tabsize int, ruler bool) (string, error) {
+	var matches []highlight.LineMatch
+	if def != nil {
+		matches = highlight.NewHighlighter(def).HighlightString(strings.Join(lines[:r.EndY+1], "\n"))
+	}
+	doc := make([]line, 0, r.EndY-r.StartY+1)
+	for y := r.StartY; y <= r.EndY; y++ {
+		from, to := 0, -1
+		if y == r.StartY {
+			from = r.StartX
+		}
+		if y == r.EndY {
+			to = r.EndX
+		}
+		var m highlight.LineMatch
+		if y < len(matches) {
+			m = matches[y]
+		}
+		doc = append(doc, line{y + 1, segments(lines[y], m, from, to, tabsize)})
+	}
+	if len(doc) > 1 && r.EndX <= 0 && len(doc[len(doc)-1].segs) == 0 {
+		doc = doc[:len(doc)-1]
+	}
+	gutter := 0
+	if ruler && len(doc) > 0 {
+		gutter = len(fmt.Sprint(doc[len(doc)-1].num)) + 1
+		lnStyle := config.GetColor("line-number")
+		for i := range doc {
+			num := segment{fmt.Sprintf("%*d ", gutter-1, doc[i].num), lnStyle, 0, gutter}
+			for j := range doc[i].segs {
+				doc[i].segs[j].col += gutter
+			}
+			doc[i].segs = append([]segment{num}, doc[i].segs...)
+		}
+	}
+	switch format {
+	case "html":
+		return renderHTML(doc), nil
+	case "ansi":
+		return renderANSI(doc), nil
+	case "svg":
+		return renderSVG(doc), nil
+	}
+	return "", errors.New("Unknown export format " + format + ", expected html, ansi or svg")
+}
+func segments(str string, m highlight.LineMatch, from, to, tabsize int) []segment {
+	var segs []segment
+	var b strings.Builder
+	style, cur := config.DefStyle, config.DefStyle
+	col, start := 0, 0
+	flush := func() {
+		if b.Len() > 0 {
+			segs = append(segs, segment{b.String(), cur, start, col - start})
+			b.Reset()
+		}
+		cur, start = style, col
+	}
+	for i, c := range []rune(str) {
+		if to >= 0 && i >= to {
+			break
+		}
+		if g, ok := m[i]; ok {
+			style = config.GetColor(g.String())
+		}
+		if i < from {
+			continue
+		}
+		if style != cur {
+			flush()
+		}
+		if c == '\t' {
+			n := tabsize - col%tabsize
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+		} else {
+			b.WriteRune(c)
+			col += runewidth.RuneWidth(c)
+		}
+	}
+	flush()
+	return segs
+}
+func colors(style tcell.Style) (tcell.Color, tcell.Color, tcell.AttrMask) {
+	fg, bg, attr := style.Decompose()
+	defFg, defBg, _ := config.DefStyle.Decompose()
+	if fg == tcell.ColorDefault {
+		fg = defFg
+	}
+	if bg == tcell.ColorDefault {
+		bg = defBg
+	}
+	if attr&tcell.AttrReverse != 0 {
+		fg, bg = bg, fg
+	}
+	return fg, bg, attr
+}
+func hex(c tcell.Color) string {
+	if v := c.Hex(); v >= 0 {
+		return fmt.Sprintf("#%06x", v)
+	}
+	return ""
+}
+func renderHTML(doc []line) string {
+	var b strings.Builder
+	defFg, defBg, _ := colors(config.DefStyle)
+	b.WriteString("<pre style=\"font-family:monospace")
+	if c := hex(defFg); c != "" {
+		b.WriteString(";color:" + c)
+	}
+	if c := hex(defBg); c != "" {
+		b.WriteString(";background-color:" + c)
+	}
+	b.WriteString("\">")
+	for _, l := range doc {
+		for _, s := range l.segs {
+			fg, bg, attr := colors(s.style)
+			var css []string
+			if c := hex(fg); c != "" && fg != defFg {
+				css = append(css, "color:"+c)
+			}
+			if c := hex(bg); c != "" && bg != defBg {
+				css = append(css, "background-color:"+c)
+			}
+			if attr&tcell.AttrBold != 0 {
+				css = append(css, "font-weight:bold")
+			}
+			if attr&tcell.AttrItalic != 0 {
+				css = append(css, "font-style:italic")
+			}
+			if attr&tcell.AttrUnderline != 0 {
+				css = append(css, "text-decoration:underline")
+			}
+			if len(css) == 0 {
+				b.WriteString(html.EscapeString(s.text))
+				continue
+			}
+			fmt.Fprintf(&b, "<span style=\"%s\">%s</span>", strings.Join(css, ";"), html.EscapeString(s.text))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("</pre>\n")
+	return b.String()
+}
+func sgrColor(c tcell.Color, base int) string {
+	if c&tcell.ColorIsRGB != 0 && !TrueColor {
+		c = tcell.FindColor(c, palette256)
+	}
+	switch {
+	case c&tcell.ColorIsRGB != 0:
+		r, g, b := c.RGB()
+		return fmt.Sprintf(";%d;2;%d;%d;%d", base, r, g, b)
+	case c&tcell.ColorValid != 0:
+		return fmt.Sprintf(";%d;5;%d", base, c-tcell.ColorValid)
+	}
+	return ""
+}
+func renderANSI(doc []line) string {
+	var b strings.Builder
+	defFg, defBg, _ := config.DefStyle.Decompose()
+	for _, l := range doc {
+		for _, s := range l.segs {
+			fg, bg, attr := s.style.Decompose()
+			if attr&tcell.AttrReverse != 0 {
+				fg, bg = bg, fg
+			}
+			b.WriteString("\x1b[0")
+			if attr&tcell.AttrBold != 0 {
+				b.WriteString(";1")
+			}
+			if attr&tcell.AttrItalic != 0 {
+				b.WriteString(";3")
+			}
+			if attr&tcell.AttrUnderline != 0 {
+				b.WriteString(";4")
+			}
+			if fg != defFg {
+				b.WriteString(sgrColor(fg, 38))
+			}
+			if bg != defBg {
+				b.WriteString(sgrColor(bg, 48))
+			}
+			b.WriteString("m" + s.text)
+		}
+		b.WriteString("\x1b[0m\n")
+	}
+	return b.String()
+}
+func renderSVG(doc []line) string {
+	const (
+		fontSize   = 14
+		charWidth  = 8.4
+		lineHeight = 18
+	)
+	width := 0
+	for _, l := range doc {
+		if n := len(l.segs); n > 0 {
+			if end := l.segs[n-1].col + l.segs[n-1].width; end > width {
+				width = end
+			}
+		}
+	}
+	var b strings.Builder
+	defFg, defBg, _ := colors(config.DefStyle)
+	fmt.Fprintf(&b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%.1f\" height=\"%d\" font-family=\"monospace\" font-size=\"%d\">\n", float64(width)*charWidth, len(doc)*lineHeight, fontSize)
+	if c := hex(defBg); c != "" {
+		fmt.Fprintf(&b, "<rect width=\"100%%\" height=\"100%%\" fill=\"%s\"/>\n", c)
+	}
+	for i, l := range doc {
+		for _, s := range l.segs {
+			if _, bg, _ := colors(s.style); bg != defBg && hex(bg) != "" {
+				fmt.Fprintf(&b, "<rect x=\"%.1f\" y=\"%d\" width=\"%.1f\" height=\"%d\" fill=\"%s\"/>\n", float64(s.col)*charWidth, i*lineHeight, float64(s.width)*charWidth, lineHeight, hex(bg))
+			}
+		}
+		fmt.Fprintf(&b, "<text y=\"%d\" xml:space=\"preserve\">", (i+1)*lineHeight-4)
+		for _, s := range l.segs {
+			fg, _, attr := colors(s.style)
+			fmt.Fprintf(&b, "<tspan x=\"%.1f\"", float64(s.col)*charWidth)
+			if c := hex(fg); c != "" {
+				fmt.Fprintf(&b, " fill=\"%s\"", c)
+			} else if c := hex(defFg); c != "" {
+				fmt.Fprintf(&b, " fill=\"%s\"", c)
+			}
+			if attr&tcell.AttrBold != 0 {
+				b.WriteString(" font-weight=\"bold\"")
+			}
+			if attr&tcell.AttrItalic != 0 {
+				b.WriteString(" font-style=\"italic\"")
+			}
+			if attr&tcell.AttrUnderline != 0 {
+				b.WriteString(" text-decoration=\"underline\"")
+			}
+			fmt.Fprintf(&b, ">%s</tspan>", html.EscapeString(s.text))
+		}
+		b.WriteString("</text>\n")
+	}
+	b.WriteString("</svg>\n")
+	return b.String()
+}
\ No newline at end of file
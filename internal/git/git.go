@@ -0,0 +1,161 @@
+package git
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+var ErrNotTracked = errors.New("File is not tracked by git")
+type Repo struct {
+	Root string
+	Dir  string
+}
+type BlameLine struct {
+	Hash   string
+	Author string
+	Date   time.Time
+}
+func run(dir string, stdin []byte, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	out, err := cmd.Output()
+	if e, ok := err.(*exec.ExitError); ok && len(e.Stderr) > 0 {
+		return out, errors.New(strings.TrimSpace(string(e.Stderr)))
+	}
+	return out, err
+}
+func Open(path string) (*Repo, error) {
+	out, err := run(filepath.Dir(path), nil, "rev-parse", "--show-toplevel", "--absolute-git-dir")
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != 2 {
+		return nil, errors.New("Not a git work tree: " + filepath.Dir(path))
+	}
+	return &Repo{Root: filepath.FromSlash(lines[0]), Dir: filepath.FromSlash(lines[1])}, nil
+}
+func (r *Repo) git(stdin []byte, args ...string) ([]byte, error) {
+	return run(r.Root, stdin, args...)
+}
+func (r *Repo) rel(path string) (string, error) {
+	if dir, err := filepath.EvalSymlinks(filepath.Dir(path)); err == nil {
+		path = filepath.Join(dir, filepath.Base(path))
+	}
+	rel, err := filepath.Rel(r.Root, path)
+	if err != nil {
+		return "", err
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", ErrNotTracked
+	}
+	return filepath.ToSlash(rel), nil
+}
+func (r *Repo) blob(hash string) ([]byte, error) {
+	return r.git(nil, "cat-file", "blob", hash)
+}
+func (r *Repo) Head(path string) ([]byte, error) {
+	rel, err := r.rel(path)
+	if err != nil {
+		return nil, err
+	}
+	out, err := r.git(nil, "rev-parse", "--verify", "--quiet", "HEAD:"+rel)
+	if err != nil {
+		return nil, ErrNotTracked
+	}
+	return r.blob(strings.TrimSpace(string(out)))
+}
+func (r *Repo) indexEntry(rel string) (string, string, error) {
+	out, err := r.git(nil, "ls-files", "--stage", "-z", "--", ":(literal)"+rel)
+	if err != nil {
+		return "", "", err
+	}
+	fields := strings.Fields(string(bytes.TrimRight(out, "\x00")))
+	if len(fields) < 3 {
+		return "", "", ErrNotTracked
+	}
+	return fields[0], fields[1], nil
+}
+func (r *Repo) Index(path string) ([]byte, error) {
+	rel, err := r.rel(path)
+	if err != nil {
+		return nil, err
+	}
+	_, hash, err := r.indexEntry(rel)
+	if err != nil {
+		return nil, err
+	}
+	return r.blob(hash)
+}
+func (r *Repo) Base(path string) ([]byte, error) {
+	base, err := r.Index(path)
+	if err == ErrNotTracked {
+		return r.Head(path)
+	}
+	return base, err
+}
+func (r *Repo) Stage(path string, content []byte) error {
+	rel, err := r.rel(path)
+	if err != nil {
+		return err
+	}
+	mode, _, err := r.indexEntry(rel)
+	if err == ErrNotTracked {
+		mode = "100644"
+		if info, err := os.Stat(path); err == nil && info.Mode()&0111 != 0 {
+			mode = "100755"
+		}
+	} else if err != nil {
+		return err
+	}
+	out, err := r.git(content, "hash-object", "-w", "--stdin", "--path="+rel)
+	if err != nil {
+		return err
+	}
+	hash := strings.TrimSpace(string(out))
+	_, err = r.git(nil, "update-index", "--add", "--cacheinfo", mode+","+hash+","+rel)
+	return err
+}
+func (r *Repo) Blame(path string) ([]BlameLine, []byte, error) {
+	rel, err := r.rel(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := r.Head(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	out, err := r.git(nil, "blame", "--line-porcelain", "HEAD", "--", rel)
+	if err != nil {
+		return nil, nil, err
+	}
+	var lines []BlameLine
+	var line BlameLine
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(nil, 1<<24)
+	for scanner.Scan() {
+		text := scanner.Text()
+		switch {
+		case strings.HasPrefix(text, "\t"):
+			lines = append(lines, line)
+			line = BlameLine{}
+		case line.Hash == "":
+			line.Hash = strings.SplitN(text, " ", 2)[0]
+		case strings.HasPrefix(text, "author "):
+			line.Author = strings.TrimPrefix(text, "author ")
+		case strings.HasPrefix(text, "author-time "):
+			if t, err := strconv.ParseInt(strings.TrimPrefix(text, "author-time "), 10, 64); err == nil {
+				line.Date = time.Unix(t, 0)
+			}
+		}
+	}
+	return lines, content, scanner.Err()
+}
\ No newline at end of file
@@ -9,6 +9,7 @@ type InfoBuf struct {
 	HasMessage bool
 	HasError   bool
 	HasYN      bool
+	Masked     bool
 	PromptType string
 	Msg    string
 	YNResp bool
@@ -68,10 +69,15 @@ func (i *InfoBuf) Prompt(prompt string, msg string, ptype string, eventcb func(s
 	i.HasPrompt = true
 	i.HasMessage, i.HasError, i.HasYN = false, false, false
 	i.HasGutter = false
+	i.Masked = false
 	i.PromptCallback = donecb
 	i.EventCallback = eventcb
 	i.Buffer.Insert(i.Buffer.Start(), msg)
 }
+func (i *InfoBuf) PasswordPrompt(prompt string, donecb func(string, bool)) {
+	i.Prompt(prompt, "", "Password", nil, donecb)
+	i.Masked = true
+}
 func (i *InfoBuf) YNPrompt(prompt string, donecb func(bool, bool)) {
 	if i.HasPrompt {
 		i.DonePrompt(true)
@@ -85,12 +91,24 @@ func (i *InfoBuf) YNPrompt(prompt string, donecb func(bool, bool)) {
 }
 func (i *InfoBuf) DonePrompt(canceled bool) {
 	hadYN := i.HasYN
+	masked := i.Masked
 	i.HasPrompt = false
 	i.HasYN = false
 	i.HasGutter = false
+	i.Masked = false
 	if !hadYN {
 		if i.PromptCallback != nil {
-			if canceled {
+			if masked {
+				resp := string(i.LineBytes(0))
+				i.Replace(i.Start(), i.End(), "")
+				i.UndoStack = new(buffer.TEStack)
+				i.RedoStack = new(buffer.TEStack)
+				delete(i.History, i.PromptType)
+				if canceled {
+					resp = ""
+				}
+				i.PromptCallback(resp, canceled)
+			} else if canceled {
 				i.Replace(i.Start(), i.End(), "")
 				i.PromptCallback("", true)
 				h := i.History[i.PromptType]
@@ -117,5 +135,6 @@ func (i *InfoBuf) DonePrompt(canceled bool) {
 func (i *InfoBuf) Reset() {
 	i.Msg = ""
 	i.HasPrompt, i.HasMessage, i.HasError = false, false, false
+	i.Masked = false
 	i.HasGutter = false
 }
\ No newline at end of file
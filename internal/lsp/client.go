@@ -0,0 +1,284 @@
+package lsp
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+var ErrTimeout = errors.New("Language server did not respond in time")
+var ErrClosed = errors.New("Language server is not running")
+type ResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+func (e *ResponseError) Error() string {
+	return e.Message
+}
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *ResponseError   `json:"error,omitempty"`
+}
+type Client struct {
+	Name         string
+	Capabilities ServerCapabilities
+	OnDiagnostics func(PublishDiagnosticsParams)
+	OnMessage     func(string)
+	w       io.Writer
+	wlock   sync.Mutex
+	lock    sync.Mutex
+	id      int
+	pending map[int]chan *message
+	timeout time.Duration
+	done    chan struct{}
+	cmd     *exec.Cmd
+}
+func NewClient(name string, r io.Reader, w io.Writer) *Client {
+	c := &Client{
+		Name:    name,
+		w:       w,
+		pending: make(map[int]chan *message),
+		done:    make(chan struct{}),
+	}
+	go c.read(bufio.NewReader(r))
+	return c
+}
+func Start(dir, command string, args ...string) (*Client, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Dir = dir
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	c := NewClient(command, stdout, stdin)
+	c.cmd = cmd
+	return c, nil
+}
+func (c *Client) Running() bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+		return true
+	}
+}
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.lock.Lock()
+	c.timeout = timeout
+	c.lock.Unlock()
+}
+func (c *Client) write(m interface{}) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	c.wlock.Lock()
+	defer c.wlock.Unlock()
+	if _, err := fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n", len(data)); err != nil {
+		return err
+	}
+	_, err = c.w.Write(data)
+	return err
+}
+func (c *Client) read(r *bufio.Reader) {
+	defer func() {
+		c.lock.Lock()
+		close(c.done)
+		for id, ch := range c.pending {
+			close(ch)
+			delete(c.pending, id)
+		}
+		c.lock.Unlock()
+	}()
+	for {
+		length := -1
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSpace(line)
+			if line == "" {
+				break
+			}
+			if v := strings.TrimPrefix(line, "Content-Length:"); v != line {
+				length, _ = strconv.Atoi(strings.TrimSpace(v))
+			}
+		}
+		if length < 0 {
+			continue
+		}
+		data := make([]byte, length)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return
+		}
+		m := new(message)
+		if json.Unmarshal(data, m) != nil {
+			continue
+		}
+		c.handle(m)
+	}
+}
+func (c *Client) handle(m *message) {
+	switch {
+	case m.Method == "" && m.ID != nil:
+		id, err := strconv.Atoi(string(*m.ID))
+		if err != nil {
+			return
+		}
+		c.lock.Lock()
+		ch, ok := c.pending[id]
+		delete(c.pending, id)
+		c.lock.Unlock()
+		if ok {
+			ch <- m
+		}
+	case m.ID != nil:
+		result, err := c.serverRequest(m.Method, m.Params)
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": m.ID, "result": result}
+		if err != nil {
+			resp = map[string]interface{}{"jsonrpc": "2.0", "id": m.ID, "error": err}
+		}
+		c.write(resp)
+	case m.Method == "textDocument/publishDiagnostics":
+		var params PublishDiagnosticsParams
+		if json.Unmarshal(m.Params, &params) == nil && c.OnDiagnostics != nil {
+			c.OnDiagnostics(params)
+		}
+	case m.Method == "window/showMessage":
+		var params struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(m.Params, &params) == nil && c.OnMessage != nil {
+			c.OnMessage(params.Message)
+		}
+	}
+}
+func (c *Client) serverRequest(method string, params json.RawMessage) (interface{}, *ResponseError) {
+	switch method {
+	case "workspace/configuration":
+		var p struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		json.Unmarshal(params, &p)
+		return make([]interface{}, len(p.Items)), nil
+	case "client/registerCapability", "client/unregisterCapability", "window/workDoneProgress/create":
+		return nil, nil
+	}
+	return nil, &ResponseError{-32601, "Method not found: " + method}
+}
+func (c *Client) Call(method string, params, result interface{}) error {
+	c.lock.Lock()
+	timeout := c.timeout
+	c.lock.Unlock()
+	return c.call(method, params, result, timeout)
+}
+func (c *Client) call(method string, params, result interface{}, timeout time.Duration) error {
+	c.lock.Lock()
+	if !c.Running() {
+		c.lock.Unlock()
+		return ErrClosed
+	}
+	c.id++
+	id := c.id
+	ch := make(chan *message, 1)
+	c.pending[id] = ch
+	c.lock.Unlock()
+	err := c.write(map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method, "params": params})
+	if err != nil {
+		c.lock.Lock()
+		delete(c.pending, id)
+		c.lock.Unlock()
+		return err
+	}
+	var expired <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		expired = t.C
+	}
+	select {
+	case m, ok := <-ch:
+		if !ok {
+			return ErrClosed
+		}
+		if m.Error != nil {
+			return m.Error
+		}
+		if result != nil && len(m.Result) > 0 {
+			return json.Unmarshal(m.Result, result)
+		}
+		return nil
+	case <-expired:
+		c.lock.Lock()
+		delete(c.pending, id)
+		c.lock.Unlock()
+		return ErrTimeout
+	}
+}
+func (c *Client) Notify(method string, params interface{}) error {
+	if !c.Running() {
+		return ErrClosed
+	}
+	return c.write(map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params})
+}
+func (c *Client) Initialize(root string) error {
+	params := map[string]interface{}{
+		"processId": os.Getpid(),
+		"rootUri":   PathToURI(root),
+		"rootPath":  root,
+		"capabilities": map[string]interface{}{
+			"textDocument": map[string]interface{}{
+				"synchronization":    map[string]interface{}{"didSave": true},
+				"completion":         map[string]interface{}{"completionItem": map[string]interface{}{"snippetSupport": false}},
+				"hover":              map[string]interface{}{"contentFormat": []string{"plaintext", "markdown"}},
+				"definition":         map[string]interface{}{"linkSupport": true},
+				"references":         map[string]interface{}{},
+				"rename":             map[string]interface{}{},
+				"formatting":         map[string]interface{}{},
+				"publishDiagnostics": map[string]interface{}{},
+			},
+			"workspace": map[string]interface{}{
+				"configuration": true,
+				"workspaceEdit": map[string]interface{}{"documentChanges": true},
+			},
+		},
+		"workspaceFolders": []map[string]string{{"uri": PathToURI(root), "name": root}},
+	}
+	var result struct {
+		Capabilities ServerCapabilities `json:"capabilities"`
+	}
+	if err := c.call("initialize", params, &result, 0); err != nil {
+		return err
+	}
+	c.Capabilities = result.Capabilities
+	return c.Notify("initialized", map[string]interface{}{})
+}
+func (c *Client) Shutdown() error {
+	err := c.Call("shutdown", nil, nil)
+	c.Notify("exit", nil)
+	if closer, ok := c.w.(io.Closer); ok {
+		closer.Close()
+	}
+	if c.cmd != nil {
+		go c.cmd.Wait()
+	}
+	return err
+}
@@ -0,0 +1,223 @@
+package lsp
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+type fakeServer struct {
+	t *testing.T
+	r *bufio.Reader
+	w *io.PipeWriter
+}
+func newFakeServer(t *testing.T) (*Client, *fakeServer) {
+	cr, sw := io.Pipe()
+	sr, cw := io.Pipe()
+	c := NewClient("fake", cr, cw)
+	s := &fakeServer{t: t, r: bufio.NewReader(sr), w: sw}
+	t.Cleanup(func() {
+		sw.Close()
+		sr.Close()
+	})
+	return c, s
+}
+func (s *fakeServer) recv() *message {
+	length := -1
+	for {
+		line, err := s.r.ReadString('\n')
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if v := strings.TrimPrefix(line, "Content-Length:"); v != line {
+			length, _ = strconv.Atoi(strings.TrimSpace(v))
+		}
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(s.r, data); err != nil {
+		s.t.Fatal(err)
+	}
+	m := new(message)
+	if err := json.Unmarshal(data, m); err != nil {
+		s.t.Fatal(err)
+	}
+	return m
+}
+func (s *fakeServer) send(m interface{}) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	fmt.Fprintf(s.w, "Content-Length: %d\r\n\r\n%s", len(data), data)
+}
+func (s *fakeServer) expect(method string, params interface{}) *message {
+	m := s.recv()
+	if m.Method != method {
+		s.t.Fatalf("got %q, want %q", m.Method, method)
+	}
+	if params != nil {
+		if err := json.Unmarshal(m.Params, params); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+	return m
+}
+func async(f func() error) chan error {
+	ch := make(chan error, 1)
+	go func() {
+		ch <- f()
+	}()
+	return ch
+}
+func wait(t *testing.T, ch chan error) error {
+	select {
+	case err := <-ch:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("call did not return")
+	}
+	return nil
+}
+func TestInitialize(t *testing.T) {
+	c, s := newFakeServer(t)
+	c.SetTimeout(time.Millisecond)
+	done := async(func() error {
+		return c.Initialize("/tmp/project")
+	})
+	var params struct {
+		RootURI string `json:"rootUri"`
+	}
+	m := s.expect("initialize", &params)
+	if params.RootURI != "file:///tmp/project" {
+		t.Errorf("rootUri = %q", params.RootURI)
+	}
+	time.Sleep(20 * time.Millisecond)
+	s.send(map[string]interface{}{"jsonrpc": "2.0", "id": m.ID, "result": map[string]interface{}{"capabilities": map[string]interface{}{"textDocumentSync": map[string]int{"change": SyncIncremental}}}})
+	s.expect("initialized", nil)
+	if err := wait(t, done); err != nil {
+		t.Fatal(err)
+	}
+	if kind := c.Capabilities.SyncKind(); kind != SyncIncremental {
+		t.Errorf("SyncKind() = %d", kind)
+	}
+}
+func TestDocumentSync(t *testing.T) {
+	c, s := newFakeServer(t)
+	done := async(func() error {
+		return c.DidOpen("file:///a.go", "go", 1, "package a\n")
+	})
+	var open struct {
+		TextDocument struct {
+			URI        string `json:"uri"`
+			LanguageID string `json:"languageId"`
+			Version    int    `json:"version"`
+			Text       string `json:"text"`
+		} `json:"textDocument"`
+	}
+	s.expect("textDocument/didOpen", &open)
+	if err := wait(t, done); err != nil {
+		t.Fatal(err)
+	}
+	if doc := open.TextDocument; doc.URI != "file:///a.go" || doc.LanguageID != "go" || doc.Version != 1 || doc.Text != "package a\n" {
+		t.Errorf("didOpen = %+v", doc)
+	}
+	r := &Range{Start: Position{Line: 0, Character: 8}, End: Position{Line: 0, Character: 9}}
+	done = async(func() error {
+		return c.DidChange("file:///a.go", 2, []TextDocumentContentChangeEvent{{Range: r, Text: "b"}})
+	})
+	var change struct {
+		TextDocument struct {
+			URI     string `json:"uri"`
+			Version int    `json:"version"`
+		} `json:"textDocument"`
+		ContentChanges []TextDocumentContentChangeEvent `json:"contentChanges"`
+	}
+	s.expect("textDocument/didChange", &change)
+	if err := wait(t, done); err != nil {
+		t.Fatal(err)
+	}
+	if change.TextDocument.Version != 2 || len(change.ContentChanges) != 1 {
+		t.Fatalf("didChange = %+v", change)
+	}
+	if ev := change.ContentChanges[0]; ev.Range == nil || *ev.Range != *r || ev.Text != "b" {
+		t.Errorf("change = %+v", ev)
+	}
+}
+func TestPublishDiagnostics(t *testing.T) {
+	c, s := newFakeServer(t)
+	diags := make(chan PublishDiagnosticsParams, 1)
+	c.OnDiagnostics = func(p PublishDiagnosticsParams) {
+		diags <- p
+	}
+	s.send(map[string]interface{}{"jsonrpc": "2.0", "method": "textDocument/publishDiagnostics", "params": PublishDiagnosticsParams{
+		URI:         "file:///a.go",
+		Diagnostics: []Diagnostic{{Severity: SeverityError, Message: "undefined: x", Range: Range{End: Position{Character: 1}}}},
+	}})
+	select {
+	case p := <-diags:
+		if p.URI != "file:///a.go" || len(p.Diagnostics) != 1 || p.Diagnostics[0].Message != "undefined: x" {
+			t.Errorf("diagnostics = %+v", p)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no diagnostics")
+	}
+}
+func TestWorkspaceConfiguration(t *testing.T) {
+	_, s := newFakeServer(t)
+	s.send(map[string]interface{}{"jsonrpc": "2.0", "id": 7, "method": "workspace/configuration", "params": map[string]interface{}{"items": []interface{}{map[string]string{"section": "a"}, map[string]string{"section": "b"}}}})
+	m := s.recv()
+	if m.ID == nil || string(*m.ID) != "7" || m.Error != nil {
+		t.Fatalf("response = %+v", m)
+	}
+	var result []interface{}
+	if err := json.Unmarshal(m.Result, &result); err != nil || len(result) != 2 {
+		t.Errorf("result = %s", m.Result)
+	}
+}
+func TestTimeout(t *testing.T) {
+	c, s := newFakeServer(t)
+	c.SetTimeout(50 * time.Millisecond)
+	done := async(func() error {
+		_, err := c.Hover("file:///a.go", Position{})
+		return err
+	})
+	m := s.expect("textDocument/hover", nil)
+	if err := wait(t, done); err != ErrTimeout {
+		t.Fatalf("err = %v, want ErrTimeout", err)
+	}
+	s.send(map[string]interface{}{"jsonrpc": "2.0", "id": m.ID, "result": nil})
+	done = async(func() error {
+		_, err := c.Hover("file:///a.go", Position{})
+		return err
+	})
+	m = s.expect("textDocument/hover", nil)
+	s.send(map[string]interface{}{"jsonrpc": "2.0", "id": m.ID, "result": map[string]string{"contents": "doc"}})
+	if err := wait(t, done); err != nil {
+		t.Fatal(err)
+	}
+}
+func TestServerExit(t *testing.T) {
+	c, s := newFakeServer(t)
+	done := async(func() error {
+		_, err := c.Definition("file:///a.go", Position{})
+		return err
+	})
+	s.expect("textDocument/definition", nil)
+	s.w.Close()
+	if err := wait(t, done); err != ErrClosed {
+		t.Fatalf("err = %v, want ErrClosed", err)
+	}
+	if c.Running() {
+		t.Error("client still running")
+	}
+	if err := c.Call("shutdown", nil, nil); err != ErrClosed {
+		t.Errorf("err = %v, want ErrClosed", err)
+	}
+}
\ No newline at end of file
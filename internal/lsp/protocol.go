@@ -0,0 +1,184 @@
+package lsp
+import (
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+	"github.com/zyedidia/micro/v2/internal/util"
+)
+const (
+	SyncNone        = 0
+	SyncFull        = 1
+	SyncIncremental = 2
+)
+const (
+	SeverityError       = 1
+	SeverityWarning     = 2
+	SeverityInformation = 3
+	SeverityHint        = 4
+)
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity"`
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+}
+type PublishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+type TextDocumentContentChangeEvent struct {
+	Range *Range `json:"range,omitempty"`
+	Text  string `json:"text"`
+}
+type CompletionItem struct {
+	Label      string `json:"label"`
+	Detail     string `json:"detail"`
+	InsertText string `json:"insertText"`
+	TextEdit   *struct {
+		NewText string `json:"newText"`
+	} `json:"textEdit"`
+}
+func (item CompletionItem) Text() string {
+	if item.TextEdit != nil {
+		return item.TextEdit.NewText
+	}
+	if item.InsertText != "" {
+		return item.InsertText
+	}
+	return item.Label
+}
+type WorkspaceEdit struct {
+	Changes         map[string][]TextEdit `json:"changes"`
+	DocumentChanges []struct {
+		TextDocument struct {
+			URI string `json:"uri"`
+		} `json:"textDocument"`
+		Edits []TextEdit `json:"edits"`
+	} `json:"documentChanges"`
+}
+func (e *WorkspaceEdit) Files() map[string][]TextEdit {
+	files := make(map[string][]TextEdit)
+	for uri, edits := range e.Changes {
+		files[uri] = append(files[uri], edits...)
+	}
+	for _, dc := range e.DocumentChanges {
+		if dc.TextDocument.URI != "" {
+			files[dc.TextDocument.URI] = append(files[dc.TextDocument.URI], dc.Edits...)
+		}
+	}
+	return files
+}
+type ServerCapabilities struct {
+	TextDocumentSync json.RawMessage `json:"textDocumentSync"`
+}
+func (c ServerCapabilities) SyncKind() int {
+	var kind int
+	if json.Unmarshal(c.TextDocumentSync, &kind) == nil {
+		return kind
+	}
+	var options struct {
+		Change int `json:"change"`
+	}
+	if json.Unmarshal(c.TextDocumentSync, &options) == nil {
+		return options.Change
+	}
+	return SyncNone
+}
+func PathToURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+func URIToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
+func UTF16Offset(line []byte, x int) int {
+	n := 0
+	for ; x > 0 && len(line) > 0; x-- {
+		r, combc, size := util.DecodeCharacter(line)
+		line = line[size:]
+		n += len(utf16.Encode(append([]rune{r}, combc...)))
+	}
+	return n
+}
+func CharOffset(line []byte, character int) int {
+	x := 0
+	for character > 0 && len(line) > 0 {
+		r, combc, size := util.DecodeCharacter(line)
+		line = line[size:]
+		character -= len(utf16.Encode(append([]rune{r}, combc...)))
+		x++
+	}
+	return x
+}
+func decodeLocations(data json.RawMessage) []Location {
+	var locs []Location
+	var links []struct {
+		TargetURI            string `json:"targetUri"`
+		TargetSelectionRange Range  `json:"targetSelectionRange"`
+	}
+	var loc Location
+	if json.Unmarshal(data, &loc) == nil && loc.URI != "" {
+		return []Location{loc}
+	}
+	if json.Unmarshal(data, &links) == nil {
+		for _, l := range links {
+			if l.TargetURI != "" {
+				locs = append(locs, Location{l.TargetURI, l.TargetSelectionRange})
+			}
+		}
+	}
+	if len(locs) == 0 {
+		json.Unmarshal(data, &locs)
+	}
+	return locs
+}
+func decodeHover(data json.RawMessage) string {
+	var hover struct {
+		Contents json.RawMessage `json:"contents"`
+	}
+	if json.Unmarshal(data, &hover) != nil {
+		return ""
+	}
+	var text string
+	if json.Unmarshal(hover.Contents, &text) == nil {
+		return text
+	}
+	var markup struct {
+		Value string `json:"value"`
+	}
+	if json.Unmarshal(hover.Contents, &markup) == nil && markup.Value != "" {
+		return markup.Value
+	}
+	var parts []json.RawMessage
+	json.Unmarshal(hover.Contents, &parts)
+	var values []string
+	for _, p := range parts {
+		if json.Unmarshal(p, &text) == nil {
+			values = append(values, text)
+		} else if json.Unmarshal(p, &markup) == nil {
+			values = append(values, markup.Value)
+		}
+	}
+	return strings.Join(values, "\n")
+}
@@ -0,0 +1,29 @@
+package lsp
+import "testing"
+func TestUTF16Offsets(t *testing.T) {
+	tests := []struct {
+		line string
+		x    int
+		char int
+		back int
+	}{
+		{"abc", 2, 2, 2},
+		{"héllo", 3, 3, 3},
+		{"a\U0001F600b", 1, 1, 1},
+		{"a\U0001F600b", 2, 3, 2},
+		{"a\U0001F600b", 3, 4, 3},
+		{"e\u0301x", 1, 2, 1},
+		{"e\u0301x", 2, 3, 2},
+		{"\U0001F600\u0301x", 1, 3, 1},
+		{"\U0001F600\u0301x", 2, 4, 2},
+		{"ab", 5, 2, 2},
+	}
+	for _, tt := range tests {
+		if got := UTF16Offset([]byte(tt.line), tt.x); got != tt.char {
+			t.Errorf("UTF16Offset(%q, %d) = %d, want %d", tt.line, tt.x, got, tt.char)
+		}
+		if got := CharOffset([]byte(tt.line), tt.char); got != tt.back {
+			t.Errorf("CharOffset(%q, %d) = %d, want %d", tt.line, tt.char, got, tt.back)
+		}
+	}
+}
\ No newline at end of file
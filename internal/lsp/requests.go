@@ -0,0 +1,89 @@
+package lsp
+import "encoding/json"
+type textDocument struct {
+	URI string `json:"uri"`
+}
+type positionParams struct {
+	TextDocument textDocument `json:"textDocument"`
+	Position     Position     `json:"position"`
+}
+func (c *Client) DidOpen(uri, languageID string, version int, text string) error {
+	return c.Notify("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{
+			"uri":        uri,
+			"languageId": languageID,
+			"version":    version,
+			"text":       text,
+		},
+	})
+}
+func (c *Client) DidChange(uri string, version int, changes []TextDocumentContentChangeEvent) error {
+	return c.Notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": uri, "version": version},
+		"contentChanges": changes,
+	})
+}
+func (c *Client) DidSave(uri string) error {
+	return c.Notify("textDocument/didSave", map[string]interface{}{"textDocument": textDocument{uri}})
+}
+func (c *Client) DidClose(uri string) error {
+	return c.Notify("textDocument/didClose", map[string]interface{}{"textDocument": textDocument{uri}})
+}
+func (c *Client) Completion(uri string, pos Position) ([]CompletionItem, error) {
+	var result json.RawMessage
+	if err := c.Call("textDocument/completion", positionParams{textDocument{uri}, pos}, &result); err != nil {
+		return nil, err
+	}
+	var items []CompletionItem
+	if json.Unmarshal(result, &items) == nil {
+		return items, nil
+	}
+	var list struct {
+		Items []CompletionItem `json:"items"`
+	}
+	err := json.Unmarshal(result, &list)
+	return list.Items, err
+}
+func (c *Client) Hover(uri string, pos Position) (string, error) {
+	var result json.RawMessage
+	if err := c.Call("textDocument/hover", positionParams{textDocument{uri}, pos}, &result); err != nil {
+		return "", err
+	}
+	return decodeHover(result), nil
+}
+func (c *Client) Definition(uri string, pos Position) ([]Location, error) {
+	var result json.RawMessage
+	if err := c.Call("textDocument/definition", positionParams{textDocument{uri}, pos}, &result); err != nil {
+		return nil, err
+	}
+	return decodeLocations(result), nil
+}
+func (c *Client) References(uri string, pos Position) ([]Location, error) {
+	params := map[string]interface{}{
+		"textDocument": textDocument{uri},
+		"position":     pos,
+		"context":      map[string]bool{"includeDeclaration": true},
+	}
+	var locs []Location
+	err := c.Call("textDocument/references", params, &locs)
+	return locs, err
+}
+func (c *Client) Rename(uri string, pos Position, name string) (*WorkspaceEdit, error) {
+	params := map[string]interface{}{
+		"textDocument": textDocument{uri},
+		"position":     pos,
+		"newName":      name,
+	}
+	edit := new(WorkspaceEdit)
+	err := c.Call("textDocument/rename", params, edit)
+	return edit, err
+}
+func (c *Client) Formatting(uri string, tabSize int, insertSpaces bool) ([]TextEdit, error) {
+	params := map[string]interface{}{
+		"textDocument": textDocument{uri},
+		"options":      map[string]interface{}{"tabSize": tabSize, "insertSpaces": insertSpaces},
+	}
+	var edits []TextEdit
+	err := c.Call("textDocument/formatting", params, &edits)
+	return edits, err
+}
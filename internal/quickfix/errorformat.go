@@ -0,0 +1,121 @@
+package quickfix
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+type Format struct {
+	re     *regexp.Regexp
+	fields []byte
+}
+func SplitErrorFormat(efm string) []string {
+	var pats []string
+	var sb strings.Builder
+	for i := 0; i < len(efm); i++ {
+		if efm[i] == '\\' && i+1 < len(efm) && efm[i+1] == ',' {
+			sb.WriteByte(',')
+			i++
+		} else if efm[i] == ',' {
+			pats = append(pats, sb.String())
+			sb.Reset()
+		} else {
+			sb.WriteByte(efm[i])
+		}
+	}
+	return append(pats, sb.String())
+}
+func CompileErrorFormat(efm string) ([]*Format, error) {
+	var formats []*Format
+	for _, pat := range SplitErrorFormat(efm) {
+		if pat == "" {
+			continue
+		}
+		f, err := compileFormat(pat)
+		if err != nil {
+			return nil, err
+		}
+		formats = append(formats, f)
+	}
+	return formats, nil
+}
+func compileFormat(pat string) (*Format, error) {
+	var sb strings.Builder
+	var fields []byte
+	sb.WriteString("^")
+	for i := 0; i < len(pat); i++ {
+		if pat[i] != '%' {
+			sb.WriteString(regexp.QuoteMeta(pat[i : i+1]))
+			continue
+		}
+		if i == len(pat)-1 {
+			return nil, errors.New("errorformat: trailing % in " + pat)
+		}
+		i++
+		switch pat[i] {
+		case 'f':
+			sb.WriteString(`(.+?)`)
+		case 'l', 'c':
+			sb.WriteString(`(\d+)`)
+		case 't':
+			sb.WriteString(`(?i:(fatal error|error|warning|info|note|hint|fatal|e|w|i|n))`)
+		case 'm':
+			sb.WriteString(`(.*)`)
+		case '%':
+			sb.WriteString("%")
+			continue
+		default:
+			return nil, errors.New("errorformat: unknown specifier %" + string(pat[i]))
+		}
+		for _, f := range fields {
+			if f == pat[i] {
+				return nil, errors.New("errorformat: duplicate specifier %" + string(pat[i]))
+			}
+		}
+		fields = append(fields, pat[i])
+	}
+	sb.WriteString("$")
+	if strings.IndexByte(string(fields), 'f') < 0 || strings.IndexByte(string(fields), 'l') < 0 {
+		return nil, errors.New("errorformat: " + pat + " needs at least %f and %l")
+	}
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, errors.New("errorformat: " + err.Error())
+	}
+	return &Format{re, fields}, nil
+}
+func ParseSeverity(s string) Severity {
+	switch strings.ToLower(s) {
+	case "w", "warning":
+		return SevWarning
+	case "i", "info", "n", "note", "hint":
+		return SevInfo
+	}
+	return SevError
+}
+func (f *Format) Match(line string) (Entry, bool) {
+	m := f.re.FindStringSubmatch(line)
+	if m == nil {
+		return Entry{}, false
+	}
+	e := Entry{Kind: SevError, Text: line}
+	for i, field := range f.fields {
+		v := m[i+1]
+		switch field {
+		case 'f':
+			e.File = strings.TrimSpace(v)
+		case 'l':
+			e.Line, _ = strconv.Atoi(v)
+		case 'c':
+			e.Col, _ = strconv.Atoi(v)
+		case 't':
+			e.Kind = ParseSeverity(v)
+		case 'm':
+			e.Msg = strings.TrimSpace(v)
+		}
+	}
+	if e.File == "" || e.Line <= 0 {
+		return Entry{}, false
+	}
+	return e, true
+}
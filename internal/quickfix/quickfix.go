@@ -0,0 +1,120 @@
+package quickfix
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+type Severity int
+const (
+	SevInfo Severity = iota
+	SevWarning
+	SevError
+)
+func (s Severity) String() string {
+	switch s {
+	case SevInfo:
+		return "info"
+	case SevWarning:
+		return "warning"
+	}
+	return "error"
+}
+type Entry struct {
+	File string
+	Line int
+	Col  int
+	Kind Severity
+	Msg  string
+	Text string
+}
+func (e Entry) AbsPath() string {
+	abs, err := filepath.Abs(e.File)
+	if err != nil {
+		return e.File
+	}
+	return abs
+}
+func (e Entry) Name() string {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, e.File); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return e.File
+}
+func (e Entry) String() string {
+	file := e.Name()
+	if e.Col > 0 {
+		return fmt.Sprintf("%s:%d:%d: %s: %s", file, e.Line, e.Col, e.Kind, e.Msg)
+	}
+	return fmt.Sprintf("%s:%d: %s: %s", file, e.Line, e.Kind, e.Msg)
+}
+type List struct {
+	Title   string
+	Entries []Entry
+	Cur     int
+}
+var Current = &List{Cur: -1}
+func Parse(output string, formats []*Format, dir string) []Entry {
+	var entries []Entry
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		for _, f := range formats {
+			e, ok := f.Match(line)
+			if !ok {
+				continue
+			}
+			if !filepath.IsAbs(e.File) && dir != "" {
+				e.File = filepath.Join(dir, e.File)
+			}
+			entries = append(entries, e)
+			break
+		}
+	}
+	return entries
+}
+func NewList(title string, entries []Entry) *List {
+	return &List{
+		Title:   title,
+		Entries: entries,
+		Cur:     -1,
+	}
+}
+func (l *List) Empty() bool {
+	return len(l.Entries) == 0
+}
+func (l *List) Count(kind Severity) int {
+	n := 0
+	for _, e := range l.Entries {
+		if e.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+func (l *List) Select(i int) (Entry, bool) {
+	if i < 0 || i >= len(l.Entries) {
+		return Entry{}, false
+	}
+	l.Cur = i
+	return l.Entries[i], true
+}
+func (l *List) Next() (Entry, bool) {
+	return l.Select(l.Cur + 1)
+}
+func (l *List) Prev() (Entry, bool) {
+	if l.Cur < 0 {
+		return l.Select(len(l.Entries) - 1)
+	}
+	return l.Select(l.Cur - 1)
+}
+func (l *List) EntriesFor(absPath string) []Entry {
+	var entries []Entry
+	for _, e := range l.Entries {
+		if e.AbsPath() == absPath {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
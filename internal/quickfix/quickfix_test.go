@@ -0,0 +1,93 @@
+package quickfix
+import (
+	"path/filepath"
+	"testing"
+)
+const testFormat = "%f:%l:%c: %t: %m,%f:%l:%c: %m,%f:%l: %t: %m,%f:%l: %m,%f(%l\\,%c): %t %m"
+func TestSplitErrorFormat(t *testing.T) {
+	got := SplitErrorFormat("%f:%l: %m,%f(%l\\,%c): %m")
+	want := []string{"%f:%l: %m", "%f(%l,%c): %m"}
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("pattern %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+func TestCompileErrorFormatErrors(t *testing.T) {
+	for _, efm := range []string{"%f:%m", "%l: %m", "%f:%l:%x", "%f:%l:%l", "%f:%l: %"} {
+		if _, err := CompileErrorFormat(efm); err == nil {
+			t.Errorf("CompileErrorFormat(%q) succeeded, want an error", efm)
+		}
+	}
+}
+func TestParse(t *testing.T) {
+	formats, err := CompileErrorFormat(testFormat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := "main.go:12:5: error: undefined: foo\r\n" +
+		"building...\n" +
+		"util.go:3:1: missing return\n" +
+		"lib.c:40: warning: unused variable\n" +
+		"/abs/x.py:7: note: defined here\n" +
+		"Prog.cs(8,2): error CS1002 ; expected\n" +
+		"bad.go:0: zero line\n"
+	tests := []Entry{
+		{File: filepath.Join("/src", "main.go"), Line: 12, Col: 5, Kind: SevError, Msg: "undefined: foo"},
+		{File: filepath.Join("/src", "util.go"), Line: 3, Col: 1, Kind: SevError, Msg: "missing return"},
+		{File: filepath.Join("/src", "lib.c"), Line: 40, Kind: SevWarning, Msg: "unused variable"},
+		{File: "/abs/x.py", Line: 7, Kind: SevInfo, Msg: "defined here"},
+		{File: filepath.Join("/src", "Prog.cs"), Line: 8, Col: 2, Kind: SevError, Msg: "CS1002 ; expected"},
+	}
+	entries := Parse(output, formats, "/src")
+	if len(entries) != len(tests) {
+		t.Fatalf("got %d entries, want %d: %+v", len(entries), len(tests), entries)
+	}
+	for i, want := range tests {
+		got := entries[i]
+		got.Text = ""
+		if got != want {
+			t.Errorf("entry %d = %+v, want %+v", i, got, want)
+		}
+	}
+}
+func TestNavigation(t *testing.T) {
+	l := NewList("test", []Entry{{File: "a", Line: 1}, {File: "b", Line: 2}, {File: "c", Line: 3}})
+	if e, ok := l.Prev(); !ok || e.File != "c" || l.Cur != 2 {
+		t.Fatalf("Prev from the start = %+v, %v (cur %d), want c", e, ok, l.Cur)
+	}
+	if _, ok := l.Next(); ok || l.Cur != 2 {
+		t.Fatalf("Next past the end moved to %d", l.Cur)
+	}
+	l.Cur = -1
+	for _, want := range []string{"a", "b", "c"} {
+		if e, ok := l.Next(); !ok || e.File != want {
+			t.Fatalf("Next = %+v, %v, want %s", e, ok, want)
+		}
+	}
+	if e, ok := l.Prev(); !ok || e.File != "b" {
+		t.Fatalf("Prev = %+v, %v, want b", e, ok)
+	}
+	if _, ok := l.Select(3); ok || l.Cur != 1 {
+		t.Fatalf("Select out of range moved to %d", l.Cur)
+	}
+	l.Select(0)
+	if _, ok := l.Prev(); ok || l.Cur != 0 {
+		t.Fatalf("Prev before the start moved to %d", l.Cur)
+	}
+}
+func TestEntriesFor(t *testing.T) {
+	l := NewList("test", []Entry{{File: "/p/a.go", Line: 1}, {File: "/p/b.go", Line: 2}, {File: "/p/a.go", Line: 9, Kind: SevWarning}})
+	if n := len(l.EntriesFor("/p/a.go")); n != 2 {
+		t.Errorf("EntriesFor returned %d entries, want 2", n)
+	}
+	if n := l.Count(SevWarning); n != 1 {
+		t.Errorf("Count(SevWarning) = %d, want 1", n)
+	}
+	if l.Empty() || !NewList("", nil).Empty() {
+		t.Error("Empty returned the wrong result")
+	}
+}
\ No newline at end of file
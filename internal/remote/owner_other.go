@@ -0,0 +1,6 @@
+//go:build windows || plan9
+package remote
+import "os"
+func ownedByUser(info os.FileInfo) bool {
+	return true
+}
\ No newline at end of file
@@ -0,0 +1,10 @@
+//go:build !windows && !plan9
+package remote
+import (
+	"os"
+	"syscall"
+)
+func ownedByUser(info os.FileInfo) bool {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	return ok && int(st.Uid) == os.Getuid()
+}
\ No newline at end of file
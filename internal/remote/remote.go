@@ -0,0 +1,123 @@
+package remote
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+)
+var ErrNoServer = errors.New("No running mecro instance found")
+var ErrNoReply = errors.New("The mecro instance closed the connection without replying")
+var listener net.Listener
+type Request struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+	Dir     string   `json:"dir"`
+	Wait    bool     `json:"wait"`
+}
+type Response struct {
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+type Handler func(req Request, reply func(Response))
+func socketDir() (string, error) {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	dir = filepath.Join(dir, fmt.Sprintf("mecro-%d", os.Getuid()))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() || info.Mode().Perm() != 0700 || !ownedByUser(info) {
+		return "", errors.New("Insecure permissions on " + dir)
+	}
+	return dir, nil
+}
+func SocketPath() (string, error) {
+	if path := os.Getenv("MECRO_SERVER"); path != "" {
+		return path, nil
+	}
+	dir, err := socketDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "server.sock"), nil
+}
+func Listen(handle Handler) (net.Listener, error) {
+	dir, err := socketDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, "server.sock")
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil, nil
+	}
+	os.Remove(path)
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if ul, ok := l.(*net.UnixListener); ok {
+		ul.SetUnlinkOnClose(true)
+	}
+	listener = l
+	os.Chmod(path, 0600)
+	os.Setenv("MECRO_SERVER", path)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn, handle)
+		}
+	}()
+	return l, nil
+}
+func Close() {
+	if listener != nil {
+		listener.Close()
+		listener = nil
+	}
+}
+func serve(conn net.Conn, handle Handler) {
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		conn.Close()
+		return
+	}
+	handle(req, func(resp Response) {
+		json.NewEncoder(conn).Encode(resp)
+		conn.Close()
+	})
+}
+func Send(req Request) (Response, error) {
+	var resp Response
+	path, err := SocketPath()
+	if err != nil {
+		return resp, err
+	}
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return resp, ErrNoServer
+	}
+	defer conn.Close()
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return resp, err
+	}
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		if errors.Is(err, io.EOF) {
+			return resp, ErrNoReply
+		}
+		return resp, err
+	}
+	return resp, nil
+}
\ No newline at end of file
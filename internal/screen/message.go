@@ -6,7 +6,12 @@ import (
 	"strconv"
 	"strings"
 )
+var Headless bool
 func TermMessage(msg ...interface{}) {
+	if Headless {
+		fmt.Fprintln(os.Stderr, msg...)
+		return
+	}
 	screenb := TempFini()
 	fmt.Println(msg...)
 	fmt.Print("\nPress enter to continue")
@@ -15,6 +20,9 @@ func TermMessage(msg ...interface{}) {
 	TempStart(screenb)
 }
 func TermPrompt(prompt string, options []string, wait bool) int {
+	if Headless {
+		return -1
+	}
 	screenb := TempFini()
 	idx := -1
 	for ok := true; ok; ok = wait && idx == -1 {
@@ -1,6 +1,7 @@
 package screen
 import (
 	"errors"
+	"fmt"
 	"log"
 	"os"
 	"sync"
@@ -69,10 +70,44 @@ func SetContent(x, y int, mainc rune, combc []rune, style tcell.Style) {
 		lastCursor.combc = combc
 	}
 }
+type CursorShape int
+const (
+	CursorDefault   CursorShape = 0
+	CursorBlock     CursorShape = 2
+	CursorUnderline CursorShape = 4
+	CursorBar       CursorShape = 6
+)
+var cursorShape CursorShape
+func SetCursorShape(shape CursorShape) {
+	if shape == cursorShape {
+		return
+	}
+	cursorShape = shape
+	writeCursorShape(shape)
+}
+func ResetCursorShape() {
+	if cursorShape != CursorDefault {
+		writeCursorShape(CursorDefault)
+	}
+}
+type ttyWriter interface {
+	TPuts(s string)
+}
+func writeCursorShape(shape CursorShape) {
+	if tty, ok := Screen.(ttyWriter); ok {
+		tty.TPuts(fmt.Sprintf("\x1b[%d q", shape))
+	}
+}
+func Fini() {
+	if Screen != nil {
+		ResetCursorShape()
+		Screen.Fini()
+	}
+}
 func TempFini() bool {
 	screenWasNil := Screen == nil
 	if !screenWasNil {
-		Screen.Fini()
+		Fini()
 		Lock()
 		Screen = nil
 	}
@@ -82,6 +117,9 @@ func TempStart(screenWasNil bool) {
 	if !screenWasNil {
 		Init()
 		Unlock()
+		if cursorShape != CursorDefault {
+			writeCursorShape(cursorShape)
+		}
 		if RestartCallback != nil {
 			RestartCallback()
 		}
@@ -0,0 +1,233 @@
+package tags
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"github.com/zyedidia/micro/v2/internal/util"
+)
+var ErrNoTags = errors.New("No tags file found")
+var names = []string{"tags", "TAGS"}
+type Tag struct {
+	Name     string
+	File     string
+	Line     int
+	Pattern  string
+	Anchored bool
+	Kind     string
+}
+type Index struct {
+	Path string
+	tags []Tag
+}
+func Find(dir string) (string, error) {
+	for {
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ErrNoTags
+		}
+		dir = parent
+	}
+}
+func Load(path string) (*Index, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	idx := &Index{Path: path}
+	if bytes.HasPrefix(data, []byte{'\f'}) {
+		idx.tags = parseEtags(data)
+	} else {
+		idx.tags = parseCtags(data)
+	}
+	dir := filepath.Dir(path)
+	for i, t := range idx.tags {
+		if !filepath.IsAbs(t.File) {
+			idx.tags[i].File = filepath.Join(dir, t.File)
+		}
+	}
+	sort.SliceStable(idx.tags, func(i, j int) bool {
+		return idx.tags[i].Name < idx.tags[j].Name
+	})
+	return idx, nil
+}
+func parseCtags(data []byte) []Tag {
+	var tags []Tag
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "!_TAG_") {
+			continue
+		}
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) < 3 {
+			continue
+		}
+		t := Tag{Name: fields[0], File: fields[1]}
+		address, ext := fields[2], ""
+		if i := strings.Index(address, ";\""); i >= 0 {
+			address, ext = address[:i], address[i+2:]
+		}
+		parseAddress(&t, address)
+		for _, f := range strings.Split(ext, "\t") {
+			if f == "" {
+				continue
+			}
+			k, v := "kind", f
+			if i := strings.IndexByte(f, ':'); i >= 0 {
+				k, v = f[:i], f[i+1:]
+			}
+			switch k {
+			case "kind":
+				t.Kind = v
+			case "line":
+				if n, err := strconv.Atoi(v); err == nil {
+					t.Line = n
+				}
+			}
+		}
+		tags = append(tags, t)
+	}
+	return tags
+}
+func parseAddress(t *Tag, address string) {
+	for address != "" {
+		if address[0] != '/' && address[0] != '?' {
+			part := address
+			if i := strings.IndexByte(address, ';'); i >= 0 {
+				part, address = address[:i], address[i+1:]
+			} else {
+				address = ""
+			}
+			if n, err := strconv.Atoi(part); err == nil {
+				t.Line = n
+			}
+			continue
+		}
+		end := 1
+		for end < len(address) && address[end] != address[0] {
+			if address[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		pattern := address[1:util.Min(end, len(address))]
+		address = strings.TrimPrefix(address[util.Min(end+1, len(address)):], ";")
+		pattern = strings.TrimPrefix(pattern, "^")
+		if strings.HasSuffix(pattern, "$") && !strings.HasSuffix(pattern, "\\$") {
+			pattern = pattern[:len(pattern)-1]
+			t.Anchored = true
+		}
+		r := strings.NewReplacer("\\\\", "\\", "\\/", "/", "\\?", "?", "\\$", "$", "\\^", "^")
+		t.Pattern = r.Replace(pattern)
+	}
+}
+func parseEtags(data []byte) []Tag {
+	var tags []Tag
+	for _, section := range bytes.Split(data, []byte{'\f', '\n'}) {
+		lines := strings.Split(string(section), "\n")
+		if len(lines) == 0 || lines[0] == "" {
+			continue
+		}
+		file := lines[0]
+		if i := strings.LastIndexByte(file, ','); i >= 0 {
+			file = file[:i]
+		}
+		for _, l := range lines[1:] {
+			i := strings.IndexByte(l, 0x7f)
+			if i < 0 {
+				continue
+			}
+			def, rest := l[:i], l[i+1:]
+			t := Tag{File: file, Pattern: def}
+			if j := strings.IndexByte(rest, 0x01); j >= 0 {
+				t.Name, rest = rest[:j], rest[j+1:]
+			} else {
+				t.Name = implicitName(def)
+			}
+			if j := strings.IndexByte(rest, ','); j >= 0 {
+				t.Line, _ = strconv.Atoi(rest[:j])
+			}
+			if t.Name != "" {
+				tags = append(tags, t)
+			}
+		}
+	}
+	return tags
+}
+func implicitName(def string) string {
+	isIdent := func(r rune) bool {
+		return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+	r := []rune(strings.TrimRightFunc(def, func(r rune) bool { return !isIdent(r) }))
+	start := len(r)
+	for start > 0 && isIdent(r[start-1]) {
+		start--
+	}
+	return string(r[start:])
+}
+func (idx *Index) Len() int {
+	return len(idx.tags)
+}
+func (idx *Index) Lookup(name string) []Tag {
+	i := sort.Search(len(idx.tags), func(i int) bool {
+		return idx.tags[i].Name >= name
+	})
+	j := i
+	for j < len(idx.tags) && idx.tags[j].Name == name {
+		j++
+	}
+	return idx.tags[i:j]
+}
+func (idx *Index) Complete(prefix string) []string {
+	i := sort.Search(len(idx.tags), func(i int) bool {
+		return idx.tags[i].Name >= prefix
+	})
+	var names []string
+	for ; i < len(idx.tags) && strings.HasPrefix(idx.tags[i].Name, prefix); i++ {
+		if len(names) == 0 || names[len(names)-1] != idx.tags[i].Name {
+			names = append(names, idx.tags[i].Name)
+		}
+	}
+	return names
+}
+func (t Tag) Locate(lines []string) int {
+	best := -1
+	if t.Pattern != "" {
+		for i, l := range lines {
+			match := l == t.Pattern
+			if !t.Anchored {
+				match = strings.HasPrefix(l, t.Pattern)
+			}
+			if match && (best < 0 || abs(i+1-t.Line) < abs(best+1-t.Line)) {
+				best = i
+			}
+		}
+	}
+	if best < 0 && t.Line > 0 {
+		best = t.Line - 1
+	}
+	if best < 0 {
+		return 0
+	}
+	return best
+}
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -332,6 +332,40 @@ func IsNonAlphaNumeric(c rune) bool {
 func IsAutocomplete(c rune) bool {
 	return c == '.' || !IsNonAlphaNumeric(c)
 }
+func FuzzyMatch(pattern, str string) (int, bool) {
+	p := []rune(strings.ToLower(pattern))
+	r := []rune(str)
+	if len(p) == 0 {
+		return 0, true
+	}
+	score := 0
+	pi := 0
+	last := -1
+	for i := 0; i < len(r) && pi < len(p); i++ {
+		if unicode.ToLower(r[i]) != p[pi] {
+			continue
+		}
+		switch {
+		case i == 0:
+			score += 8
+		case last == i-1:
+			score += 5
+		case IsNonAlphaNumeric(r[i-1]) || unicode.IsUpper(r[i]) && unicode.IsLower(r[i-1]):
+			score += 4
+		default:
+			score++
+		}
+		if last >= 0 {
+			score -= Min(i-last-1, 3)
+		}
+		last = i
+		pi++
+	}
+	if pi < len(p) {
+		return 0, false
+	}
+	return score*16 - len(r), true
+}
 func ParseSpecial(s string) string {
 	return strings.ReplaceAll(s, "\\t", "\t")
 }
@@ -0,0 +1,99 @@
+package watcher
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+var Events = make(chan string, 256)
+type watch struct {
+	count int
+	dir   string
+}
+var (
+	lock  sync.Mutex
+	paths = make(map[string]*watch)
+	dirs  = make(map[string]int)
+)
+func watchDir(path string) string {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return path
+	}
+	return filepath.Dir(path)
+}
+func Watch(path string) {
+	if path == "" {
+		return
+	}
+	path = filepath.Clean(path)
+	lock.Lock()
+	defer lock.Unlock()
+	if w, ok := paths[path]; ok {
+		w.count++
+		return
+	}
+	w := &watch{1, watchDir(path)}
+	paths[path] = w
+	dirs[w.dir]++
+	if dirs[w.dir] == 1 {
+		addWatch(w.dir)
+	}
+}
+func Unwatch(path string) {
+	if path == "" {
+		return
+	}
+	path = filepath.Clean(path)
+	lock.Lock()
+	defer lock.Unlock()
+	w, ok := paths[path]
+	if !ok {
+		return
+	}
+	w.count--
+	if w.count > 0 {
+		return
+	}
+	delete(paths, path)
+	dirs[w.dir]--
+	if dirs[w.dir] <= 0 {
+		delete(dirs, w.dir)
+		removeWatch(w.dir)
+	}
+}
+func Watching(path string) bool {
+	path = filepath.Clean(path)
+	lock.Lock()
+	defer lock.Unlock()
+	w, ok := paths[path]
+	return ok && isWatched(w.dir)
+}
+func notify(dir, name string) {
+	path := dir
+	if name != "" {
+		path = filepath.Join(dir, name)
+	}
+	lock.Lock()
+	_, file := paths[path]
+	_, parent := paths[dir]
+	lock.Unlock()
+	if file || parent {
+		select {
+		case Events <- path:
+		default:
+		}
+	}
+}
+func notifyAll() {
+	lock.Lock()
+	var all []string
+	for path := range paths {
+		all = append(all, path)
+	}
+	lock.Unlock()
+	for _, path := range all {
+		select {
+		case Events <- path:
+		default:
+		}
+	}
+}
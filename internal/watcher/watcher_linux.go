@@ -0,0 +1,80 @@
+package watcher
+import (
+	"strings"
+	"syscall"
+	"unsafe"
+)
+const inotifyMask = syscall.IN_CLOSE_WRITE | syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_ATTRIB | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+var (
+	inotifyFd = -1
+	inotifyFailed bool
+	wds = make(map[string]int)
+	wdDirs = make(map[int]string)
+)
+func addWatch(dir string) {
+	if inotifyFd < 0 {
+		if inotifyFailed {
+			return
+		}
+		fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC)
+		if err != nil {
+			inotifyFailed = true
+			return
+		}
+		inotifyFd = fd
+		go readEvents(fd)
+	}
+	wd, err := syscall.InotifyAddWatch(inotifyFd, dir, inotifyMask)
+	if err != nil {
+		return
+	}
+	wds[dir] = wd
+	wdDirs[wd] = dir
+}
+func removeWatch(dir string) {
+	wd, ok := wds[dir]
+	if !ok {
+		return
+	}
+	syscall.InotifyRmWatch(inotifyFd, uint32(wd))
+	delete(wds, dir)
+	delete(wdDirs, wd)
+}
+func isWatched(dir string) bool {
+	_, ok := wds[dir]
+	return ok
+}
+func readEvents(fd int) {
+	var buf [syscall.SizeofInotifyEvent * 4096]byte
+	for {
+		n, err := syscall.Read(fd, buf[:])
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil || n <= 0 {
+			return
+		}
+		for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+			ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			start := off + syscall.SizeofInotifyEvent
+			off = start + int(ev.Len)
+			if ev.Mask&syscall.IN_Q_OVERFLOW != 0 {
+				notifyAll()
+				continue
+			}
+			name := strings.TrimRight(string(buf[start:off]), "\x00")
+			lock.Lock()
+			dir, ok := wdDirs[int(ev.Wd)]
+			if ok && ev.Mask&syscall.IN_IGNORED != 0 {
+				if wds[dir] == int(ev.Wd) {
+					delete(wds, dir)
+				}
+				delete(wdDirs, int(ev.Wd))
+			}
+			lock.Unlock()
+			if ok && ev.Mask&syscall.IN_IGNORED == 0 {
+				notify(dir, name)
+			}
+		}
+	}
+}
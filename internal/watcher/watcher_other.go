@@ -0,0 +1,9 @@
+//go:build !linux
+package watcher
+func addWatch(dir string) {
+}
+func removeWatch(dir string) {
+}
+func isWatched(dir string) bool {
+	return false
+}
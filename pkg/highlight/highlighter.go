@@ -335,4 +335,33 @@ func (h *Highlighter) ReHighlightLine(input LineStates, lineN int) {
 	curState := h.lastRegion
 	input.SetMatch(lineN, match)
 	input.SetState(lineN, curState)
+}
+func (h *Highlighter) HighlightLine(input LineStates, lineN int, matches bool) bool {
+	input.Lock()
+	defer input.Unlock()
+	if lineN >= input.LinesNum() {
+		return false
+	}
+	line := input.LineBytes(lineN)
+	h.lastRegion = nil
+	if lineN > 0 {
+		h.lastRegion = input.State(lineN - 1)
+	}
+	var highlights LineMatch
+	if matches {
+		highlights = make(LineMatch)
+	}
+	var match LineMatch
+	if lineN == 0 || h.lastRegion == nil {
+		match = h.highlightEmptyRegion(highlights, 0, true, lineN, line, !matches)
+	} else {
+		match = h.highlightRegion(highlights, 0, true, lineN, line, h.lastRegion, !matches)
+	}
+	if matches {
+		input.SetMatch(lineN, match)
+	}
+	curState := h.lastRegion
+	changed := curState != input.State(lineN)
+	input.SetState(lineN, curState)
+	return changed
 }
\ No newline at end of file
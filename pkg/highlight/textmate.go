@@ -0,0 +1,386 @@
+package highlight
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"gopkg.in/yaml.v2"
+)
+type tmGrammar struct {
+	Name           string             `json:"name"`
+	ScopeName      string             `json:"scopeName"`
+	FileTypes      []string           `json:"fileTypes"`
+	FirstLineMatch string             `json:"firstLineMatch"`
+	Patterns       []*tmRule          `json:"patterns"`
+	Repository     map[string]*tmRule `json:"repository"`
+}
+type tmRule struct {
+	Name          string               `json:"name"`
+	ContentName   string               `json:"contentName"`
+	Match         string               `json:"match"`
+	Begin         string               `json:"begin"`
+	End           string               `json:"end"`
+	Include       string               `json:"include"`
+	Captures      map[string]tmCapture `json:"captures"`
+	BeginCaptures map[string]tmCapture `json:"beginCaptures"`
+	Patterns      []*tmRule            `json:"patterns"`
+	Repository    map[string]*tmRule   `json:"repository"`
+}
+type tmCapture struct {
+	Name string `json:"name"`
+}
+var tmScopes = [][2]string{
+	{"comment", "comment"},
+	{"string", "constant.string"},
+	{"constant.numeric", "constant.number"},
+	{"constant.language", "constant.bool"},
+	{"constant.character.escape", "constant.specialChar"},
+	{"constant.other.placeholder", "constant.specialChar"},
+	{"constant", "constant"},
+	{"keyword.operator", "symbol.operator"},
+	{"keyword.control.directive", "preproc"},
+	{"meta.preprocessor", "preproc"},
+	{"keyword", "statement"},
+	{"storage.type", "type"},
+	{"storage.modifier", "type.keyword"},
+	{"storage", "statement"},
+	{"entity.name.type", "type"},
+	{"entity.name.class", "identifier.class"},
+	{"entity.name.tag", "symbol.tag"},
+	{"entity.other.inherited-class", "identifier.class"},
+	{"entity", "identifier"},
+	{"support.class", "identifier.class"},
+	{"support.type", "type"},
+	{"support.constant", "constant"},
+	{"support.variable", "identifier.var"},
+	{"support", "identifier"},
+	{"variable", "identifier.var"},
+	{"punctuation.section.brackets", "symbol.brackets"},
+	{"punctuation.definition.tag", "symbol.tag"},
+	{"punctuation.definition.comment", "comment"},
+	{"punctuation.definition.string", "constant.string"},
+	{"punctuation", "symbol"},
+	{"markup.underline", "underlined"},
+	{"markup.raw", "constant.string"},
+	{"markup", "special"},
+	{"invalid", "error"},
+}
+var tmFiletypes = map[string]string{
+	"js":    "javascript",
+	"ts":    "typescript",
+	"cs":    "csharp",
+	"shell": "shell",
+}
+const tmMaxRules = 5000
+var errTooLarge = fmt.Errorf("grammar expands to more than %d rules", tmMaxRules)
+type tmConverter struct {
+	g        *tmGrammar
+	filetype string
+	active   map[string]int
+	repos    []map[string]*tmRule
+	count    int
+	err      error
+	skipped  []string
+	seen     map[string]bool
+}
+func ConvertTextMate(data []byte) ([]byte, error) {
+	g := new(tmGrammar)
+	if err := json.Unmarshal(data, g); err != nil {
+		return nil, err
+	}
+	c := &tmConverter{g: g, active: map[string]int{"$self": 1}, seen: make(map[string]bool)}
+	c.filetype = tmFiletype(g.ScopeName)
+	if c.filetype == "" {
+		c.filetype = strings.ToLower(g.Name)
+	}
+	if c.filetype == "" {
+		return nil, errors.New("grammar has no scopeName")
+	}
+	var detect yaml.MapSlice
+	if len(g.FileTypes) > 0 {
+		exts := make([]string, len(g.FileTypes))
+		for i, ext := range g.FileTypes {
+			exts[i] = regexp.QuoteMeta(ext)
+		}
+		detect = append(detect, yaml.MapItem{Key: "filename", Value: "(^|/|\\.)(" + strings.Join(exts, "|") + ")$"})
+	}
+	if g.FirstLineMatch != "" {
+		if re := c.regex(g.FirstLineMatch); re != nil {
+			detect = append(detect, yaml.MapItem{Key: "header", Value: re.String()})
+		}
+	}
+	c.repos = append(c.repos, g.Repository)
+	rules := c.rules(g.Patterns)
+	if c.err != nil {
+		return nil, c.err
+	}
+	out, err := yaml.Marshal(yaml.MapSlice{
+		{Key: "filetype", Value: c.filetype},
+		{Key: "detect", Value: detect},
+		{Key: "rules", Value: rules},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var report strings.Builder
+	for _, skipped := range c.skipped {
+		report.WriteString("# skipped " + skipped + "\n")
+	}
+	return append([]byte(report.String()), out...), nil
+}
+func (c *tmConverter) regex(s string) *regexp.Regexp {
+	re, err := tmRegex(s)
+	if err != nil && !c.seen[s] {
+		c.seen[s] = true
+		c.skipped = append(c.skipped, fmt.Sprintf("%q: %v", s, err))
+	}
+	return re
+}
+func (c *tmConverter) emit(group string, value interface{}) []interface{} {
+	if c.count++; c.count > tmMaxRules {
+		c.err = errTooLarge
+		return nil
+	}
+	return []interface{}{yaml.MapSlice{{Key: group, Value: value}}}
+}
+func (c *tmConverter) rules(list []*tmRule) []interface{} {
+	out := []interface{}{}
+	for _, r := range list {
+		out = append(out, c.rule(r)...)
+	}
+	return out
+}
+func (c *tmConverter) rule(r *tmRule) []interface{} {
+	if r == nil || c.err != nil {
+		return nil
+	}
+	if r.Repository != nil {
+		c.repos = append(c.repos, r.Repository)
+		defer func() {
+			c.repos = c.repos[:len(c.repos)-1]
+		}()
+	}
+	switch {
+	case r.Include != "":
+		return c.include(r.Include)
+	case r.Match != "":
+		group := c.group(r.Name, r.Captures)
+		if group == "" {
+			return nil
+		}
+		re := c.regex(r.Match)
+		if re == nil || re.MatchString("") {
+			return nil
+		}
+		return c.emit(group, re.String())
+	case r.Begin != "":
+		if r.End == "" {
+			return nil
+		}
+		start, end := c.regex(r.Begin), c.regex(r.End)
+		if start == nil || end == nil || start.MatchString("") {
+			return nil
+		}
+		endStr := end.String()
+		if endStr == "" {
+			endStr = "$"
+		}
+		name := r.ContentName
+		if name == "" {
+			name = r.Name
+		}
+		group := tmGroup(name)
+		if group == "" {
+			group = "default"
+		}
+		region := yaml.MapSlice{{Key: "start", Value: start.String()}, {Key: "end", Value: endStr}}
+		limit := c.group(r.Name, r.BeginCaptures)
+		if limit != "" && limit != group {
+			region = append(region, yaml.MapItem{Key: "limit-group", Value: limit})
+		}
+		rules := c.rules(r.Patterns)
+		region = append(region, yaml.MapItem{Key: "rules", Value: rules})
+		return c.emit(group, region)
+	}
+	return c.rules(r.Patterns)
+}
+func (c *tmConverter) include(name string) []interface{} {
+	var target []*tmRule
+	switch {
+	case strings.HasPrefix(name, "#"):
+		name = name[1:]
+		for i := len(c.repos) - 1; i >= 0; i-- {
+			if r, ok := c.repos[i][name]; ok {
+				target = []*tmRule{r}
+				break
+			}
+		}
+	case name == "$self" || name == "$base" || tmFiletype(name) == c.filetype:
+		name, target = "$self", c.g.Patterns
+	default:
+		if i := strings.IndexByte(name, '#'); i >= 0 {
+			name = name[:i]
+		}
+		return c.emit("include", tmFiletype(name))
+	}
+	if target == nil || c.active[name] > 0 {
+		return nil
+	}
+	c.active[name]++
+	defer func() {
+		c.active[name]--
+	}()
+	return c.rules(target)
+}
+func (c *tmConverter) group(name string, captures map[string]tmCapture) string {
+	if group := tmGroup(name); group != "" {
+		return group
+	}
+	keys := make([]string, 0, len(captures))
+	for k := range captures {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if group := tmGroup(captures[k].Name); group != "" {
+			return group
+		}
+	}
+	return ""
+}
+func tmFiletype(scope string) string {
+	if i := strings.IndexByte(scope, '#'); i >= 0 {
+		scope = scope[:i]
+	}
+	ft := strings.ToLower(scope[strings.LastIndexByte(scope, '.')+1:])
+	if alias, ok := tmFiletypes[ft]; ok {
+		return alias
+	}
+	return ft
+}
+func tmGroup(scope string) string {
+	fields := strings.Fields(scope)
+	if len(fields) == 0 {
+		return ""
+	}
+	for _, s := range tmScopes {
+		if fields[0] == s[0] || strings.HasPrefix(fields[0], s[0]+".") {
+			return s[1]
+		}
+	}
+	return ""
+}
+func tmRegex(s string) (*regexp.Regexp, error) {
+	rs := []rune(s)
+	var b strings.Builder
+	extended, class, quant := false, false, false
+	for i := 0; i < len(rs); i++ {
+		c := rs[i]
+		wasQuant := quant
+		quant = false
+		switch {
+		case c == '\\' && i+1 < len(rs):
+			i++
+			switch e := rs[i]; {
+			case e == 'h' && class:
+				b.WriteString("0-9A-Fa-f")
+			case e == 'h':
+				b.WriteString("[0-9A-Fa-f]")
+			case e == 'H' && !class:
+				b.WriteString("[^0-9A-Fa-f]")
+			case e == 'G':
+				return nil, errors.New("\\G is not supported")
+			case e == 'Z':
+				b.WriteString(`\z`)
+			case e == 'e':
+				b.WriteString(`\x1b`)
+			case e >= '1' && e <= '9', e == 'k', e == 'g', e == 'K', e == 'R', e == 'X':
+				return nil, fmt.Errorf("\\%c is not supported", e)
+			default:
+				b.WriteRune('\\')
+				b.WriteRune(e)
+			}
+		case class:
+			if c == ']' {
+				class = false
+			}
+			b.WriteRune(c)
+		case c == '[':
+			class = true
+			b.WriteRune(c)
+			if i+1 < len(rs) && rs[i+1] == '^' {
+				i++
+				b.WriteRune('^')
+			}
+			if i+1 < len(rs) && rs[i+1] == ']' {
+				i++
+				b.WriteString(`\]`)
+			}
+		case extended && (c == ' ' || c == '\t' || c == '\n' || c == '\r'):
+		case extended && c == '#':
+			for i+1 < len(rs) && rs[i+1] != '\n' {
+				i++
+			}
+		case c == '+' && wasQuant:
+		case c == '*' || c == '+' || c == '?' || c == '}':
+			quant = true
+			b.WriteRune(c)
+		case c == '(' && i+2 < len(rs) && rs[i+1] == '?':
+			rest := string(rs[i+2:])
+			switch {
+			case strings.HasPrefix(rest, "="), strings.HasPrefix(rest, "!"), strings.HasPrefix(rest, "<="), strings.HasPrefix(rest, "<!"):
+				return nil, errors.New("lookaround is not supported")
+			case strings.HasPrefix(rest, "#"):
+				i = tmGroupEnd(rs, i)
+			case strings.HasPrefix(rest, ">"):
+				b.WriteString("(?:")
+				i += 2
+			case strings.HasPrefix(rest, "<"):
+				b.WriteString("(?P<")
+				i += 2
+			default:
+				j := i + 2
+				for j < len(rs) && rs[j] != ')' && rs[j] != ':' {
+					j++
+				}
+				flags := string(rs[i+2 : j])
+				if strings.Contains(flags, "x") {
+					extended = true
+					flags = strings.Replace(flags, "x", "", -1)
+				}
+				if j < len(rs) && rs[j] == ')' && (flags == "" || flags == "-") {
+					i = j
+					continue
+				}
+				b.WriteString("(?" + flags)
+				i = j - 1
+			}
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return regexp.Compile(b.String())
+}
+func tmGroupEnd(rs []rune, i int) int {
+	depth, class := 0, false
+	for ; i < len(rs); i++ {
+		switch c := rs[i]; {
+		case c == '\\':
+			i++
+		case class:
+			class = c != ']'
+		case c == '[':
+			class = true
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return i
+}
\ No newline at end of file
@@ -0,0 +1,143 @@
+package highlight
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+func TestTextMateRegex(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`\h+`, `[0-9A-Fa-f]+`},
+		{`[\h_]`, `[0-9A-Fa-f_]`},
+		{`\H`, `[^0-9A-Fa-f]`},
+		{`a\Z`, `a\z`},
+		{`\e\[`, `\x1b\[`},
+		{`[]a]`, `[\]a]`},
+		{`a++b*+`, `a+b*`},
+		{`(?<name>\w+)`, `(?P<name>\w+)`},
+		{`(?>ab)c`, `(?:ab)c`},
+		{`(?i)abc`, `(?i)abc`},
+		{`a(?#note)b`, `ab`},
+		{"(?x) a  b # comment\n c", `abc`},
+		{`a(?=b)`, ""},
+		{`a(?!b)`, ""},
+		{`(?<=a)b`, ""},
+		{`(?<!a)b`, ""},
+		{`\Gabc`, ""},
+		{`(a)\1`, ""},
+		{`\k<name>`, ""},
+	}
+	for _, tt := range tests {
+		re, err := tmRegex(tt.in)
+		switch {
+		case tt.want == "" && err == nil:
+			t.Errorf("tmRegex(%q) = %q, want an error", tt.in, re)
+		case tt.want != "" && err != nil:
+			t.Errorf("tmRegex(%q): %v", tt.in, err)
+		case tt.want != "" && re.String() != tt.want:
+			t.Errorf("tmRegex(%q) = %q, want %q", tt.in, re, tt.want)
+		}
+	}
+}
+func convertGrammar(t *testing.T, grammar string) string {
+	out, err := ConvertTextMate([]byte(grammar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := ParseFile(out)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	header, err := MakeHeaderYaml(out)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	if _, err := ParseDef(f, header); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	return string(out)
+}
+func TestTextMateConvert(t *testing.T) {
+	tests := []struct {
+		grammar string
+		want    string
+	}{
+		{
+			`{"scopeName": "source.demo", "fileTypes": ["demo"], "patterns": [
+				{"match": "\\b(if|else)\\b", "name": "keyword.control.demo"},
+				{"match": "(\\w+)\\(", "captures": {"1": {"name": "entity.name.function"}}},
+				{"match": "x", "name": "meta.unknown"}]}`,
+			"filetype: demo\ndetect:\n  filename: (^|/|\\.)(demo)$\nrules:\n- statement: \\b(if|else)\\b\n- identifier: (\\w+)\\(\n",
+		},
+		{
+			`{"scopeName": "source.demo", "patterns": [{"begin": "\"", "end": "\"", "name": "string.quoted.double",
+				"beginCaptures": {"0": {"name": "punctuation.definition.string.begin"}},
+				"patterns": [{"match": "\\\\.", "name": "constant.character.escape"}]}]}`,
+			"filetype: demo\ndetect: {}\nrules:\n- constant.string:\n    start: '\"'\n    end: '\"'\n    rules:\n    - constant.specialChar: \\\\.\n",
+		},
+		{
+			`{"scopeName": "source.demo", "patterns": [{"begin": "<!--", "end": "-->", "contentName": "comment.block",
+				"beginCaptures": {"0": {"name": "punctuation.section.brackets"}}}]}`,
+			"filetype: demo\ndetect: {}\nrules:\n- comment:\n    start: <!--\n    end: -->\n    limit-group: symbol.brackets\n    rules: []\n",
+		},
+		{
+			`{"scopeName": "source.demo", "patterns": [{"match": "(?<=\\.)\\w+", "name": "variable"}, {"include": "source.js#expr"}]}`,
+			"# skipped \"(?<=\\\\.)\\\\w+\": lookaround is not supported\nfiletype: demo\ndetect: {}\nrules:\n- include: javascript\n",
+		},
+	}
+	for i, tt := range tests {
+		if got := convertGrammar(t, tt.grammar); got != tt.want {
+			t.Errorf("grammar %d:\n%s\nwant:\n%s", i, got, tt.want)
+		}
+	}
+}
+func TestTextMateRecursiveInclude(t *testing.T) {
+	grammar := `{"scopeName": "source.demo", "patterns": [{"include": "#expr"}], "repository": {
+		"expr": {"patterns": [{"include": "#paren"}, {"match": "\\d+", "name": "constant.numeric"}]},
+		"paren": {"begin": "\\(", "end": "\\)", "name": "punctuation.section.brackets", "patterns": [{"include": "#expr"}, {"include": "$self"}]}}}`
+	want := "filetype: demo\ndetect: {}\nrules:\n- symbol.brackets:\n    start: \\(\n    end: \\)\n    rules: []\n- constant.number: \\d+\n"
+	if got := convertGrammar(t, grammar); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+func TestTextMateTooLarge(t *testing.T) {
+	repo := make([]string, 20)
+	for i := range repo {
+		repo[i] = fmt.Sprintf(`"r%d": {"patterns": [{"include": "#r%d"}, {"include": "#r%d"}]}`, i, i+1, i+1)
+	}
+	repo = append(repo, `"r20": {"match": "a", "name": "keyword"}`)
+	grammar := `{"scopeName": "source.demo", "patterns": [{"include": "#r0"}], "repository": {` + strings.Join(repo, ",") + `}}`
+	if _, err := ConvertTextMate([]byte(grammar)); err != errTooLarge {
+		t.Fatalf("err = %v, want errTooLarge", err)
+	}
+}
+func TestTextMateFileTypes(t *testing.T) {
+	out, err := ConvertTextMate([]byte(`{"scopeName": "source.makefile", "fileTypes": ["mk", "Makefile", "GNUmakefile"], "patterns": []}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := MakeHeaderYaml(out)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"Makefile", true},
+		{"/src/project/Makefile", true},
+		{"GNUmakefile", true},
+		{"rules.mk", true},
+		{"/src/rules.mk", true},
+		{"NotAMakefile", false},
+		{"Makefile.bak", false},
+		{"main.go", false},
+	}
+	for _, tt := range tests {
+		if got := header.MatchFileName(tt.path); got != tt.want {
+			t.Errorf("MatchFileName(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
\ No newline at end of file
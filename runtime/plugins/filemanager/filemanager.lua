@@ -3,6 +3,7 @@ local micro = import("micro")
 local config = import("micro/config")
 local shell = import("micro/shell")
 local buffer = import("micro/buffer")
+local util = import("micro/util")
 local os = import("os")
 local filepath = import("path/filepath")
 local function clear_messenger()
@@ -11,6 +12,7 @@ local tree_view = nil
 local current_dir = os.Getwd()
 local highest_visible_indent = 0
 local scanlist = {}
+local watched_dirs = {}
 local function new_listobj(p, d, o, i)
 	return {
 		["abspath"] = p,
@@ -158,6 +160,29 @@ local function scanlist_is_empty()
 		return false
 	end
 end
+local function sync_watches(close)
+	local wanted = {}
+	if not close then
+		wanted[current_dir] = true
+		for i = 1, #scanlist do
+			if scanlist[i].dirmsg == "-" then
+				wanted[scanlist[i].abspath] = true
+			end
+		end
+	end
+	for dir, _ in pairs(watched_dirs) do
+		if not wanted[dir] then
+			util.Unwatch(dir)
+			watched_dirs[dir] = nil
+		end
+	end
+	for dir, _ in pairs(wanted) do
+		if not watched_dirs[dir] then
+			util.Watch(dir)
+			watched_dirs[dir] = true
+		end
+	end
+end
 local function refresh_view()
 	clear_messenger()
 	if tree_view:GetView().Width < 30 then
@@ -183,6 +208,7 @@ local function refresh_view()
 		tree_view.Buf.EventHandler:Insert(buffer.Loc(0, i + 2), display_content)
 	end
     tree_view:Tab():Resize()
+	sync_watches(false)
 end
 local function move_cursor_top()
 	tree_view.Cursor.Loc.Y = 2
@@ -346,6 +372,39 @@ local function uncompress_target(y)
 		refresh_and_select()
 	end
 end
+local function rescan_tree()
+	local expanded = {}
+	for i = 1, #scanlist do
+		if scanlist[i].dirmsg == "-" then
+			expanded[scanlist[i].abspath] = true
+		end
+	end
+	local last_y = tree_view.Cursor.Loc.Y
+	local scan_results = get_scanlist(current_dir, 0, 0)
+	if scan_results ~= nil then
+		scanlist = scan_results
+	else
+		scanlist = {}
+	end
+	highest_visible_indent = 0
+	local i = 1
+	while i <= #scanlist do
+		if expanded[scanlist[i].abspath] and scanlist[i].dirmsg == "+" then
+			uncompress_target(i)
+		end
+		i = i + 1
+	end
+	refresh_view()
+	select_line(last_y)
+end
+function onFileChanged(path)
+	if tree_view == nil then
+		return
+	end
+	if watched_dirs[path] or watched_dirs[filepath.Dir(path)] then
+		rescan_tree()
+	end
+end
 local function path_exists(path)
 	local go_os = import("os")
 	local file_stat, stat_err = go_os.Stat(path)
@@ -487,6 +546,7 @@ local function open_tree()
 end
 local function close_tree()
 	if tree_view ~= nil then
+		sync_watches(true)
 		tree_view:Quit()
 		tree_view = nil
 		clear_messenger()